@@ -1,15 +1,23 @@
 package config
 
-import "github.com/ilyakaznacheev/cleanenv"
+import (
+	"time"
+
+	"github.com/ilyakaznacheev/cleanenv"
+)
 
 type Config struct {
-	UserNameDB string `env:"DB_USERNAME"`
-	UserPassDB string `env:"DB_USERPASS"`
-	ProtocolDB string `env:"DB_PROTOCOL"`
-	NameDB     string `env:"DB_DATABASENAME"`
-	PortDB     string `env:"DB_PORT"`
-	Port       string `env:"SERVERPORT"`
-	SessionKey string `env:"SESSION_KEY"`
+	UserNameDB   string        `env:"DB_USERNAME"`
+	UserPassDB   string        `env:"DB_USERPASS"`
+	ProtocolDB   string        `env:"DB_PROTOCOL"`
+	NameDB       string        `env:"DB_DATABASENAME"`
+	PortDB       string        `env:"DB_PORT"`
+	Port         string        `env:"SERVERPORT"`
+	SessionKey   string        `env:"SESSION_KEY"`
+	PingInterval time.Duration `env:"WS_PING_INTERVAL" env-default:"30s"` // Websocketでpingを送信する間隔
+	PongTimeout  time.Duration `env:"WS_PONG_TIMEOUT" env-default:"75s"`  // クライアントから何も受信しないまま切断するまでの時間
+	PinLimit     int           `env:"PIN_LIMIT" env-default:"50"`         // Roomごとにピン留めできるメッセージの数
+	InviteKey    string        `env:"INVITE_KEY" env-required:"true"`     // 招待リンクの署名に使う鍵。再起動やサーバー間で変わると発行済みの招待リンクが使えなくなるため必須
 }
 
 func NewConfig() (*Config, error) {
@@ -18,9 +18,10 @@ import (
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/httpserver"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/session"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/signedtoken"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/timefmt"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/ulid"
-	"golang.org/x/net/websocket"
+	"gorm.io/gorm"
 )
 
 var accesslogfile *os.File
@@ -41,10 +42,44 @@ func Run(cfg *config.Config, accessfile, chatLogFile *os.File) {
 	if err != nil {
 		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - ParticipatingRoom: %w", err))
 	}
+	migrateParticipatingRoomRoles(pg)
 	err = pg.Db.AutoMigrate(&domain.Room{})
 	if err != nil {
 		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - Room: %w", err))
 	}
+	migrateMessageClientIDIndex(pg)
+	err = pg.Db.AutoMigrate(&domain.Message{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - Message: %w", err))
+	}
+	backfillMessageSeq(pg)
+	backfillMessageToUserID(pg)
+	migrateMessageSearch(pg)
+	err = pg.Db.AutoMigrate(&domain.MessageRevision{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - MessageRevision: %w", err))
+	}
+	err = pg.Db.AutoMigrate(&domain.MessageReaction{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - MessageReaction: %w", err))
+	}
+	err = pg.Db.AutoMigrate(&domain.MessageMention{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - MessageMention: %w", err))
+	}
+	err = pg.Db.AutoMigrate(&domain.MessagePin{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - MessagePin: %w", err))
+	}
+	err = pg.Db.AutoMigrate(&domain.RoomInvite{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - RoomInvite: %w", err))
+	}
+	err = pg.Db.AutoMigrate(&domain.JoinRequest{})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - pg.Db.AutoMigrate - JoinRequest: %w", err))
+	}
+	migrateRoomIDs(pg)
 	insertTokumei(pg)
 
 	newSession := session.New()
@@ -53,12 +88,33 @@ func Run(cfg *config.Config, accessfile, chatLogFile *os.File) {
 	userRepo := repository.NewUserRepo(pg)
 	participatingRoomRepo := repository.NewParticipatingRoomRepo(pg)
 	roomRepo := repository.NewRoomRepo(pg)
+	messageRepo := repository.NewMessageRepo(pg)
+	messageReactionRepo := repository.NewMessageReactionRepo(pg)
+	messageMentionRepo := repository.NewMessageMentionRepo(pg)
+	messagePinRepo := repository.NewMessagePinRepo(pg)
+	roomInviteRepo := repository.NewRoomInviteRepo(pg)
+	joinRequestRepo := repository.NewJoinRequestRepo(pg)
 	userUsecase := usecase.NewUserUsecase(userRepo)
 	participatingRoomUsecase := usecase.NewParticipatingRoomUsecase(participatingRoomRepo)
 	roomUsecase := usecase.NewRoomUsecase(roomRepo)
+	messageUsecase := usecase.NewMessageUsecase(messageRepo)
+	messageReactionUsecase := usecase.NewMessageReactionUsecase(messageReactionRepo, messageRepo)
+	messageMentionUsecase := usecase.NewMessageMentionUsecase(messageMentionRepo)
+	messagePinUsecase := usecase.NewMessagePinUsecase(messagePinRepo, messageRepo, cfg.PinLimit)
+	roomInviteUsecase := usecase.NewRoomInviteUsecase(roomInviteRepo, signedtoken.New([]byte(cfg.InviteKey)))
+	joinRequestUsecase := usecase.NewJoinRequestUsecase(joinRequestRepo)
+	roomRoleUsecase := usecase.NewRoomRoleUsecase(participatingRoomRepo)
+
+	// Roomごとのメッセージ配信
+	rooms, err := getRooms(roomUsecase)
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - getRooms: %w", err))
+	}
+	hub := handler.NewHub(chatLogFile)
+	hub.Init(rooms)
 
 	// User
-	userHandler := handler.NewUserHandler(userUsecase, participatingRoomUsecase, roomUsecase, newSession)
+	userHandler := handler.NewUserHandler(userUsecase, participatingRoomUsecase, roomUsecase, messageUsecase, roomRoleUsecase, newSession, hub)
 	mux.Handle("/usermenu", loggingMiddleware(http.HandlerFunc(userHandler.Menu)))                 // usermenuページ
 	mux.Handle("/login", loggingMiddleware(http.HandlerFunc(userHandler.Login)))                   // ログインページ
 	mux.Handle("/signup", loggingMiddleware(http.HandlerFunc(userHandler.Signup)))                 // サインアップページ
@@ -68,21 +124,29 @@ func Run(cfg *config.Config, accessfile, chatLogFile *os.File) {
 	mux.Handle("/username", loggingMiddleware(http.HandlerFunc(userHandler.GetUserName)))          // 自身のユーザー名取得
 
 	// Room
-	rooms, err := getRooms(roomUsecase)
-	if err != nil {
-		log.Fatal(fmt.Errorf("app - Run - getRooms: %w", err))
-	}
-	roomHandler := handler.NewRoomHandler(userUsecase, participatingRoomUsecase, roomUsecase, newSession, rooms)
-	mux.Handle("/", loggingMiddleware(http.HandlerFunc(roomHandler.Top)))                    // roomtopページ
-	mux.Handle("/room", loggingMiddleware(http.HandlerFunc(roomHandler.Room)))               // Room内のページ
-	mux.Handle("/deleteroom", loggingMiddleware(http.HandlerFunc(roomHandler.Delete)))       // Room削除
-	mux.Handle("/rooms", loggingMiddleware(http.HandlerFunc(roomHandler.RoomsList)))         // Room一覧取得
-	mux.Handle("/joinrooms", loggingMiddleware(http.HandlerFunc(roomHandler.JoinRoomsList))) // 参加中のRoom一覧取得
+	roomHandler := handler.NewRoomHandler(userUsecase, participatingRoomUsecase, roomUsecase, messageUsecase, messageMentionUsecase, messagePinUsecase, roomInviteUsecase, joinRequestUsecase, roomRoleUsecase, newSession, hub)
+	mux.Handle("/", loggingMiddleware(http.HandlerFunc(roomHandler.Top)))                                        // roomtopページ
+	mux.Handle("/room", loggingMiddleware(http.HandlerFunc(roomHandler.Room)))                                   // Room内のページ
+	mux.Handle("/deleteroom", loggingMiddleware(http.HandlerFunc(roomHandler.Delete)))                           // Room削除
+	mux.Handle("/rooms", loggingMiddleware(http.HandlerFunc(roomHandler.RoomsList)))                             // Room一覧取得
+	mux.Handle("/joinrooms", loggingMiddleware(http.HandlerFunc(roomHandler.JoinRoomsList)))                     // 参加中のRoom一覧取得
+	mux.Handle("/rooms/{id}", loggingMiddleware(http.HandlerFunc(roomHandler.RoomInfo)))                         // Roomの名前、トピック、説明の取得と変更
+	mux.Handle("/rooms/{id}/pins", loggingMiddleware(http.HandlerFunc(roomHandler.Pins)))                        // Roomのピン留めされたメッセージ一覧取得
+	mux.Handle("/search", loggingMiddleware(http.HandlerFunc(roomHandler.Search)))                               // 参加中のRoomのメッセージ検索
+	mux.Handle("/direct", loggingMiddleware(http.HandlerFunc(roomHandler.Direct)))                               // ダイレクトメッセージの開始
+	mux.Handle("/rooms/{id}/invites", loggingMiddleware(http.HandlerFunc(roomHandler.Invites)))                  // Roomの招待リンク一覧取得と作成
+	mux.Handle("/rooms/{id}/invites/{inviteid}", loggingMiddleware(http.HandlerFunc(roomHandler.Invite)))        // 招待リンクの無効化
+	mux.Handle("/invite", loggingMiddleware(http.HandlerFunc(roomHandler.JoinByInvite)))                         // 招待リンクからRoomに参加
+	mux.Handle("/rooms/{id}/requests", loggingMiddleware(http.HandlerFunc(roomHandler.JoinRequests)))            // 参加リクエスト一覧取得と送信
+	mux.Handle("/rooms/{id}/requests/{requestid}", loggingMiddleware(http.HandlerFunc(roomHandler.JoinRequest))) // 参加リクエストの承認と却下
+	mux.Handle("/rooms/{id}/members", loggingMiddleware(http.HandlerFunc(roomHandler.Members)))                  // Roomの参加者と役割の一覧取得
+	mux.Handle("/rooms/{id}/members/{userid}", loggingMiddleware(http.HandlerFunc(roomHandler.Member)))          // 参加者の退出
+	mux.Handle("/rooms/{id}/members/{userid}/role", loggingMiddleware(http.HandlerFunc(roomHandler.MemberRole))) // 参加者の役割の変更
+	mux.Handle("/rooms/{id}/owner", loggingMiddleware(http.HandlerFunc(roomHandler.Owner)))                      // 作成者の役割の移譲
 
 	// websocket
-	websocketHandler := handler.NewWebsocketHandler(userUsecase, participatingRoomUsecase, roomUsecase, newSession, chatLogFile)
-	mux.Handle("/ws", websocket.Handler(websocketHandler.HandleConnection)) // メッセージWebsocket用
-	go websocketHandler.HandleMessages()                                    // goroutineとチャネルで常にメッセージを待つ
+	websocketHandler := handler.NewWebsocketHandler(userUsecase, participatingRoomUsecase, roomUsecase, messageUsecase, messageReactionUsecase, messageMentionUsecase, messagePinUsecase, roomRoleUsecase, newSession, hub, cfg.PingInterval, cfg.PongTimeout)
+	mux.Handle("/ws", http.HandlerFunc(websocketHandler.HandleConnection)) // メッセージWebsocket用
 
 	// static
 	staticFileDirectory := http.Dir("./static")
@@ -138,6 +202,24 @@ func insertTokumei(pg *postgres.Postgres) {
 	log.Println("匿名ユーザーが登録されました。")
 }
 
+// seqの追加前に保存されたメッセージにRoomごとの連番を割り当てる
+func backfillMessageSeq(pg *postgres.Postgres) {
+	err := pg.Db.Exec(`UPDATE messages SET seq = s.seq
+		FROM (SELECT id, ROW_NUMBER() OVER (PARTITION BY room_id ORDER BY id) AS seq FROM messages) AS s
+		WHERE messages.id = s.id AND messages.room_id IN (SELECT room_id FROM messages GROUP BY room_id HAVING MAX(seq) = 0)`).Error
+	if err != nil {
+		log.Printf("db.Exec backfill messages.seq error: %v\n", err)
+		return
+	}
+
+	err = pg.Db.Exec(`UPDATE rooms SET last_seq = m.max_seq
+		FROM (SELECT room_id, MAX(seq) AS max_seq FROM messages GROUP BY room_id) AS m
+		WHERE rooms.id = m.room_id AND rooms.last_seq < m.max_seq`).Error
+	if err != nil {
+		log.Printf("db.Exec backfill rooms.last_seq error: %v\n", err)
+	}
+}
+
 func getRooms(roomUsecase usecase.RoomUsecase) (*domain.Rooms, error) {
 	ctx := context.Background()
 	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
@@ -145,3 +227,100 @@ func getRooms(roomUsecase usecase.RoomUsecase) (*domain.Rooms, error) {
 
 	return roomUsecase.GetAll(ctx)
 }
+
+// 送信先のユーザーIDがないプライベートメッセージに、送信先のユーザー名からユーザーIDを設定する
+func backfillMessageToUserID(pg *postgres.Postgres) {
+	err := pg.Db.Exec(`UPDATE messages SET to_user_id = users.id
+		FROM users
+		WHERE messages.to_name = users.name AND messages.to_name <> '' AND messages.to_user_id = ''`).Error
+	if err != nil {
+		log.Printf("db.Exec backfill messages.to_user_id error: %v\n", err)
+	}
+}
+
+// 全文検索用のtsvector列とGINインデックスを作成する
+func migrateMessageSearch(pg *postgres.Postgres) {
+	err := pg.Db.Exec(`ALTER TABLE messages ADD COLUMN IF NOT EXISTS search_vector tsvector
+		GENERATED ALWAYS AS (to_tsvector('simple', markdown)) STORED`).Error
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - migrateMessageSearch - search_vector: %w", err))
+	}
+
+	err = pg.Db.Exec(`CREATE INDEX IF NOT EXISTS idx_messages_search_vector ON messages USING GIN (search_vector)`).Error
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - migrateMessageSearch - idx_messages_search_vector: %w", err))
+	}
+}
+
+// 4桁の数字だった既存のRoomのIDをULIDに置き換える
+// 以前のIDはスラッグとして残し、古いURLでも開けるようにする
+func migrateRoomIDs(pg *postgres.Postgres) {
+	var ids []string
+	err := pg.Db.Model(&domain.Room{}).Where("LENGTH(id) <> ?", len(ulid.NewULID())).Pluck("id", &ids).Error
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - migrateRoomIDs - Pluck: %w", err))
+	}
+
+	tables := []string{"participating_rooms", "messages", "message_revisions", "message_reactions", "message_mentions", "message_pins", "room_invites", "join_requests"}
+	for _, oldID := range ids {
+		newID := ulid.NewULID()
+		err = pg.Db.Transaction(func(tx *gorm.DB) error {
+			for _, table := range tables {
+				err := tx.Exec("UPDATE "+table+" SET room_id = ? WHERE room_id = ?", newID, oldID).Error
+				if err != nil {
+					return fmt.Errorf("%s: %w", table, err)
+				}
+			}
+			return tx.Exec("UPDATE rooms SET id = ?, slug = COALESCE(slug, ?) WHERE id = ?", newID, oldID, oldID).Error
+		})
+		if err != nil {
+			log.Fatal(fmt.Errorf("app - Run - migrateRoomIDs - %s: %w", oldID, err))
+		}
+		log.Printf("room %s migrated to %s\n", oldID, newID)
+	}
+}
+
+// clientidの重複排除を一意インデックスで行うため、ユーザーとclientidのインデックスを削除し、
+// 既に重複しているメッセージは最も古いもの以外のclientidを空にする
+func migrateMessageClientIDIndex(pg *postgres.Postgres) {
+	if !pg.Db.Migrator().HasIndex(&domain.Message{}, "idx_messages_user_client") {
+		return
+	}
+
+	err := pg.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec(`UPDATE messages SET client_id = ''
+			WHERE id IN (
+				SELECT id FROM (
+					SELECT id, ROW_NUMBER() OVER (PARTITION BY room_id, user_id, client_id ORDER BY id) AS n
+					FROM messages WHERE client_id <> ''
+				) duplicates WHERE n > 1
+			)`).Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropIndex(&domain.Message{}, "idx_messages_user_client")
+	})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - migrateMessageClientIDIndex: %w", err))
+	}
+	log.Println("idx_messages_user_client migrated to idx_messages_room_user_client")
+}
+
+// 作成者かどうかのis_master列を役割に置き換える
+func migrateParticipatingRoomRoles(pg *postgres.Postgres) {
+	if !pg.Db.Migrator().HasColumn(&domain.ParticipatingRoom{}, "is_master") {
+		return
+	}
+
+	err := pg.Db.Transaction(func(tx *gorm.DB) error {
+		err := tx.Exec("UPDATE participating_rooms SET role = ? WHERE is_master", domain.RoleOwner).Error
+		if err != nil {
+			return err
+		}
+		return tx.Migrator().DropColumn(&domain.ParticipatingRoom{}, "is_master")
+	})
+	if err != nil {
+		log.Fatal(fmt.Errorf("app - Run - migrateParticipatingRoomRoles: %w", err))
+	}
+	log.Println("is_master migrated to role")
+}
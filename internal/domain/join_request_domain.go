@@ -0,0 +1,35 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// 参加リクエストの状態
+const (
+	JoinRequestPending  = "pending"  // Roomの作成者の承認待ち
+	JoinRequestApproved = "approved" // 承認済み
+	JoinRequestDenied   = "denied"   // 却下済み
+)
+
+var (
+	// 参加リクエストを受け付けていないRoomに参加リクエストを送信した
+	ErrJoinRequestNotAllowed = errors.New("このRoomには参加リクエストを送信できません。")
+	// 承認待ちの参加リクエストが既にある
+	ErrJoinRequestExists = errors.New("既に参加リクエストを送信しています。")
+	// 承認または却下済みの参加リクエストを処理しようとした
+	ErrJoinRequestDecided = errors.New("この参加リクエストは既に処理されています。")
+)
+
+// 参加承認制のRoomへの参加リクエスト
+type JoinRequest struct {
+	ID        string `gorm:"primaryKey"` // ULID
+	RoomID    string `gorm:"index"`
+	UserID    string `gorm:"index"`
+	UserName  string
+	Status    string `gorm:"not null;default:pending"`
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+type JoinRequests []JoinRequest
@@ -0,0 +1,145 @@
+package domain
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	messageLengthMax  = 10000
+	clientIDLengthMax = 64
+)
+
+var (
+	// 同じclientidのメッセージが既に保存されている
+	ErrDuplicateMessage = errors.New("同じclientidのメッセージは既に送信されています。")
+	// メッセージの送信者でもRoomの作成者でもないユーザーが編集や削除をしようとした
+	ErrMessageForbidden = errors.New("このメッセージを変更する権限がありません。")
+	// 履歴やスレッドの取得位置のbeforeがメッセージIDではない
+	ErrInvalidCursor = errors.New("before の値が不正です。")
+	// 削除済みのメッセージを編集や削除しようとした
+	ErrMessageDeleted = errors.New("このメッセージは削除されています。")
+	// スレッドの返信先のメッセージがRoomに存在しない
+	ErrParentNotFound = errors.New("返信先のメッセージが見つかりません。")
+	// プライベートメッセージはスレッドにできない
+	ErrPrivateThread = errors.New("プライベートメッセージはスレッドにできません。")
+	// プライベートメッセージの送信先のユーザーが存在しない
+	ErrWhisperTargetNotFound = errors.New("送信先のユーザーが見つかりません。")
+	// プライベートメッセージの送信先のユーザーがRoomに参加していない
+	ErrWhisperTargetNotMember = errors.New("送信先のユーザーはこのRoomに参加していません。")
+	// プライベートメッセージの送信先のユーザーがRoomに接続していない
+	ErrWhisperTargetOffline = errors.New("送信先のユーザーはオンラインではありません。")
+)
+
+// Roomに送信されたチャットメッセージ
+type Message struct {
+	ID         string `gorm:"primaryKey"`
+	RoomID     string `gorm:"index;index:idx_messages_room_seq,priority:1;uniqueIndex:idx_messages_room_user_client,priority:1"`
+	Seq        int64  `gorm:"index:idx_messages_room_seq,priority:2"` // Roomごとに単調増加する番号
+	UserID     string `gorm:"uniqueIndex:idx_messages_room_user_client,priority:2"`
+	ClientID   string `gorm:"uniqueIndex:idx_messages_room_user_client,priority:3,where:client_id <> ''"` // 再送時の重複排除のためにクライアントが付与するID。Room、ユーザーごとに一意
+	UserName   string
+	ToName     string
+	ToUserID   string `gorm:"index;not null;default:''"` // プライベートメッセージの送信先のユーザーID。配信先の判定に使う
+	ParentID   string `gorm:"index;not null;default:''"` // スレッドへの返信であれば返信先のメッセージのID
+	AlsoToRoom bool   `gorm:"not null;default:false"`    // スレッドへの返信をRoomのタイムラインにも表示するかどうか
+	Markdown   string
+	HTML       string
+	Edited     bool            // 編集されたかどうか。編集前の内容はMessageRevisionに残す
+	Deleted    bool            // 削除されたかどうか。削除前の内容はMessageRevisionに残す
+	Mentions   MessageMentions `gorm:"-"` // 保存時にメンションとして記録するユーザー
+	CreatedAt  time.Time
+	UpdatedAt  time.Time
+}
+
+type Messages []Message
+
+// スレッドの返信の概要
+type ThreadSummary struct {
+	ParentID     string
+	ReplyCount   int64
+	LastReplyAt  time.Time
+	Participants []string // 返信したユーザー名
+}
+
+type ThreadSummaries []ThreadSummary
+
+// スレッドへの返信かどうか
+func (m *Message) IsReply() bool {
+	return m.ParentID != ""
+}
+
+// Roomのタイムラインに表示するかどうか。スレッドへの返信は送信者が指定した場合のみ表示する
+func (m *Message) InTimeline() bool {
+	return !m.IsReply() || m.AlsoToRoom
+}
+
+// userがメッセージを閲覧できるかどうか。プライベートメッセージは送信者と送信先のみ閲覧できる
+func (m *Message) VisibleTo(userID string) bool {
+	return m.ToName == "" || m.UserID == userID || m.ToUserID == userID
+}
+
+// メッセージの@メンションのうち、Roomの参加者をメンションとして設定する
+// 送信者自身と、プライベートメッセージの送信先以外のユーザーはメンションしない
+func (m *Message) SetMentions(participants Users) {
+	names := make([]string, 0, len(participants))
+	users := make(map[string]User, len(participants))
+	for _, user := range participants {
+		names = append(names, user.Name)
+		users[user.Name] = user
+	}
+
+	m.Mentions = nil
+	for _, name := range FindMentions(m.Markdown, names) {
+		user := users[name]
+		if user.ID == m.UserID {
+			continue
+		}
+		if m.ToName != "" && user.ID != m.ToUserID {
+			continue
+		}
+		m.Mentions = append(m.Mentions, MessageMention{MessageID: m.ID, UserID: user.ID, UserName: user.Name, RoomID: m.RoomID})
+	}
+}
+
+// userがメッセージを編集、削除できるかどうか。送信者本人か、他のユーザーのメッセージを管理できる役割の参加者のみ変更できる
+func (m *Message) CanModify(userID string, moderator bool) error {
+	if m.Deleted {
+		return ErrMessageDeleted
+	}
+
+	if m.UserID != userID && !moderator {
+		return ErrMessageForbidden
+	}
+
+	return nil
+}
+
+func (m *Message) Validate() error {
+	if m.RoomID == "" {
+		return errors.New("roomid の値が不正です。")
+	}
+
+	if m.UserID == "" || m.UserName == "" {
+		return errors.New("送信者の値が不正です。")
+	}
+
+	if m.Markdown == "" {
+		return errors.New("メッセージが空です。")
+	}
+
+	if utf8.RuneCountInString(m.Markdown) > messageLengthMax {
+		return errors.New("メッセージは10000文字以内にしてください")
+	}
+
+	if len(m.ClientID) > clientIDLengthMax {
+		return errors.New("clientid は64文字以内にしてください")
+	}
+
+	if m.IsReply() && m.ToName != "" {
+		return ErrPrivateThread
+	}
+
+	return nil
+}
@@ -0,0 +1,99 @@
+package domain
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// メッセージで@メンションされたユーザー。Roomを開いていない間にメンションされたものを未読として数える
+type MessageMention struct {
+	MessageID string `gorm:"primaryKey"`
+	UserID    string `gorm:"primaryKey;index:idx_message_mentions_user_read,priority:1"`
+	UserName  string
+	RoomID    string `gorm:"index"`
+	Read      bool   `gorm:"index:idx_message_mentions_user_read,priority:2"`
+	CreatedAt time.Time
+}
+
+type MessageMentions []MessageMention
+
+// Roomごとの未読のメンションの数
+type MentionCount struct {
+	RoomID string
+	Count  int64
+}
+
+type MentionCounts []MentionCount
+
+// 文字列を@メンションとそれ以外に分けたもの。Nameが空でなければ@メンション
+type MentionSegment struct {
+	Text string
+	Name string
+}
+
+// textを、namesのユーザー名への@メンションとそれ以外の部分に分割する
+// ユーザー名には空白なども使えるため、@の後ろに続く最も長いユーザー名を採用する
+func SplitMentions(text string, names []string) []MentionSegment {
+	var segments []MentionSegment
+	start := 0
+	for i := 0; i < len(text); i++ {
+		if text[i] != '@' || !isMentionStart(text, i) {
+			continue
+		}
+
+		name := longestMentionName(text[i+1:], names)
+		if name == "" {
+			continue
+		}
+		if start < i {
+			segments = append(segments, MentionSegment{Text: text[start:i]})
+		}
+		end := i + 1 + len(name)
+		segments = append(segments, MentionSegment{Text: text[i:end], Name: name})
+		start = end
+		i = end - 1
+	}
+	if start < len(text) {
+		segments = append(segments, MentionSegment{Text: text[start:]})
+	}
+	return segments
+}
+
+// textの@メンションのうち、namesに含まれるユーザー名を出現順に重複なく返す
+func FindMentions(text string, names []string) []string {
+	var found []string
+	seen := make(map[string]bool)
+	for _, segment := range SplitMentions(text, names) {
+		if segment.Name != "" && !seen[segment.Name] {
+			seen[segment.Name] = true
+			found = append(found, segment.Name)
+		}
+	}
+	return found
+}
+
+// @の前が文字や数字でなければメンションとみなす。メールアドレスなどを除くため
+func isMentionStart(text string, i int) bool {
+	if i == 0 {
+		return true
+	}
+	r, _ := utf8.DecodeLastRuneInString(text[:i])
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// textの先頭に一致するユーザー名のうち最も長いものを返す。ユーザー名の直後は文字や数字であってはならない
+func longestMentionName(text string, names []string) string {
+	longest := ""
+	for _, name := range names {
+		if name == "" || len(name) <= len(longest) || !strings.HasPrefix(text, name) {
+			continue
+		}
+		if r, _ := utf8.DecodeRuneInString(text[len(name):]); r != utf8.RuneError && (unicode.IsLetter(r) || unicode.IsDigit(r)) {
+			continue
+		}
+		longest = name
+	}
+	return longest
+}
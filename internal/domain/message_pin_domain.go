@@ -0,0 +1,27 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// Roomにピン留めできるメッセージの数の上限を超えた
+	ErrTooManyPins = errors.New("このRoomにはこれ以上メッセージをピン留めできません。")
+	// 既にピン留めされているメッセージをピン留めしようとした
+	ErrAlreadyPinned = errors.New("このメッセージは既にピン留めされています。")
+	// プライベートメッセージをピン留めしようとした
+	ErrPrivatePin = errors.New("プライベートメッセージはピン留めできません。")
+	// Roomの作成者以外がピン留めしようとした
+	ErrPinForbidden = errors.New("ピン留めする権限がありません。")
+)
+
+// Roomにピン留めされたメッセージ
+type MessagePin struct {
+	MessageID string `gorm:"primaryKey"`
+	RoomID    string `gorm:"index"`
+	UserID    string // ピン留めしたユーザー
+	CreatedAt time.Time
+}
+
+type MessagePins []MessagePin
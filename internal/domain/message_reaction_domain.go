@@ -0,0 +1,163 @@
+package domain
+
+import (
+	"errors"
+	"time"
+	"unicode/utf8"
+)
+
+// 1つの絵文字として受け付ける最大の文字数。ZWJで結合された絵文字を考慮する
+const emojiLengthMax = 16
+
+const (
+	zeroWidthJoiner    = '\u200d'
+	variationSelector  = '\ufe0f'
+	combiningKeycap    = '\u20e3'
+	cancelTag          = '\U000e007f'
+	regionalIndicatorA = '\U0001f1e6'
+	regionalIndicatorZ = '\U0001f1ff'
+	skinToneLight      = '\U0001f3fb'
+	skinToneDark       = '\U0001f3ff'
+)
+
+// 単独で絵文字として表示される文字の範囲（Emoji_Presentation）
+var emojiPresentationRanges = [][2]rune{
+	{0x231a, 0x231b}, {0x23e9, 0x23ec}, {0x23f0, 0x23f0}, {0x23f3, 0x23f3},
+	{0x25fd, 0x25fe}, {0x2614, 0x2615}, {0x2648, 0x2653}, {0x267f, 0x267f},
+	{0x2693, 0x2693}, {0x26a1, 0x26a1}, {0x26aa, 0x26ab}, {0x26bd, 0x26be},
+	{0x26c4, 0x26c5}, {0x26ce, 0x26ce}, {0x26d4, 0x26d4}, {0x26ea, 0x26ea},
+	{0x26f2, 0x26f3}, {0x26f5, 0x26f5}, {0x26fa, 0x26fa}, {0x26fd, 0x26fd},
+	{0x2705, 0x2705}, {0x270a, 0x270b}, {0x2728, 0x2728}, {0x274c, 0x274c},
+	{0x274e, 0x274e}, {0x2753, 0x2755}, {0x2757, 0x2757}, {0x2795, 0x2797},
+	{0x27b0, 0x27b0}, {0x27bf, 0x27bf}, {0x2b1b, 0x2b1c}, {0x2b50, 0x2b50},
+	{0x2b55, 0x2b55}, {0x1f004, 0x1f004}, {0x1f0cf, 0x1f0cf}, {0x1f18e, 0x1f18e},
+	{0x1f191, 0x1f19a}, {0x1f201, 0x1f201}, {0x1f21a, 0x1f21a}, {0x1f22f, 0x1f22f},
+	{0x1f232, 0x1f236}, {0x1f238, 0x1f23a}, {0x1f250, 0x1f251}, {0x1f300, 0x1f3fa},
+	{0x1f400, 0x1f64f}, {0x1f680, 0x1f6ff}, {0x1f7e0, 0x1f7f0}, {0x1f90c, 0x1f9ff},
+	{0x1fa70, 0x1faff},
+}
+
+// 異体字セレクタか肌の色が続く場合のみ絵文字として表示される文字の範囲（Extended_Pictographicのうち文字として表示されるもの）
+var emojiTextDefaultRanges = [][2]rune{
+	{0x00a9, 0x00a9}, {0x00ae, 0x00ae}, {0x203c, 0x203c}, {0x2049, 0x2049},
+	{0x2122, 0x2122}, {0x2139, 0x2139}, {0x2194, 0x2199}, {0x21a9, 0x21aa},
+	{0x2328, 0x2328}, {0x23cf, 0x23cf}, {0x23ed, 0x23ef}, {0x23f1, 0x23f2},
+	{0x23f8, 0x23fa}, {0x24c2, 0x24c2}, {0x25aa, 0x25ab}, {0x25b6, 0x25b6},
+	{0x25c0, 0x25c0}, {0x25fb, 0x25fc}, {0x2600, 0x27bf}, {0x2934, 0x2935},
+	{0x2b05, 0x2b07}, {0x3030, 0x3030}, {0x303d, 0x303d}, {0x3297, 0x3297},
+	{0x3299, 0x3299}, {0x1f170, 0x1f171}, {0x1f17e, 0x1f17f}, {0x1f202, 0x1f202},
+	{0x1f237, 0x1f237},
+}
+
+// 1つのメッセージに付けられる絵文字の種類数の上限を超えた
+var ErrTooManyReactions = errors.New("このメッセージにはこれ以上の種類のリアクションを付けられません。")
+
+// メッセージへの絵文字のリアクション。同じユーザーが同じ絵文字を付けられるのは1回のみ
+type MessageReaction struct {
+	MessageID string `gorm:"primaryKey"`
+	UserID    string `gorm:"primaryKey"`
+	Emoji     string `gorm:"primaryKey"`
+	RoomID    string `gorm:"index"`
+	CreatedAt time.Time
+}
+
+type MessageReactions []MessageReaction
+
+func (r *MessageReaction) Validate() error {
+	if r.MessageID == "" || r.RoomID == "" {
+		return errors.New("メッセージの値が不正です。")
+	}
+
+	if r.UserID == "" {
+		return errors.New("ユーザーの値が不正です。")
+	}
+
+	if utf8.RuneCountInString(r.Emoji) > emojiLengthMax || !isSingleEmoji([]rune(r.Emoji)) {
+		return errors.New("絵文字の値が不正です。")
+	}
+
+	return nil
+}
+
+// 1つの絵文字かどうか。国旗、キーキャップ、肌の色や異体字セレクタ、ZWJで結合された絵文字も1つとして扱う
+func isSingleEmoji(rs []rune) bool {
+	// 国旗は地域指示記号2つ
+	if len(rs) == 2 && isRegionalIndicator(rs[0]) && isRegionalIndicator(rs[1]) {
+		return true
+	}
+
+	// キーキャップは数字、#、*の後に異体字セレクタとキーキャップ記号
+	if len(rs) >= 2 && len(rs) <= 3 && rs[len(rs)-1] == combiningKeycap {
+		if (rs[0] >= '0' && rs[0] <= '9') || rs[0] == '#' || rs[0] == '*' {
+			return len(rs) == 2 || rs[1] == variationSelector
+		}
+		return false
+	}
+
+	// 絵文字をZWJで結合したもの。各絵文字の後には異体字セレクタ、肌の色、タグ（地域の旗）を付けられる
+	// ©や▶のように通常は文字として表示されるものは、異体字セレクタか肌の色が続く場合のみ絵文字とする
+	i := 0
+	for {
+		if i >= len(rs) {
+			return false
+		}
+		presented := inRanges(rs[i], emojiPresentationRanges)
+		if !presented && !inRanges(rs[i], emojiTextDefaultRanges) {
+			return false
+		}
+		i++
+		if i < len(rs) && rs[i] == variationSelector {
+			presented = true
+			i++
+		}
+		if i < len(rs) && rs[i] >= skinToneLight && rs[i] <= skinToneDark {
+			presented = true
+			i++
+		}
+		if !presented {
+			return false
+		}
+		if i < len(rs) && isTag(rs[i]) {
+			for i < len(rs) && isTag(rs[i]) && rs[i] != cancelTag {
+				i++
+			}
+			if i >= len(rs) || rs[i] != cancelTag {
+				return false
+			}
+			i++
+		}
+		if i == len(rs) {
+			return true
+		}
+		if rs[i] != zeroWidthJoiner {
+			return false
+		}
+		i++
+	}
+}
+
+func inRanges(c rune, ranges [][2]rune) bool {
+	for _, r := range ranges {
+		if c >= r[0] && c <= r[1] {
+			return true
+		}
+	}
+	return false
+}
+
+func isRegionalIndicator(c rune) bool {
+	return c >= regionalIndicatorA && c <= regionalIndicatorZ
+}
+
+func isTag(c rune) bool {
+	return c >= 0xe0020 && c <= cancelTag
+}
+
+// メッセージごと絵文字ごとのリアクションの数
+type ReactionCount struct {
+	MessageID string
+	Emoji     string
+	Count     int64
+}
+
+type ReactionCounts []ReactionCount
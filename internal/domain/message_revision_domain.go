@@ -0,0 +1,16 @@
+package domain
+
+import "time"
+
+// 編集、削除される前のメッセージの内容
+type MessageRevision struct {
+	ID        string `gorm:"primaryKey"`
+	MessageID string `gorm:"index"`
+	RoomID    string `gorm:"index"`
+	EditorID  string // 編集、削除したユーザー
+	Markdown  string
+	HTML      string
+	CreatedAt time.Time
+}
+
+type MessageRevisions []MessageRevision
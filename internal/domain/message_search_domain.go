@@ -0,0 +1,29 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+// 検索語やカーソルが不正
+var ErrInvalidSearch = errors.New("検索条件が不正です。")
+
+// 検索結果のスニペットで一致した語を囲む区切り文字
+// メッセージの本文に含まれにくい私用領域の文字を使い、HTMLエスケープした後に<mark>に置き換える
+const (
+	SearchHighlightStart = "\uE000"
+	SearchHighlightStop  = "\uE001"
+)
+
+// メッセージの検索結果
+type MessageSearchResult struct {
+	ID        string
+	RoomID    string
+	UserName  string
+	ToName    string
+	ParentID  string
+	Snippet   string // 一致した語の前後の本文
+	CreatedAt time.Time
+}
+
+type MessageSearchResults []MessageSearchResult
@@ -4,13 +4,32 @@ import "time"
 
 // ユーザーの参加中Room情報
 type ParticipatingRoom struct {
-	ID        int `gorm:"unique"`
-	RoomID    string
-	IsMaster  bool
-	UserID    string `gorm:"foreignKey:UserID;references:ID"`
-	User      User
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID          int `gorm:"unique"`
+	RoomID      string
+	Role        string `gorm:"not null;default:member"` // Roomでの役割
+	UserID      string `gorm:"foreignKey:UserID;references:ID"`
+	User        User
+	LastReadSeq int64 `gorm:"not null;default:0"` // 最後に読んだメッセージのseq
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 type ParticipatingRooms []ParticipatingRoom
+
+// 参加中のRoomの未読メッセージ数と最後の発言日時
+type RoomActivity struct {
+	RoomID         string
+	UnreadCount    int64
+	LastActivityAt *time.Time // メッセージがなければnil
+}
+
+type RoomActivities []RoomActivity
+
+// ダイレクトメッセージのRoomと相手のユーザー
+type DirectPeer struct {
+	RoomID   string
+	UserID   string
+	UserName string
+}
+
+type DirectPeers []DirectPeer
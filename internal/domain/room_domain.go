@@ -1,12 +1,120 @@
 package domain
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Roomの種類
+const (
+	RoomKindGroup  = "group"  // 複数のユーザーが参加するRoom
+	RoomKindDirect = "direct" // 2人のユーザーのダイレクトメッセージ
+)
+
+// グループのRoomの公開範囲
+const (
+	RoomVisibilityPublic  = "public"  // 誰でも参加できる
+	RoomVisibilityInvite  = "invite"  // 招待リンクからのみ参加できる
+	RoomVisibilityRequest = "request" // 参加リクエストをRoomの作成者が承認すると参加できる
+)
+
+const (
+	roomNameLengthMax        = 50
+	roomTopicLengthMax       = 100
+	roomDescriptionLengthMax = 500
+	// ULID(26文字)と区別できるよう、スラッグはそれより短くする
+	roomSlugLengthMax = 24
+)
+
+// スラッグに使える文字。小文字の英数字を-でつなげる
+var roomSlugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+var (
+	// 自分自身をダイレクトメッセージの相手に指定した
+	ErrDirectSelf = errors.New("自分自身とのダイレクトメッセージは作成できません。")
+	// Roomの名前、トピック、説明が不正
+	ErrInvalidRoom = errors.New("Roomの設定が不正です。")
+	// 他のRoomが既に使っているスラッグを指定した
+	ErrSlugTaken = errors.New("そのスラッグは既に使われています。")
+)
 
 // Room
 type Room struct {
-	ID        string `gorm:"unique"`
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID          string  `gorm:"unique"` // ULID
+	Kind        string  `gorm:"not null;default:group"`
+	DirectKey   *string `gorm:"uniqueIndex"` // ダイレクトメッセージの2人のユーザーIDから作るキー。グループのRoomはnil
+	Slug        *string `gorm:"uniqueIndex"` // URLでIDの代わりに使える短い名前。設定しない場合はnil
+	Name        string  `gorm:"not null;default:''"`
+	Topic       string  `gorm:"not null;default:''"` // Roomで今話している話題
+	Description string  `gorm:"not null;default:''"`
+	Visibility  string  `gorm:"not null;default:public"` // 参加できるユーザーの範囲
+	LastSeq     int64   // Roomに最後に保存されたメッセージのseq
+	CreatedAt   time.Time
+	UpdatedAt   time.Time
 }
 
 type Rooms []Room
+
+// ダイレクトメッセージのRoomかどうか
+func (r *Room) IsDirect() bool {
+	return r.Kind == RoomKindDirect
+}
+
+// 参加者以外も参加できるRoomかどうか
+func (r *Room) IsPublic() bool {
+	return !r.IsDirect() && (r.Visibility == "" || r.Visibility == RoomVisibilityPublic)
+}
+
+// 2人のユーザーのダイレクトメッセージのRoomを一意に決めるキー。ユーザーの順番によらず同じになる
+func DirectKey(userID, peerID string) string {
+	if userID > peerID {
+		userID, peerID = peerID, userID
+	}
+	return userID + ":" + peerID
+}
+
+// 名前、トピック、説明、スラッグの前後の空白を取り除き、長さと形式を確認する
+// 空のスラッグはnilに、空の公開範囲はpublicにする
+func (r *Room) Validate() error {
+	r.Name = strings.TrimSpace(r.Name)
+	r.Topic = strings.TrimSpace(r.Topic)
+	r.Description = strings.TrimSpace(r.Description)
+	if r.Slug != nil {
+		slug := strings.ToLower(strings.TrimSpace(*r.Slug))
+		if slug == "" {
+			r.Slug = nil
+		} else {
+			r.Slug = &slug
+		}
+	}
+
+	if r.Name == "" || utf8.RuneCountInString(r.Name) > roomNameLengthMax {
+		return fmt.Errorf("%w 名前は1文字以上%d文字以内にしてください。", ErrInvalidRoom, roomNameLengthMax)
+	}
+
+	if utf8.RuneCountInString(r.Topic) > roomTopicLengthMax {
+		return fmt.Errorf("%w トピックは%d文字以内にしてください。", ErrInvalidRoom, roomTopicLengthMax)
+	}
+
+	if utf8.RuneCountInString(r.Description) > roomDescriptionLengthMax {
+		return fmt.Errorf("%w 説明は%d文字以内にしてください。", ErrInvalidRoom, roomDescriptionLengthMax)
+	}
+
+	if r.Slug != nil && (len(*r.Slug) > roomSlugLengthMax || !roomSlugPattern.MatchString(*r.Slug)) {
+		return fmt.Errorf("%w スラッグは%d文字以内の英小文字、数字、-にしてください。", ErrInvalidRoom, roomSlugLengthMax)
+	}
+
+	switch r.Visibility {
+	case "":
+		r.Visibility = RoomVisibilityPublic
+	case RoomVisibilityPublic, RoomVisibilityInvite, RoomVisibilityRequest:
+	default:
+		return fmt.Errorf("%w 公開範囲の指定が正しくありません。", ErrInvalidRoom)
+	}
+
+	return nil
+}
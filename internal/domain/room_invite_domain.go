@@ -0,0 +1,59 @@
+package domain
+
+import (
+	"errors"
+	"time"
+)
+
+const (
+	// 招待リンクの有効期間の上限
+	RoomInviteTTLMax = 30 * 24 * time.Hour
+	// 招待リンクの使用回数の上限に指定できる最大値
+	roomInviteMaxUsesMax = 1000
+)
+
+var (
+	// 署名が一致しない、または存在しない招待リンク
+	ErrInvalidInvite = errors.New("招待リンクが無効です。")
+	// 有効期限が切れた招待リンク
+	ErrInviteExpired = errors.New("招待リンクの有効期限が切れています。")
+	// 使用回数の上限に達した招待リンク
+	ErrInviteUsedUp = errors.New("招待リンクの使用回数の上限に達しています。")
+	// 招待リンクの有効期間や使用回数の指定が不正
+	ErrInvalidInviteSetting = errors.New("招待リンクの設定が不正です。")
+)
+
+// Roomへの招待リンク
+type RoomInvite struct {
+	ID        string `gorm:"primaryKey"` // ULID
+	RoomID    string `gorm:"index"`
+	UserID    string // 招待リンクを作成したユーザー
+	MaxUses   int    `gorm:"not null;default:0"` // 使用回数の上限。0なら無制限
+	Uses      int    `gorm:"not null;default:0"`
+	ExpiresAt time.Time
+	CreatedAt time.Time
+}
+
+type RoomInvites []RoomInvite
+
+// 有効期間と使用回数の上限を確認する
+func (i *RoomInvite) Validate(ttl time.Duration) error {
+	if ttl <= 0 || ttl > RoomInviteTTLMax {
+		return ErrInvalidInviteSetting
+	}
+	if i.MaxUses < 0 || i.MaxUses > roomInviteMaxUsesMax {
+		return ErrInvalidInviteSetting
+	}
+	return nil
+}
+
+// 招待リンクが今使えるかどうか。使えなければその理由のエラーを返す
+func (i *RoomInvite) Usable(now time.Time) error {
+	if !now.Before(i.ExpiresAt) {
+		return ErrInviteExpired
+	}
+	if i.MaxUses > 0 && i.Uses >= i.MaxUses {
+		return ErrInviteUsedUp
+	}
+	return nil
+}
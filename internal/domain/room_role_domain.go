@@ -0,0 +1,95 @@
+package domain
+
+import "errors"
+
+// Roomの参加者の役割。上にあるほど強い
+const (
+	RoleOwner     = "owner"     // Roomの作成者。すべての操作ができる
+	RoleAdmin     = "admin"     // Roomの削除以外のすべての操作ができる
+	RoleModerator = "moderator" // メッセージの管理と参加者の退出ができる
+	RoleMember    = "member"    // メッセージの送信ができる
+	RoleReadOnly  = "readonly"  // 閲覧のみできる
+)
+
+// 役割によって許可される操作
+type Permission string
+
+const (
+	PermPost           Permission = "post"            // メッセージの送信
+	PermDeleteMessages Permission = "delete_messages" // 他のユーザーのメッセージの編集と削除
+	PermPin            Permission = "pin"             // メッセージのピン留め
+	PermKick           Permission = "kick"            // 自分より弱い役割の参加者の退出
+	PermInvite         Permission = "invite"          // 招待リンクの作成と参加リクエストの承認
+	PermEditSettings   Permission = "edit_settings"   // Roomの名前、トピック、説明、公開範囲の変更
+	PermManageRoles    Permission = "manage_roles"    // 自分より弱い役割の参加者の役割の変更
+	PermDeleteRoom     Permission = "delete_room"     // Roomの削除
+	PermTransferOwner  Permission = "transfer_owner"  // 作成者の役割を他の参加者に譲る
+)
+
+// 役割ごとに許可される操作
+var rolePermissions = map[string][]Permission{
+	RoleOwner:     {PermPost, PermDeleteMessages, PermPin, PermKick, PermInvite, PermEditSettings, PermManageRoles, PermDeleteRoom, PermTransferOwner},
+	RoleAdmin:     {PermPost, PermDeleteMessages, PermPin, PermKick, PermInvite, PermEditSettings, PermManageRoles},
+	RoleModerator: {PermPost, PermDeleteMessages, PermPin, PermKick},
+	RoleMember:    {PermPost},
+	RoleReadOnly:  {},
+}
+
+// 役割の強さ。大きいほど強い
+var roleRanks = map[string]int{
+	RoleOwner:     5,
+	RoleAdmin:     4,
+	RoleModerator: 3,
+	RoleMember:    2,
+	RoleReadOnly:  1,
+}
+
+var (
+	// 役割で許可されていない操作をしようとした
+	ErrForbidden = errors.New("この操作を行う権限がありません。")
+	// 存在しない役割、または変更で指定できない役割を指定した
+	ErrInvalidRole = errors.New("役割の指定が正しくありません。")
+)
+
+// 存在する役割かどうか
+func ValidRole(role string) bool {
+	_, ok := roleRanks[role]
+	return ok
+}
+
+// 役割で操作が許可されているかどうか
+func (p *ParticipatingRoom) Can(perm Permission) bool {
+	for _, allowed := range rolePermissions[p.Role] {
+		if allowed == perm {
+			return true
+		}
+	}
+	return false
+}
+
+// Roomの作成者かどうか
+func (p *ParticipatingRoom) IsOwner() bool {
+	return p.Role == RoleOwner
+}
+
+// 指定した役割より強い役割かどうか
+func (p *ParticipatingRoom) Outranks(role string) bool {
+	return roleRanks[p.Role] > roleRanks[role]
+}
+
+// 作成者がいなくなるときに作成者を引き継ぐ参加者を選ぶ
+// 管理者、モデレーター、メンバーの順に、同じ役割の中では最も早く参加した参加者を選ぶ。閲覧のみの参加者しかいなければnil
+func (ps ParticipatingRooms) Successor() *ParticipatingRoom {
+	var successor *ParticipatingRoom
+	for i := range ps {
+		p := &ps[i]
+		if p.IsOwner() || p.Role == RoleReadOnly {
+			continue
+		}
+		if successor == nil || p.Outranks(successor.Role) ||
+			(p.Role == successor.Role && (p.CreatedAt.Before(successor.CreatedAt) || (p.CreatedAt.Equal(successor.CreatedAt) && p.ID < successor.ID))) {
+			successor = p
+		}
+	}
+	return successor
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	maxMessageSize = 64 * 1024 // クライアントから受信する1メッセージの最大バイト数
+)
+
+// クライアントとのWebsocketコネクション
+// テストではメモリ上の実装に差し替える
+type Conn interface {
+	// 1メッセージを受信する
+	ReadMessage() ([]byte, error)
+	// 1メッセージを送信する
+	WriteMessage(data []byte) error
+	// pingの制御フレームを送信する。他のメソッドと並行して呼び出せる
+	WritePing() error
+	// pongの制御フレームを受信した時に呼び出す関数を設定する
+	SetPongHandler(h func())
+	SetReadDeadline(t time.Time) error
+	SetWriteDeadline(t time.Time) error
+	// 理由のコードを付けてクローズフレームを送信し、コネクションを閉じる
+	Close(code int, reason string) error
+}
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:    1024,
+	WriteBufferSize:   1024,
+	EnableCompression: true, // permessage-deflate
+}
+
+// gorilla/websocketのコネクション
+type wsConn struct {
+	conn *websocket.Conn
+}
+
+// HTTPのリクエストをWebsocketにアップグレードする
+func upgrade(w http.ResponseWriter, r *http.Request) (Conn, error) {
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetReadLimit(maxMessageSize)
+
+	return &wsConn{conn: conn}, nil
+}
+
+func (c *wsConn) ReadMessage() ([]byte, error) {
+	_, r, err := c.conn.NextReader()
+	if err != nil {
+		return nil, err
+	}
+
+	// 圧縮されたメッセージではSetReadLimitが展開前の大きさにしか効かないため、展開後の大きさも制限する
+	data, err := io.ReadAll(io.LimitReader(r, maxMessageSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(data) > maxMessageSize {
+		_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseMessageTooBig, ""), time.Now().Add(writeWait))
+		return nil, websocket.ErrReadLimit
+	}
+
+	return data, nil
+}
+
+func (c *wsConn) WriteMessage(data []byte) error {
+	return c.conn.WriteMessage(websocket.TextMessage, data)
+}
+
+func (c *wsConn) WritePing() error {
+	return c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(writeWait))
+}
+
+func (c *wsConn) SetPongHandler(h func()) {
+	c.conn.SetPongHandler(func(string) error {
+		h()
+		return nil
+	})
+}
+
+func (c *wsConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *wsConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
+
+func (c *wsConn) Close(code int, reason string) error {
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason), time.Now().Add(writeWait))
+	return c.conn.Close()
+}
@@ -0,0 +1,308 @@
+package handler
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
+	"github.com/gorilla/websocket"
+)
+
+// テスト用のメモリ上のコネクション
+// sendでクライアントからの受信を、hangupでクライアントからの切断を再現する
+type fakeConn struct {
+	mu           sync.Mutex
+	frames       [][]byte
+	block        chan struct{} // nilでない場合、閉じられるまでWriteMessageをブロックする
+	closed       bool
+	closeCode    int
+	closeReason  string
+	pings        int
+	pongHandler  func()
+	readDeadline time.Time
+	inbound      chan []byte
+	hungup       chan struct{}
+	hangupOnce   sync.Once
+}
+
+// 読み取りの期限切れ
+type timeoutError struct{}
+
+func (timeoutError) Error() string   { return "i/o timeout" }
+func (timeoutError) Timeout() bool   { return true }
+func (timeoutError) Temporary() bool { return true }
+
+func (f *fakeConn) channels() (chan []byte, chan struct{}) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.inbound == nil {
+		f.inbound = make(chan []byte, 16)
+		f.hungup = make(chan struct{})
+	}
+	return f.inbound, f.hungup
+}
+
+// 実際のコネクションと同様に、読み取り中に延ばされた期限にも従う
+func (f *fakeConn) ReadMessage() ([]byte, error) {
+	inbound, hungup := f.channels()
+	ticker := time.NewTicker(time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case data := <-inbound:
+			return data, nil
+		case <-hungup:
+			return nil, io.EOF
+		case <-ticker.C:
+			f.mu.Lock()
+			deadline := f.readDeadline
+			f.mu.Unlock()
+			if !deadline.IsZero() && time.Now().After(deadline) {
+				return nil, timeoutError{}
+			}
+		}
+	}
+}
+
+func (f *fakeConn) WriteMessage(data []byte) error {
+	if f.block != nil {
+		<-f.block
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.frames = append(f.frames, data)
+	return nil
+}
+
+func (f *fakeConn) WritePing() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pings++
+	return nil
+}
+
+func (f *fakeConn) SetPongHandler(h func()) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.pongHandler = h
+}
+
+func (f *fakeConn) SetReadDeadline(t time.Time) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.readDeadline = t
+	return nil
+}
+
+func (f *fakeConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
+
+func (f *fakeConn) Close(code int, reason string) error {
+	f.mu.Lock()
+	if !f.closed {
+		f.closed = true
+		f.closeCode = code
+		f.closeReason = reason
+	}
+	f.mu.Unlock()
+	f.hangup()
+	return nil
+}
+
+// クライアントからフレームを送信する
+func (f *fakeConn) send(data string) {
+	inbound, _ := f.channels()
+	inbound <- []byte(data)
+}
+
+// クライアントからpongを送信する
+func (f *fakeConn) pong() {
+	f.mu.Lock()
+	h := f.pongHandler
+	f.mu.Unlock()
+	if h != nil {
+		h()
+	}
+}
+
+// クライアントから切断する
+func (f *fakeConn) hangup() {
+	_, hungup := f.channels()
+	f.hangupOnce.Do(func() {
+		close(hungup)
+	})
+}
+
+// 書き込まれたフレームをエンベロープとして読み取る
+func (f *fakeConn) messages() []*envelope.Envelope {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var msgs []*envelope.Envelope
+	for _, data := range f.frames {
+		e, err := envelope.Decode(data)
+		if err != nil {
+			panic(fmt.Sprintf("envelope.Decode(%s) error = %v", data, err))
+		}
+		msgs = append(msgs, e)
+	}
+	return msgs
+}
+
+// 書き込まれたフレームをそのまま返す
+func (f *fakeConn) rawFrames() [][]byte {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([][]byte(nil), f.frames...)
+}
+
+func (f *fakeConn) isClosed() bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closed
+}
+
+// 送信されたpingの数
+func (f *fakeConn) pingCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pings
+}
+
+// 閉じた時の理由のコード
+func (f *fakeConn) closeStatus() (int, string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.closeCode, f.closeReason
+}
+
+// コネクションが閉じられるまで待つ
+func waitClosed(t *testing.T, conn *fakeConn) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !conn.isClosed() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !conn.isClosed() {
+		t.Fatalf("connection was not closed")
+	}
+}
+
+// クライアントのwritePumpがn件のメッセージを書き込むまで待つ
+func waitMessages(t *testing.T, conn *fakeConn, n int) []*envelope.Envelope {
+	t.Helper()
+	waitFrames(t, conn, n)
+	return conn.messages()
+}
+
+// クライアントのwritePumpがn件のフレームを書き込むまで待つ
+func waitFrames(t *testing.T, conn *fakeConn, n int) [][]byte {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for len(conn.rawFrames()) < n && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	return conn.rawFrames()
+}
+
+// 実際のWebsocketでサーバー側のConnを動かす
+func serveWS(t *testing.T, fn func(conn Conn)) *websocket.Conn {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		conn, err := upgrade(w, r)
+		if err != nil {
+			t.Errorf("upgrade() error = %v", err)
+			return
+		}
+		fn(conn)
+	}))
+	t.Cleanup(srv.Close)
+
+	dialer := websocket.Dialer{EnableCompression: true}
+	ws, resp, err := dialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http"), nil)
+	if err != nil {
+		t.Fatalf("Dial() error = %v", err)
+	}
+	t.Cleanup(func() { ws.Close() })
+	if ext := resp.Header.Get("Sec-WebSocket-Extensions"); !strings.Contains(ext, "permessage-deflate") {
+		t.Errorf("Sec-WebSocket-Extensions = %q, want permessage-deflate", ext)
+	}
+	return ws
+}
+
+func TestWsConn_CloseCode(t *testing.T) {
+	ws := serveWS(t, func(conn Conn) {
+		conn.Close(closeCodeSlowConsumer, "slow")
+	})
+
+	_, _, err := ws.ReadMessage()
+	if !websocket.IsCloseError(err, closeCodeSlowConsumer) {
+		t.Fatalf("ReadMessage() error = %v, want close %d", err, closeCodeSlowConsumer)
+	}
+	if ce := err.(*websocket.CloseError); ce.Text != "slow" {
+		t.Errorf("close reason = %q, want %q", ce.Text, "slow")
+	}
+}
+
+func TestWsConn_ReadLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		compress bool
+	}{
+		{name: "[異常系] 圧縮なし", compress: false},
+		{name: "[異常系] 圧縮あり（展開後の大きさで制限する）", compress: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			readErr := make(chan error, 1)
+			ws := serveWS(t, func(conn Conn) {
+				_, err := conn.ReadMessage()
+				readErr <- err
+				conn.Close(closeCodeNormal, "")
+			})
+
+			ws.EnableWriteCompression(tt.compress)
+			err := ws.WriteMessage(websocket.TextMessage, []byte(strings.Repeat("a", maxMessageSize+1)))
+			if err != nil {
+				t.Fatalf("WriteMessage() error = %v", err)
+			}
+
+			if err := <-readErr; err != websocket.ErrReadLimit {
+				t.Errorf("server ReadMessage() error = %v, want %v", err, websocket.ErrReadLimit)
+			}
+			_, _, err = ws.ReadMessage()
+			if !websocket.IsCloseError(err, websocket.CloseMessageTooBig) {
+				t.Errorf("client ReadMessage() error = %v, want close %d", err, websocket.CloseMessageTooBig)
+			}
+		})
+	}
+}
+
+func TestWsConn_PingPong(t *testing.T) {
+	ponged := make(chan struct{})
+	ws := serveWS(t, func(conn Conn) {
+		conn.SetPongHandler(func() {
+			close(ponged)
+		})
+		if err := conn.WritePing(); err != nil {
+			t.Errorf("WritePing() error = %v", err)
+		}
+		conn.ReadMessage()
+		conn.Close(closeCodeNormal, "")
+	})
+
+	// クライアントは読み取り中にpingへ自動で応答する
+	go ws.ReadMessage()
+	select {
+	case <-ponged:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("pong was not received")
+	}
+}
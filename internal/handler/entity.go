@@ -11,17 +11,130 @@ type SentUser struct {
 	Name string `json:"name"`
 }
 
-// クライアントサーバ間でやりとりするメッセージ
+// 旧形式のメッセージの種類
+const (
+	messageTypeHistory = "history" // 過去のメッセージの要求と送信
+	messageTypeClose   = "close"   // サーバーからの切断の通知
+)
+
+// クライアントを切断する理由のコード
+const (
+	closeCodeNormal       = 1000
+	closeCodeInternal     = 1011 // サーバー内部のエラー
+	closeCodeBadRequest   = 4000 // 参加するRoomの指定が不正
+	closeCodeSlowConsumer = 4001 // 送信キューが溢れた
+	closeCodeWriteFailed  = 4002 // 送信に失敗した
+	closeCodePingTimeout  = 4003 // 一定時間クライアントから何も受信しなかった
+	closeCodeRoomNotFound = 4004 // 参加するRoomが存在しない
+	closeCodeForbidden    = 4005 // 送信者がセッションのユーザーと一致しない
+	closeCodeNotMember    = 4006 // 参加者以外は参加できないRoomに参加しようとした
+	closeCodeKicked       = 4007 // Roomから退出させられた
+)
+
+// クライアントサーバ間でやりとりする旧形式のメッセージ
+// 新しいクライアントはpkg/envelopeのエンベロープ形式を使う
 type Message struct {
-	RoomID      string   `json:"roomid"`
-	Message     string   `json:"message"`
-	Name        string   `json:"name"`
-	ToName      string   `json:"toname"`
-	AllUsers    []string `json:"allusers"`
-	OnlineUsers []string `json:"onlineusers"`
+	Type        string           `json:"type"`
+	ID          string           `json:"id"`
+	RoomID      string           `json:"roomid"`
+	Message     string           `json:"message"`
+	Name        string           `json:"name"`
+	ToName      string           `json:"toname"`
+	AllUsers    []string         `json:"allusers"`
+	OnlineUsers []string         `json:"onlineusers"`
+	Before      string           `json:"before"`
+	History     []HistoryMessage `json:"history"`
+	HasMore     bool             `json:"hasmore"`
+	Code        int              `json:"code"`
+}
+
+// 旧形式で履歴として送信する保存済みのメッセージ
+type HistoryMessage struct {
+	ID        string `json:"id"`
+	Message   string `json:"message"`
+	Name      string `json:"name"`
+	ToName    string `json:"toname"`
+	CreatedAt string `json:"createdat"`
 }
 
 // ルーム一覧送信用
 type SentRoomsList struct {
-	RoomsList []string `json:"roomslist"`
+	RoomsList      []string          `json:"roomslist"`
+	Rooms          []SentRoom        `json:"rooms,omitempty"` // RoomsListのRoomの名前、トピック、説明
+	UnreadMentions map[string]int64  `json:"unreadmentions,omitempty"`
+	Unread         map[string]int64  `json:"unread,omitempty"`       // Roomごとの未読メッセージ数
+	LastActivity   map[string]string `json:"lastactivity,omitempty"` // Roomごとの最後の発言日時
+	DirectRooms    []SentDirectRoom  `json:"directrooms,omitempty"`  // ダイレクトメッセージはRoomsListとは別に返す
+}
+
+// Room送信用
+type SentRoom struct {
+	ID          string `json:"id"`
+	Slug        string `json:"slug,omitempty"`
+	Name        string `json:"name"`
+	Topic       string `json:"topic"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility,omitempty"` // グループのRoomの公開範囲
+}
+
+// 招待リンク送信用
+type SentInvite struct {
+	ID        string `json:"id"`
+	URL       string `json:"url"` // トークンを含む招待リンクのパス
+	MaxUses   int    `json:"maxuses"`
+	Uses      int    `json:"uses"`
+	ExpiresAt string `json:"expiresat"`
+}
+
+// 招待リンク一覧送信用
+type SentInvites struct {
+	Invites []SentInvite `json:"invites"`
+}
+
+// 参加リクエスト送信用
+type SentJoinRequest struct {
+	ID        string `json:"id"`
+	Name      string `json:"name"` // リクエストしたユーザー名
+	Status    string `json:"status"`
+	CreatedAt string `json:"createdat"`
+}
+
+// 参加リクエスト一覧送信用
+type SentJoinRequests struct {
+	Requests []SentJoinRequest `json:"requests"`
+}
+
+// Roomの参加者送信用
+type SentMember struct {
+	UserID string `json:"userid"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+}
+
+// Roomの参加者一覧送信用
+type SentMembers struct {
+	Members []SentMember `json:"members"`
+}
+
+// ダイレクトメッセージのRoom送信用
+type SentDirectRoom struct {
+	RoomID string `json:"roomid"`
+	Name   string `json:"name"` // 相手のユーザー名
+}
+
+// メッセージ検索結果送信用
+type SentSearchResults struct {
+	Results []SearchResult `json:"results"`
+	Next    string         `json:"next,omitempty"` // 続きを取得するときにbeforeに指定するID
+}
+
+// 検索に一致したメッセージ
+type SearchResult struct {
+	ID        string `json:"id"`
+	RoomID    string `json:"roomid"`
+	Name      string `json:"name"`
+	ToName    string `json:"toname"`
+	ParentID  string `json:"parentid"`
+	Snippet   string `json:"snippet"` // HTMLエスケープ済みで、一致した語を<mark>で囲んだ抜粋
+	CreatedAt string `json:"createdat"`
 }
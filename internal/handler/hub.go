@@ -0,0 +1,571 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/timefmt"
+)
+
+const (
+	clientSendBufferSize = 256              // クライアントごとの送信キューの長さ
+	writeWait            = 10 * time.Second // 1回の送信の書き込み期限
+	typingTimeout        = 6 * time.Second  // typing.startの再送がないまま経過すると入力を終了したとみなす時間
+)
+
+// クライアントに送信するイベント
+type Event struct {
+	Type      string
+	ID        string
+	Payload   any
+	fromID    string // 送信者のユーザーID
+	toID      string // ささやきの宛先のユーザーID
+	toName    string // ささやきの宛先のユーザー名。空の場合はRoom全体に送信する
+	ephemeral bool   // 送信者以外に配信し、チャットログに残さない一時的なイベント
+}
+
+// Roomに接続しているクライアント
+// 送信はwritePumpのgoroutineからのみ行う
+type Client struct {
+	UserID      string
+	Name        string
+	legacy      bool // 旧形式のメッセージでやりとりするクライアントかどうか
+	conn        Conn
+	send        chan Event
+	closed      chan struct{}
+	closeOnce   sync.Once
+	closeCode   int
+	closeReason string
+}
+
+func NewClient(userID, name string, legacy bool, conn Conn) *Client {
+	client := &Client{
+		UserID: userID,
+		Name:   name,
+		legacy: legacy,
+		conn:   conn,
+		send:   make(chan Event, clientSendBufferSize),
+		closed: make(chan struct{}),
+	}
+	go client.writePump()
+
+	return client
+}
+
+// 送信キューにイベントを追加する。キューが溢れている場合はfalseを返す
+func (c *Client) enqueue(ev Event) bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+	}
+
+	select {
+	case c.send <- ev:
+		return true
+	default:
+		return false
+	}
+}
+
+// 理由のコードを付けてクライアントを切断する
+func (c *Client) Close(code int, reason string) {
+	c.closeOnce.Do(func() {
+		c.closeCode = code
+		c.closeReason = reason
+		close(c.closed)
+	})
+}
+
+// 切断済みかどうか
+func (c *Client) isClosed() bool {
+	select {
+	case <-c.closed:
+		return true
+	default:
+		return false
+	}
+}
+
+// クライアントの形式に合わせてイベントをJSONに変換する
+func (c *Client) encode(ev Event) ([]byte, error) {
+	if c.legacy {
+		return json.Marshal(eventToLegacy(ev))
+	}
+	return envelope.Encode(ev.Type, ev.ID, ev.Payload)
+}
+
+// 1件のイベントを書き込む
+func (c *Client) write(ev Event) error {
+	if c.legacy && !legacySupports(ev) {
+		return nil
+	}
+
+	data, err := c.encode(ev)
+	if err != nil {
+		return fmt.Errorf("encode error: %w", err)
+	}
+
+	_ = c.conn.SetWriteDeadline(time.Now().Add(writeWait))
+	return c.conn.WriteMessage(data)
+}
+
+// pingを送信する。送信キューを経由せずに送る
+func (c *Client) ping() {
+	err := c.conn.WritePing()
+	if err != nil {
+		log.Printf("ping error:%v\n", err)
+		c.Close(closeCodeWriteFailed, "メッセージの送信に失敗しました。")
+	}
+}
+
+// goroutineで送信キューのイベントをクライアントに書き込む
+func (c *Client) writePump() {
+	for {
+		select {
+		case ev := <-c.send:
+			err := c.write(ev)
+			if err != nil {
+				log.Printf("Send error:%v\n", err)
+				c.Close(closeCodeWriteFailed, "メッセージの送信に失敗しました。")
+			}
+		case <-c.closed:
+			// 旧形式のクライアントはクローズフレームの理由を表示しないため、メッセージでも通知する
+			if c.legacy && c.closeCode != closeCodeNormal {
+				err := c.write(Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{Message: c.closeReason, Code: c.closeCode}})
+				if err != nil {
+					log.Printf("close Send error:%v\n", err)
+				}
+			}
+			c.conn.Close(c.closeCode, c.closeReason)
+			return
+		}
+	}
+}
+
+// 作成された各ルームのRoomHubを管理する
+type Hub struct {
+	mu        sync.RWMutex
+	rooms     map[string]*RoomHub
+	usersMu   sync.RWMutex
+	users     map[string]map[*Client]bool // Roomに参加中のクライアントをユーザーIDごとに管理する
+	logMu     sync.Mutex
+	chatLog   io.Writer
+	evictions atomic.Int64
+
+	typingTimeout time.Duration
+}
+
+func NewHub(chatLog io.Writer) *Hub {
+	return &Hub{
+		rooms:         make(map[string]*RoomHub),
+		users:         make(map[string]map[*Client]bool),
+		chatLog:       chatLog,
+		typingTimeout: typingTimeout,
+	}
+}
+
+// DBに保存されているRoomのRoomHubを起動
+func (h *Hub) Init(rooms *domain.Rooms) {
+	for _, room := range *rooms {
+		if room.IsDirect() {
+			h.CreateDirect(room.ID)
+		} else {
+			h.Create(room.ID)
+		}
+	}
+}
+
+// RoomHubを作成して起動する。既に存在する場合はそれを返す
+func (h *Hub) Create(roomID string) *RoomHub {
+	return h.create(roomID, false)
+}
+
+// ダイレクトメッセージのRoomのRoomHubを作成して起動する。既に存在する場合はそれを返す
+func (h *Hub) CreateDirect(roomID string) *RoomHub {
+	return h.create(roomID, true)
+}
+
+func (h *Hub) create(roomID string, direct bool) *RoomHub {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if room, exists := h.rooms[roomID]; exists {
+		return room
+	}
+
+	room := newRoomHub(roomID, h)
+	room.Direct = direct
+	h.rooms[roomID] = room
+	go room.run()
+
+	return room
+}
+
+// RoomHubの取得
+func (h *Hub) Get(roomID string) (*RoomHub, bool) {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	room, exists := h.rooms[roomID]
+	return room, exists
+}
+
+// RoomHubを停止して削除
+func (h *Hub) Delete(roomID string) {
+	h.mu.Lock()
+	room, exists := h.rooms[roomID]
+	delete(h.rooms, roomID)
+	h.mu.Unlock()
+
+	if exists {
+		room.stop()
+	}
+}
+
+// Roomに参加したクライアントを、ユーザー宛てのイベントの送信先に追加する
+func (h *Hub) addClient(client *Client) {
+	h.usersMu.Lock()
+	defer h.usersMu.Unlock()
+
+	if h.users[client.UserID] == nil {
+		h.users[client.UserID] = make(map[*Client]bool)
+	}
+	h.users[client.UserID][client] = true
+}
+
+// Roomから退出したクライアントを、ユーザー宛てのイベントの送信先から削除する
+func (h *Hub) removeClient(client *Client) {
+	h.usersMu.Lock()
+	defer h.usersMu.Unlock()
+
+	delete(h.users[client.UserID], client)
+	if len(h.users[client.UserID]) == 0 {
+		delete(h.users, client.UserID)
+	}
+}
+
+// ユーザーの接続中のすべてのクライアントに、参加中のRoomに関わらずイベントを送信する
+func (h *Hub) SendToUser(userID string, ev Event) {
+	h.usersMu.RLock()
+	clients := make([]*Client, 0, len(h.users[userID]))
+	for client := range h.users[userID] {
+		clients = append(clients, client)
+	}
+	h.usersMu.RUnlock()
+
+	for _, client := range clients {
+		if !client.enqueue(ev) {
+			h.evict(client)
+		}
+	}
+}
+
+// 送信キューが溢れたクライアントを切断する
+func (h *Hub) evict(client *Client) {
+	evictions := h.evictions.Add(1)
+	log.Printf("slow client evicted: %s (total %d)\n", client.Name, evictions)
+	client.Close(closeCodeSlowConsumer, "メッセージの受信が追いつかないため切断しました。")
+}
+
+// 送信キューが溢れて切断したクライアントの累計数
+func (h *Hub) Evictions() int64 {
+	return h.evictions.Load()
+}
+
+// チャットログを出力と保存
+func (h *Hub) writeChatLog(chatlog string) {
+	h.logMu.Lock()
+	defer h.logMu.Unlock()
+
+	fmt.Print(chatlog)
+	fmt.Fprint(h.chatLog, chatlog)
+}
+
+// 1つのRoomのクライアントとメッセージ配信を管理する
+// clients、typingClientsはrunのgoroutineからのみ読み書きする
+type RoomHub struct {
+	ID            string
+	Direct        bool // ダイレクトメッセージのRoomかどうか。参加者以外は参加できない
+	hub           *Hub
+	seqMu         sync.Mutex // メッセージの保存から配信までを直列にし、seq順に配信する
+	clients       map[*Client]bool
+	typingClients map[*Client]time.Time // 入力中のクライアントと入力中とみなす期限
+	register      chan *Client
+	unregister    chan *Client
+	broadcast     chan Event
+	typing        chan typingChange
+	online        chan chan []*Client
+	done          chan struct{}
+	stopOnce      sync.Once
+}
+
+// クライアントの入力中の状態の変更
+type typingChange struct {
+	client *Client
+	typing bool
+}
+
+func newRoomHub(roomID string, hub *Hub) *RoomHub {
+	return &RoomHub{
+		ID:            roomID,
+		hub:           hub,
+		clients:       make(map[*Client]bool),
+		typingClients: make(map[*Client]time.Time),
+		register:      make(chan *Client),
+		unregister:    make(chan *Client),
+		broadcast:     make(chan Event),
+		typing:        make(chan typingChange),
+		online:        make(chan chan []*Client),
+		done:          make(chan struct{}),
+	}
+}
+
+// Roomにクライアントを参加させる。Roomが削除済みの場合はfalseを返す
+func (r *RoomHub) Register(client *Client) bool {
+	select {
+	case r.register <- client:
+		return true
+	case <-r.done:
+		return false
+	}
+}
+
+// Roomからクライアントを削除
+func (r *RoomHub) Unregister(client *Client) {
+	select {
+	case r.unregister <- client:
+	case <-r.done:
+	}
+}
+
+// Roomのクライアントにイベントを配信する
+func (r *RoomHub) Broadcast(ev Event) {
+	select {
+	case r.broadcast <- ev:
+	case <-r.done:
+	}
+}
+
+// クライアントの入力中の状態を更新し、変わった場合はRoomの他のクライアントに知らせる
+// 入力中の状態はtyping.startを送り直さなければ期限切れで終了する
+func (r *RoomHub) SetTyping(client *Client, typing bool) {
+	select {
+	case r.typing <- typingChange{client: client, typing: typing}:
+	case <-r.done:
+	}
+}
+
+// Roomに接続中のユーザー名一覧の取得
+func (r *RoomHub) OnlineUsers() ([]string, error) {
+	clients, err := r.onlineClients()
+	if err != nil {
+		return nil, err
+	}
+
+	var users []string
+	for _, client := range clients {
+		users = append(users, client.Name)
+	}
+	return users, nil
+}
+
+// ユーザーがRoomに接続中かどうか
+func (r *RoomHub) IsOnline(userID string) (bool, error) {
+	clients, err := r.onlineClients()
+	if err != nil {
+		return false, err
+	}
+
+	for _, client := range clients {
+		if client.UserID == userID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// ユーザーのRoomへの接続を理由のコードを付けてすべて切断する
+func (r *RoomHub) CloseUser(userID string, code int, reason string) error {
+	clients, err := r.onlineClients()
+	if err != nil {
+		return err
+	}
+
+	for _, client := range clients {
+		if client.UserID == userID {
+			client.Close(code, reason)
+		}
+	}
+	return nil
+}
+
+// Roomに接続中のクライアント一覧の取得
+func (r *RoomHub) onlineClients() ([]*Client, error) {
+	reply := make(chan []*Client, 1)
+	select {
+	case r.online <- reply:
+	case <-r.done:
+		return nil, fmt.Errorf("this room was not found")
+	}
+	return <-reply, nil
+}
+
+func (r *RoomHub) stop() {
+	r.stopOnce.Do(func() {
+		close(r.done)
+	})
+}
+
+// goroutineでRoomへの参加、退出、メッセージを待ち受ける
+func (r *RoomHub) run() {
+	// 入力中のクライアントがいる間だけ、最も早い期限に合わせて動かす
+	typingTimer := time.NewTimer(r.hub.typingTimeout)
+	typingTimer.Stop()
+	defer typingTimer.Stop()
+	var typingExpired <-chan time.Time
+
+	for {
+		select {
+		case client := <-r.register:
+			r.clients[client] = true
+		case client := <-r.unregister:
+			delete(r.clients, client)
+			r.setTyping(client, false, time.Now())
+			typingExpired = r.resetTypingTimer(typingTimer)
+		case ev := <-r.broadcast:
+			r.deliver(ev)
+		case t := <-r.typing:
+			r.setTyping(t.client, t.typing, time.Now())
+			typingExpired = r.resetTypingTimer(typingTimer)
+		case now := <-typingExpired:
+			for client, expiry := range r.typingClients {
+				if !now.Before(expiry) {
+					r.setTyping(client, false, now)
+				}
+			}
+			typingExpired = r.resetTypingTimer(typingTimer)
+		case reply := <-r.online:
+			clients := make([]*Client, 0, len(r.clients))
+			for client := range r.clients {
+				clients = append(clients, client)
+			}
+			reply <- clients
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// 入力中の状態を更新し、開始または終了した場合はRoomの他のクライアントに配信する
+func (r *RoomHub) setTyping(client *Client, typing bool, now time.Time) {
+	_, wasTyping := r.typingClients[client]
+	if typing {
+		if !r.clients[client] { // Roomから外れたクライアント
+			return
+		}
+		r.typingClients[client] = now.Add(r.hub.typingTimeout)
+		if !wasTyping {
+			r.deliver(Event{Type: envelope.TypeTypingStart, Payload: &envelope.TypingPayload{RoomID: r.ID, Name: client.Name}, fromID: client.UserID, ephemeral: true})
+		}
+		return
+	}
+
+	if wasTyping {
+		delete(r.typingClients, client)
+		r.deliver(Event{Type: envelope.TypeTypingStop, Payload: &envelope.TypingPayload{RoomID: r.ID, Name: client.Name}, fromID: client.UserID, ephemeral: true})
+	}
+}
+
+// 入力中の期限切れを確認するタイマーを最も早い期限に合わせ、待ち受けるチャネルを返す
+func (r *RoomHub) resetTypingTimer(timer *time.Timer) <-chan time.Time {
+	if len(r.typingClients) == 0 {
+		timer.Stop()
+		return nil
+	}
+
+	var next time.Time
+	for _, expiry := range r.typingClients {
+		if next.IsZero() || expiry.Before(next) {
+			next = expiry
+		}
+	}
+	timer.Reset(time.Until(next))
+	return timer.C
+}
+
+// 接続中のクライアントにイベントを送信する
+func (r *RoomHub) deliver(ev Event) {
+	if ev.ephemeral {
+		for client := range r.clients {
+			if client.UserID != ev.fromID {
+				r.send(client, ev)
+			}
+		}
+		return
+	}
+
+	r.logEvent(ev)
+
+	// ささやきは名前ではなくユーザーIDで送信者と宛先に配信する
+	if ev.toName != "" || ev.toID != "" {
+		for client := range r.clients {
+			if client.UserID == ev.toID || client.UserID == ev.fromID {
+				r.send(client, ev)
+			}
+		}
+		return
+	}
+
+	for client := range r.clients {
+		r.send(client, ev)
+	}
+}
+
+// チャットログを出力と保存 日時、サーバー名、ユーザー名、宛先、メッセージ
+// ログに残すのはチャットメッセージ、入退室の通知、システムからのお知らせのみ
+func (r *RoomHub) logEvent(ev Event) {
+	// 編集や削除もChatMessagePayloadで送信されるため、ペイロードではなくイベントの種類で判定する
+	var from, text string
+	switch ev.Type {
+	case envelope.TypeChatMessage:
+		p, ok := ev.Payload.(*envelope.ChatMessagePayload)
+		if !ok {
+			return
+		}
+		from, text = p.Name, p.Message
+	case envelope.TypePresenceUpdate:
+		p, ok := ev.Payload.(*envelope.PresenceUpdatePayload)
+		if !ok {
+			return
+		}
+		from, text = "Server", p.Message
+	case envelope.TypeSystemNotice:
+		p, ok := ev.Payload.(*envelope.SystemNoticePayload)
+		if !ok {
+			return
+		}
+		from, text = "Server", p.Message
+	default:
+		return
+	}
+	replaceNlMsg := strings.ReplaceAll(text, "\n", " ") // 改行があるとログが改行されてしまうため、改行を削除
+	chatlog := fmt.Sprintf("%s: [S%s] From(%s) To (%s) Msg(%s)\n", timefmt.TimeToStr(time.Now()), r.ID, from, ev.toName, replaceNlMsg)
+	r.hub.writeChatLog(chatlog)
+}
+
+// クライアントの送信キューに追加し、溢れた場合はRoomから外して切断する
+func (r *RoomHub) send(client *Client, ev Event) {
+	if !client.enqueue(ev) {
+		delete(r.clients, client)
+		r.hub.evict(client)
+	}
+}
@@ -0,0 +1,404 @@
+package handler
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
+)
+
+// チャットメッセージのイベントを作成する
+func chatEvent(roomID, from, message string) Event {
+	return Event{Type: envelope.TypeChatMessage, Payload: &envelope.ChatMessagePayload{RoomID: roomID, Name: from, Message: message}}
+}
+
+func TestHub_ConcurrentClients(t *testing.T) {
+	const (
+		roomCount      = 4
+		clientsPerRoom = 100
+	)
+
+	hub := NewHub(io.Discard)
+	for i := 0; i < roomCount; i++ {
+		hub.Create(fmt.Sprintf("%04d", i))
+	}
+
+	type testClient struct {
+		room   *RoomHub
+		client *Client
+		conn   *fakeConn
+	}
+	var clients []testClient
+	for i := 0; i < roomCount; i++ {
+		room, exists := hub.Get(fmt.Sprintf("%04d", i))
+		if !exists {
+			t.Fatalf("hub.Get(%04d) not found", i)
+		}
+		for j := 0; j < clientsPerRoom; j++ {
+			conn := &fakeConn{}
+			clients = append(clients, testClient{room: room, client: NewClient(fmt.Sprintf("id%d-%d", i, j), fmt.Sprintf("user%d-%d", i, j), false, conn), conn: conn})
+		}
+	}
+
+	// 全クライアントが同時に参加
+	var wg sync.WaitGroup
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c testClient) {
+			defer wg.Done()
+			if !c.room.Register(c.client) {
+				t.Errorf("RoomHub.Register() = false, want true")
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	// 全クライアントが同時にメッセージを送信し、オンライン一覧を取得
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c testClient) {
+			defer wg.Done()
+			c.room.Broadcast(chatEvent(c.room.ID, c.client.Name, "hello"))
+			if _, err := c.room.OnlineUsers(); err != nil {
+				t.Errorf("RoomHub.OnlineUsers() error = %v", err)
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	for i := 0; i < roomCount; i++ {
+		room, _ := hub.Get(fmt.Sprintf("%04d", i))
+		users, err := room.OnlineUsers()
+		if err != nil {
+			t.Fatalf("RoomHub.OnlineUsers() error = %v", err)
+		}
+		if len(users) != clientsPerRoom {
+			t.Errorf("RoomHub.OnlineUsers() len = %d, want %d", len(users), clientsPerRoom)
+		}
+	}
+
+	// 同じRoomのメッセージのみ全て届いている
+	for _, c := range clients {
+		msgs := waitMessages(t, c.conn, clientsPerRoom)
+		if len(msgs) != clientsPerRoom {
+			t.Fatalf("client received %d messages, want %d", len(msgs), clientsPerRoom)
+		}
+		for _, msg := range msgs {
+			var p envelope.ChatMessagePayload
+			if err := msg.DecodePayload(&p); err != nil {
+				t.Fatalf("DecodePayload() error = %v", err)
+			}
+			if p.RoomID != c.room.ID {
+				t.Errorf("client received message for room %s, want %s", p.RoomID, c.room.ID)
+			}
+		}
+	}
+
+	// 全クライアントが同時に退出
+	for _, c := range clients {
+		wg.Add(1)
+		go func(c testClient) {
+			defer wg.Done()
+			c.room.Unregister(c.client)
+		}(c)
+	}
+	wg.Wait()
+
+	for i := 0; i < roomCount; i++ {
+		room, _ := hub.Get(fmt.Sprintf("%04d", i))
+		users, err := room.OnlineUsers()
+		if err != nil {
+			t.Fatalf("RoomHub.OnlineUsers() error = %v", err)
+		}
+		if len(users) != 0 {
+			t.Errorf("RoomHub.OnlineUsers() len = %d, want 0", len(users))
+		}
+	}
+}
+
+func TestRoomHub_Whisper(t *testing.T) {
+	hub := NewHub(io.Discard)
+	room := hub.Create("1234")
+
+	// 宛先と同じ名前でも、ユーザーIDが異なるクライアントには届かない
+	from, to, other, sameName := &fakeConn{}, &fakeConn{}, &fakeConn{}, &fakeConn{}
+	room.Register(NewClient("id1", "from", false, from))
+	room.Register(NewClient("id2", "to", false, to))
+	room.Register(NewClient("id3", "other", false, other))
+	room.Register(NewClient("id4", "to", false, sameName))
+
+	room.Broadcast(Event{Type: envelope.TypeChatMessage, Payload: &envelope.ChatMessagePayload{RoomID: room.ID, Name: "from", ToName: "to", Message: "secret"}, fromID: "id1", toID: "id2", toName: "to"})
+	if _, err := room.OnlineUsers(); err != nil {
+		t.Fatalf("RoomHub.OnlineUsers() error = %v", err)
+	}
+
+	if got := len(waitMessages(t, from, 1)); got != 1 {
+		t.Errorf("sender received %d messages, want 1", got)
+	}
+	if got := len(waitMessages(t, to, 1)); got != 1 {
+		t.Errorf("recipient received %d messages, want 1", got)
+	}
+	if got := len(other.messages()); got != 0 {
+		t.Errorf("other client received %d messages, want 0", got)
+	}
+	if got := len(sameName.messages()); got != 0 {
+		t.Errorf("client with the same name received %d messages, want 0", got)
+	}
+}
+
+func TestRoomHub_ChatLog(t *testing.T) {
+	var chatLog bytes.Buffer
+	hub := NewHub(&chatLog)
+	room := hub.Create("1234")
+
+	conn := &fakeConn{}
+	room.Register(NewClient("id1", "user", false, conn))
+
+	// リアクションや役割の変更はチャットログに残らない
+	room.Broadcast(Event{Type: envelope.TypeReactionUpdate, Payload: &envelope.ReactionUpdatePayload{RoomID: "1234", ID: "01J00000000000000000000001"}})
+	room.Broadcast(Event{Type: envelope.TypeRoleUpdate, Payload: &envelope.RoleUpdatePayload{RoomID: "1234", UserID: "id1", Name: "user", Role: "admin"}})
+	room.Broadcast(chatEvent("1234", "user", "hello"))
+	room.Broadcast(Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{RoomID: "1234", Message: "notice"}})
+	waitMessages(t, conn, 4)
+
+	hub.logMu.Lock()
+	defer hub.logMu.Unlock()
+	lines := strings.Split(strings.TrimSuffix(chatLog.String(), "\n"), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[0], "From(user) To () Msg(hello)") || !strings.HasSuffix(lines[1], "From(Server) To () Msg(notice)") {
+		t.Errorf("chat log = %q, want chat message and system notice only", chatLog.String())
+	}
+}
+
+func TestRoomHub_ChatLogUpdate(t *testing.T) {
+	var chatLog bytes.Buffer
+	hub := NewHub(&chatLog)
+	room := hub.Create("1234")
+
+	conn := &fakeConn{}
+	room.Register(NewClient("id1", "user", false, conn))
+
+	// 編集と削除はチャットメッセージと同じペイロードでもチャットログに残らない
+	room.Broadcast(Event{Type: envelope.TypeChatUpdate, Payload: &envelope.ChatMessagePayload{ID: "01J00000000000000000000001", RoomID: "1234", Name: "user", Message: "edited", Edited: true}})
+	room.Broadcast(Event{Type: envelope.TypeChatUpdate, Payload: &envelope.ChatMessagePayload{ID: "01J00000000000000000000001", RoomID: "1234", Name: "user", Deleted: true}})
+	waitMessages(t, conn, 2)
+
+	hub.logMu.Lock()
+	defer hub.logMu.Unlock()
+	if chatLog.Len() != 0 {
+		t.Errorf("chat log = %q, want empty", chatLog.String())
+	}
+}
+
+func TestRoomHub_Typing(t *testing.T) {
+	var chatLog bytes.Buffer
+	hub := NewHub(&chatLog)
+	hub.typingTimeout = 50 * time.Millisecond
+	room := hub.Create("1234")
+
+	typing, other := &fakeConn{}, &fakeConn{}
+	typingClient := NewClient("id1", "typing", false, typing)
+	room.Register(typingClient)
+	room.Register(NewClient("id2", "other", false, other))
+
+	// 入力中の間に送り直しても開始は1回だけ配信される
+	room.SetTyping(typingClient, true)
+	room.SetTyping(typingClient, true)
+	room.SetTyping(typingClient, false)
+
+	msgs := waitMessages(t, other, 2)
+	if len(msgs) != 2 || msgs[0].Type != envelope.TypeTypingStart || msgs[1].Type != envelope.TypeTypingStop {
+		t.Fatalf("other client received %+v, want typing.start and typing.stop", msgs)
+	}
+	var payload envelope.TypingPayload
+	if err := msgs[0].DecodePayload(&payload); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if payload.RoomID != "1234" || payload.Name != "typing" {
+		t.Errorf("typing.start = %+v, want typing in room 1234", payload)
+	}
+
+	// 送り直さなければ期限切れで終了する
+	room.SetTyping(typingClient, true)
+	msgs = waitMessages(t, other, 4)
+	if len(msgs) != 4 || msgs[2].Type != envelope.TypeTypingStart || msgs[3].Type != envelope.TypeTypingStop {
+		t.Fatalf("other client received %+v, want typing.stop after timeout", msgs)
+	}
+
+	// 入力中のまま退出すると終了する
+	room.SetTyping(typingClient, true)
+	room.Unregister(typingClient)
+	msgs = waitMessages(t, other, 6)
+	if len(msgs) != 6 || msgs[4].Type != envelope.TypeTypingStart || msgs[5].Type != envelope.TypeTypingStop {
+		t.Fatalf("other client received %+v, want typing.stop after leaving", msgs)
+	}
+
+	// 入力中の本人には届かず、チャットログにも残らない
+	if _, err := room.OnlineUsers(); err != nil {
+		t.Fatalf("RoomHub.OnlineUsers() error = %v", err)
+	}
+	if got := len(typing.messages()); got != 0 {
+		t.Errorf("typing client received %d messages, want 0", got)
+	}
+	if chatLog.Len() != 0 {
+		t.Errorf("chat log = %q, want empty", chatLog.String())
+	}
+}
+
+func TestHub_Delete(t *testing.T) {
+	hub := NewHub(io.Discard)
+	room := hub.Create("1234")
+	hub.Delete("1234")
+
+	if _, exists := hub.Get("1234"); exists {
+		t.Errorf("hub.Get() exists = true after Delete")
+	}
+	if room.Register(NewClient("id1", "user", false, &fakeConn{})) {
+		t.Errorf("RoomHub.Register() = true after Delete")
+	}
+	room.Broadcast(chatEvent(room.ID, "user", "hello"))
+	room.Unregister(NewClient("id1", "user", false, &fakeConn{}))
+	if _, err := room.OnlineUsers(); err == nil {
+		t.Errorf("RoomHub.OnlineUsers() error = nil after Delete")
+	}
+}
+
+func TestHub_ConcurrentCreateDelete(t *testing.T) {
+	hub := NewHub(io.Discard)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 200; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			roomID := fmt.Sprintf("%04d", i%10)
+			room := hub.Create(roomID)
+			room.Register(NewClient(fmt.Sprintf("id%d", i), fmt.Sprintf("user%d", i), false, &fakeConn{}))
+			room.Broadcast(chatEvent(room.ID, "user", "hello"))
+			hub.Get(roomID)
+			if i%3 == 0 {
+				hub.Delete(roomID)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestRoomHub_EvictSlowClient(t *testing.T) {
+	hub := NewHub(io.Discard)
+	room := hub.Create("1234")
+
+	slow := &fakeConn{block: make(chan struct{})}
+	fast := &fakeConn{}
+	slowClient := NewClient("id1", "slow", false, slow)
+	room.Register(slowClient)
+	room.Register(NewClient("id2", "fast", false, fast))
+
+	// writePumpが1件を書き込み中のまま、送信キューを溢れさせる
+	for i := 0; i < clientSendBufferSize+2; i++ {
+		room.Broadcast(chatEvent(room.ID, "fast", fmt.Sprintf("msg%d", i)))
+	}
+
+	users, err := room.OnlineUsers()
+	if err != nil {
+		t.Fatalf("RoomHub.OnlineUsers() error = %v", err)
+	}
+	if len(users) != 1 || users[0] != "fast" {
+		t.Errorf("RoomHub.OnlineUsers() = %v, want [fast]", users)
+	}
+	if got := hub.Evictions(); got != 1 {
+		t.Errorf("Hub.Evictions() = %d, want 1", got)
+	}
+	if !slowClient.isClosed() {
+		t.Errorf("slow client was not closed")
+	}
+
+	// ブロックを解除すると理由のコードを付けてコネクションを閉じる
+	close(slow.block)
+	deadline := time.Now().Add(5 * time.Second)
+	for !slow.isClosed() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if !slow.isClosed() {
+		t.Fatalf("slow connection was not closed")
+	}
+	if code, _ := slow.closeStatus(); code != closeCodeSlowConsumer {
+		t.Errorf("close code = %d, want %d", code, closeCodeSlowConsumer)
+	}
+
+	// 他のクライアントには全て届いている
+	if got := len(waitMessages(t, fast, clientSendBufferSize+2)); got != clientSendBufferSize+2 {
+		t.Errorf("fast client received %d messages, want %d", got, clientSendBufferSize+2)
+	}
+}
+
+func TestRoomHub_CloseUser(t *testing.T) {
+	hub := NewHub(io.Discard)
+	room := hub.Create("1234")
+
+	// 同じユーザーの複数のコネクションはすべて切断し、他のユーザーは切断しない
+	kicked1, kicked2, other := &fakeConn{}, &fakeConn{}, &fakeConn{}
+	kickedClient1 := NewClient("id1", "kicked", false, kicked1)
+	kickedClient2 := NewClient("id1", "kicked", false, kicked2)
+	otherClient := NewClient("id2", "other", false, other)
+	room.Register(kickedClient1)
+	room.Register(kickedClient2)
+	room.Register(otherClient)
+
+	err := room.CloseUser("id1", closeCodeKicked, "Roomから退出させられました。")
+	if err != nil {
+		t.Fatalf("RoomHub.CloseUser() error = %v", err)
+	}
+	if !kickedClient1.isClosed() || !kickedClient2.isClosed() {
+		t.Errorf("kicked user's clients were not closed")
+	}
+	if otherClient.isClosed() {
+		t.Errorf("other user's client was closed")
+	}
+
+	deadline := time.Now().Add(5 * time.Second)
+	for !kicked1.isClosed() && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if code, _ := kicked1.closeStatus(); code != closeCodeKicked {
+		t.Errorf("close code = %d, want %d", code, closeCodeKicked)
+	}
+}
+
+func TestRoomHub_LegacyClient(t *testing.T) {
+	hub := NewHub(io.Discard)
+	room := hub.Create("1234")
+
+	v1, legacy := &fakeConn{}, &fakeConn{}
+	room.Register(NewClient("id1", "v1", false, v1))
+	room.Register(NewClient("id2", "legacy", true, legacy))
+
+	room.Broadcast(chatEvent(room.ID, "v1", "hello"))
+
+	// エンベロープ形式のクライアントにはエンベロープで届く
+	msgs := waitMessages(t, v1, 1)
+	if len(msgs) != 1 || msgs[0].Type != envelope.TypeChatMessage {
+		t.Fatalf("v1 client received %+v, want 1 %s", msgs, envelope.TypeChatMessage)
+	}
+
+	// 旧形式のクライアントにはフラットなMessageで届く
+	frames := waitFrames(t, legacy, 1)
+	if len(frames) != 1 {
+		t.Fatalf("legacy client received %d frames, want 1", len(frames))
+	}
+	if envelope.IsEnvelope(frames[0]) {
+		t.Errorf("legacy client received envelope %s", frames[0])
+	}
+	var msg Message
+	if err := json.Unmarshal(frames[0], &msg); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if msg.Message != "hello" || msg.Name != "v1" {
+		t.Errorf("legacy message = %+v, want message hello from v1", msg)
+	}
+}
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
+)
+
+// 送信者とRoomはセッションと参加時に決まったRoomのものを使う
+// フレームに含まれていた場合は一致しているかだけを確認し、異なる値での上書きは拒否する
+type frameIdentity struct {
+	UserID *string `json:"userid"`
+	Name   *string `json:"name"`
+	RoomID *string `json:"roomid"`
+}
+
+// フレームに含まれる送信者とRoomを取り出す
+// 旧形式はフレームの直下、エンベロープ形式はペイロードに含まれる
+func claimedIdentity(data []byte, e *envelope.Envelope, legacy bool) (frameIdentity, error) {
+	var claimed frameIdentity
+	src := data
+	if !legacy {
+		src = e.Payload
+	}
+	if len(src) == 0 {
+		return claimed, nil
+	}
+
+	err := json.Unmarshal(src, &claimed)
+	if err != nil {
+		return claimed, fmt.Errorf("json.Unmarshal error: %w", err)
+	}
+	return claimed, nil
+}
+
+// 送信者とRoomが接続のものと一致しているか。roomIDが空の場合はRoomを確認しない
+func (c frameIdentity) verify(userID, userName, roomID string) error {
+	if c.UserID != nil && *c.UserID != userID {
+		return errors.New("userid はセッションのユーザーと一致しません。")
+	}
+
+	if c.Name != nil && *c.Name != userName {
+		return errors.New("name はセッションのユーザーと一致しません。")
+	}
+
+	if roomID != "" && c.RoomID != nil && *c.RoomID != roomID {
+		return errors.New("roomid は参加しているRoomと一致しません。")
+	}
+
+	return nil
+}
+
+// フレームに含まれる送信者とRoomを確認する
+func verifyIdentity(data []byte, e *envelope.Envelope, legacy bool, userID, userName, roomID string) error {
+	claimed, err := claimedIdentity(data, e, legacy)
+	if err != nil {
+		return err
+	}
+	return claimed.verify(userID, userName, roomID)
+}
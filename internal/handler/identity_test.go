@@ -0,0 +1,187 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
+	"go.uber.org/mock/gomock"
+)
+
+func TestVerifyIdentity(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		roomID  string
+		wantErr bool
+	}{
+		{
+			name:    "[正常系] 送信者とRoomを含まない",
+			data:    `{"v":1,"type":"chat.send","payload":{"message":"hello"}}`,
+			roomID:  "1234",
+			wantErr: false,
+		},
+		{
+			name:    "[正常系] 接続と一致する送信者とRoom",
+			data:    `{"v":1,"type":"chat.send","payload":{"message":"hello","userid":"id1","name":"user","roomid":"1234"}}`,
+			roomID:  "1234",
+			wantErr: false,
+		},
+		{
+			name:    "[正常系] 旧形式で接続と一致する送信者とRoom",
+			data:    `{"roomid":"1234","message":"hello","name":"user","toname":""}`,
+			roomID:  "1234",
+			wantErr: false,
+		},
+		{
+			name:    "[正常系] 参加前はRoomを確認しない",
+			data:    `{"v":1,"type":"room.join","payload":{"roomid":"5678","name":"user"}}`,
+			roomID:  "",
+			wantErr: false,
+		},
+		{
+			name:    "[異常系] 他のユーザーのuserid",
+			data:    `{"v":1,"type":"chat.send","payload":{"message":"hello","userid":"id2"}}`,
+			roomID:  "1234",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 他のユーザーのname",
+			data:    `{"v":1,"type":"chat.send","payload":{"message":"hello","name":"other"}}`,
+			roomID:  "1234",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 参加していないRoom",
+			data:    `{"v":1,"type":"history.request","payload":{"roomid":"5678"}}`,
+			roomID:  "1234",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 旧形式で他のユーザーのname",
+			data:    `{"roomid":"1234","message":"hello","name":"other","toname":""}`,
+			roomID:  "1234",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 旧形式で参加していないRoom",
+			data:    `{"roomid":"5678","message":"hello","name":"user","toname":""}`,
+			roomID:  "1234",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] nameが文字列ではない",
+			data:    `{"v":1,"type":"chat.send","payload":{"message":"hello","name":1}}`,
+			roomID:  "1234",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, legacy, err := decodeFrame([]byte(tt.data), tt.roomID == "")
+			if err != nil {
+				t.Fatalf("decodeFrame() error = %v", err)
+			}
+			err = verifyIdentity([]byte(tt.data), e, legacy, "id1", "user", tt.roomID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("verifyIdentity() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+// なりすましたフレームは保存もブロードキャストもせずにエラーを返す
+func TestWebsocketHandler_serveConn_RejectImpersonation(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame string
+	}{
+		{
+			name:  "[異常系] 他のユーザーの名前で送信",
+			frame: `{"v":1,"type":"chat.send","id":"c1","payload":{"message":"hello","name":"other"}}`,
+		},
+		{
+			name:  "[異常系] 他のユーザーのIDで送信",
+			frame: `{"v":1,"type":"chat.send","id":"c1","payload":{"message":"hello","userid":"id2"}}`,
+		},
+		{
+			name:  "[異常系] 参加していないRoomに送信",
+			frame: `{"v":1,"type":"chat.send","id":"c1","payload":{"message":"hello","roomid":"5678"}}`,
+		},
+		{
+			name:  "[異常系] 参加していないRoomの履歴を要求",
+			frame: `{"v":1,"type":"history.request","id":"c1","payload":{"roomid":"5678"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			// messageUsecase.Createなどが呼ばれるとgomockが失敗する
+			h, u := newTestWebsocketHandler(ctrl, time.Minute)
+			conn, done := joinTestRoom(t, h, u)
+
+			conn.send(tt.frame)
+			e := waitEvent(t, conn, envelope.TypeError)
+			var p envelope.ErrorPayload
+			if err := e.DecodePayload(&p); err != nil {
+				t.Fatalf("DecodePayload() error = %v", err)
+			}
+			if e.ID != "c1" || p.Code != envelope.ErrCodeForbidden {
+				t.Errorf("error = %s %+v, want id c1 with code %s", e.ID, p, envelope.ErrCodeForbidden)
+			}
+
+			conn.hangup()
+			waitDone(t, done)
+		})
+	}
+}
+
+// 旧形式のクライアントも他のユーザーの名前では送信できない
+func TestWebsocketHandler_serveConn_RejectLegacyImpersonation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, time.Minute)
+	u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1"}, nil)
+	u.participatingRoom.EXPECT().GetUsersByRoomID(gomock.Any(), "1234").Return(&domain.Users{}, nil).Times(2)
+	u.messageMention.EXPECT().MarkReadByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(nil).Times(2)
+	u.message.EXPECT().GetHistory(gomock.Any(), "1234", "id1", "", historyLimit).Return(&domain.Messages{}, nil)
+	u.messagePin.EXPECT().GetByRoomID(gomock.Any(), "1234").Return(&domain.Messages{}, nil)
+
+	conn := &fakeConn{}
+	done := startServeConn(h, conn)
+	conn.send(`{"roomid":"1234","name":"user"}`)
+	frames := waitFrames(t, conn, 3) // ようこそ、履歴、入室
+
+	conn.send(`{"roomid":"1234","message":"hello","name":"other","toname":""}`)
+	frames = waitFrames(t, conn, len(frames)+1)
+	var msg Message
+	if err := json.Unmarshal(frames[len(frames)-1], &msg); err != nil {
+		t.Fatalf("json.Unmarshal() error = %v", err)
+	}
+	if msg.Name != "Server" || msg.Message == "" {
+		t.Errorf("last message = %+v, want error from Server", msg)
+	}
+
+	conn.hangup()
+	waitDone(t, done)
+}
+
+func TestWebsocketHandler_serveConn_RejectJoinImpersonation(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, _ := newTestWebsocketHandler(ctrl, time.Minute)
+	conn := &fakeConn{}
+	done := startServeConn(h, conn)
+
+	conn.send(`{"roomid":"1234","name":"other"}`)
+	waitDone(t, done)
+	if code, _ := conn.closeStatus(); code != closeCodeForbidden {
+		t.Errorf("close code = %d, want %d", code, closeCodeForbidden)
+	}
+}
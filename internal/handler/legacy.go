@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
+)
+
+// 旧形式(フラットなMessage)とエンベロープ形式の互換処理
+// 旧形式のroom.jsを読み込んだままのクライアントがいなくなったら削除する
+
+// 受信したフレームをエンベロープに変換する。旧形式だった場合はlegacyにtrueを返す
+// 旧形式では最初のフレームが参加するRoomの指定になる
+func decodeFrame(data []byte, first bool) (e *envelope.Envelope, legacy bool, err error) {
+	if envelope.IsEnvelope(data) {
+		e, err = envelope.Decode(data)
+		return e, false, err
+	}
+
+	var msg Message
+	err = json.Unmarshal(data, &msg)
+	if err != nil {
+		return nil, true, fmt.Errorf("json.Unmarshal error: %w", err)
+	}
+
+	e, err = legacyToEnvelope(msg, first)
+	return e, true, err
+}
+
+// 旧形式のメッセージをエンベロープに変換する
+func legacyToEnvelope(msg Message, first bool) (*envelope.Envelope, error) {
+	switch {
+	case first:
+		return envelope.New(envelope.TypeRoomJoin, "", &envelope.RoomJoinPayload{RoomID: msg.RoomID})
+	case msg.Type == messageTypeHistory:
+		return envelope.New(envelope.TypeHistoryRequest, "", &envelope.HistoryRequestPayload{Before: msg.Before})
+	default:
+		return envelope.New(envelope.TypeChatSend, "", &envelope.ChatSendPayload{Message: msg.Message, ToName: msg.ToName})
+	}
+}
+
+// イベントを旧形式のメッセージに変換する
+// 旧形式で表現できるイベント。それ以外のイベントは旧形式のクライアントには送らない
+var legacyEventTypes = map[string]bool{
+	envelope.TypeChatMessage:    true,
+	envelope.TypeHistoryPage:    true,
+	envelope.TypePresenceUpdate: true,
+	envelope.TypeSystemNotice:   true,
+	envelope.TypeError:          true,
+}
+
+// 旧形式のクライアントに送るイベントかどうか
+// スレッドの概念がないため、Roomのタイムラインに表示しないスレッドへの返信は送らない
+func legacySupports(ev Event) bool {
+	if !legacyEventTypes[ev.Type] {
+		return false
+	}
+	if p, ok := ev.Payload.(*envelope.ChatMessagePayload); ok && p.ParentID != "" && !p.AlsoToRoom {
+		return false
+	}
+	return true
+}
+
+func eventToLegacy(ev Event) Message {
+	switch p := ev.Payload.(type) {
+	case *envelope.ChatMessagePayload:
+		return Message{ID: p.ID, RoomID: p.RoomID, Message: p.Message, Name: p.Name, ToName: p.ToName}
+	case *envelope.HistoryPagePayload:
+		history := make([]HistoryMessage, 0, len(p.Messages))
+		for _, m := range p.Messages {
+			history = append(history, HistoryMessage{ID: m.ID, Message: m.Message, Name: m.Name, ToName: m.ToName, CreatedAt: m.CreatedAt})
+		}
+		return Message{Type: messageTypeHistory, RoomID: p.RoomID, Name: "Server", Before: p.Before, History: history, HasMore: p.HasMore}
+	case *envelope.PresenceUpdatePayload:
+		return Message{RoomID: p.RoomID, Message: p.Message, Name: "Server", AllUsers: p.AllUsers, OnlineUsers: p.OnlineUsers}
+	case *envelope.SystemNoticePayload:
+		if p.Code != 0 {
+			return Message{Type: messageTypeClose, RoomID: p.RoomID, Message: p.Message, Name: "Server", Code: p.Code}
+		}
+		return Message{RoomID: p.RoomID, Message: p.Message, Name: "Server"}
+	case *envelope.ErrorPayload:
+		return Message{Message: p.Message, Name: "Server"}
+	default:
+		return Message{Name: "Server"}
+	}
+}
@@ -0,0 +1,150 @@
+package handler
+
+import (
+	"testing"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
+)
+
+func TestDecodeFrame(t *testing.T) {
+	tests := []struct {
+		name       string
+		data       string
+		first      bool
+		wantType   string
+		wantLegacy bool
+		wantErr    bool
+	}{
+		{
+			name:       "[正常系]エンベロープ形式",
+			data:       `{"v":1,"type":"chat.send","id":"c1","payload":{"message":"hello"}}`,
+			first:      false,
+			wantType:   envelope.TypeChatSend,
+			wantLegacy: false,
+			wantErr:    false,
+		},
+		{
+			name:       "[正常系]旧形式の参加",
+			data:       `{"roomid":"1234","name":"user"}`,
+			first:      true,
+			wantType:   envelope.TypeRoomJoin,
+			wantLegacy: true,
+			wantErr:    false,
+		},
+		{
+			name:       "[正常系]旧形式の履歴要求",
+			data:       `{"type":"history","roomid":"1234","before":"01ARZ3NDEKTSV4RRFFQ69G5FAV"}`,
+			first:      false,
+			wantType:   envelope.TypeHistoryRequest,
+			wantLegacy: true,
+			wantErr:    false,
+		},
+		{
+			name:       "[正常系]旧形式のメッセージ送信",
+			data:       `{"roomid":"1234","message":"hello","name":"user","toname":""}`,
+			first:      false,
+			wantType:   envelope.TypeChatSend,
+			wantLegacy: true,
+			wantErr:    false,
+		},
+		{
+			name:       "[異常系]未対応のバージョン",
+			data:       `{"v":2,"type":"chat.send","payload":{}}`,
+			first:      false,
+			wantLegacy: false,
+			wantErr:    true,
+		},
+		{
+			name:       "[異常系]JSONでない",
+			data:       `hello`,
+			first:      false,
+			wantLegacy: true,
+			wantErr:    true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e, legacy, err := decodeFrame([]byte(tt.data), tt.first)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodeFrame() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if legacy != tt.wantLegacy {
+				t.Errorf("decodeFrame() legacy = %v, want %v", legacy, tt.wantLegacy)
+			}
+			if tt.wantErr {
+				return
+			}
+			if e.Type != tt.wantType {
+				t.Errorf("decodeFrame() type = %s, want %s", e.Type, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestEventToLegacy(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   Event
+		want Message
+	}{
+		{
+			name: "[正常系]チャットメッセージ",
+			ev:   Event{Type: envelope.TypeChatMessage, Payload: &envelope.ChatMessagePayload{ID: "m1", RoomID: "1234", Name: "user", ToName: "to", Message: "<p>hello</p>"}},
+			want: Message{ID: "m1", RoomID: "1234", Message: "<p>hello</p>", Name: "user", ToName: "to"},
+		},
+		{
+			name: "[正常系]切断の通知",
+			ev:   Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{Message: "bye", Code: closeCodeSlowConsumer}},
+			want: Message{Type: messageTypeClose, Message: "bye", Name: "Server", Code: closeCodeSlowConsumer},
+		},
+		{
+			name: "[正常系]エラー",
+			ev:   Event{Type: envelope.TypeError, Payload: &envelope.ErrorPayload{Code: envelope.ErrCodeBadRequest, Message: "bad"}},
+			want: Message{Message: "bad", Name: "Server"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := eventToLegacy(tt.ev)
+			if got.Type != tt.want.Type || got.ID != tt.want.ID || got.RoomID != tt.want.RoomID || got.Message != tt.want.Message || got.Name != tt.want.Name || got.ToName != tt.want.ToName || got.Code != tt.want.Code {
+				t.Errorf("eventToLegacy() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLegacySupports(t *testing.T) {
+	tests := []struct {
+		name string
+		ev   Event
+		want bool
+	}{
+		{
+			name: "[正常系]チャットメッセージ",
+			ev:   Event{Type: envelope.TypeChatMessage, Payload: &envelope.ChatMessagePayload{ID: "m1", RoomID: "1234"}},
+			want: true,
+		},
+		{
+			name: "[正常系]Roomにも送信されたスレッドへの返信",
+			ev:   Event{Type: envelope.TypeChatMessage, Payload: &envelope.ChatMessagePayload{ID: "m2", RoomID: "1234", ParentID: "m1", AlsoToRoom: true}},
+			want: true,
+		},
+		{
+			name: "[異常系]スレッドへの返信",
+			ev:   Event{Type: envelope.TypeChatMessage, Payload: &envelope.ChatMessagePayload{ID: "m2", RoomID: "1234", ParentID: "m1"}},
+			want: false,
+		},
+		{
+			name: "[異常系]旧形式で表現できないイベント",
+			ev:   Event{Type: envelope.TypeThreadUpdate, Payload: &envelope.ThreadUpdatePayload{RoomID: "1234", ID: "m1"}},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := legacySupports(tt.ev); got != tt.want {
+				t.Errorf("legacySupports() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
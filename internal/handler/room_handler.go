@@ -8,40 +8,63 @@ import (
 	"html/template"
 	"log"
 	"net/http"
+	"net/url"
 	"strconv"
 	"time"
 
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/usecase"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/session"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/timefmt"
 	"gorm.io/gorm"
 )
 
+// 検索結果を1回で返す件数
+const searchLimit = 20
+
 type RoomHandler struct {
 	userUsecase              usecase.UserUsecase
 	participatingRoomUsecase usecase.ParticipatingRoomUsecase
 	roomUsecase              usecase.RoomUsecase
+	messageUsecase           usecase.MessageUsecase
+	messageMentionUsecase    usecase.MessageMentionUsecase
+	messagePinUsecase        usecase.MessagePinUsecase
+	roomInviteUsecase        usecase.RoomInviteUsecase
+	joinRequestUsecase       usecase.JoinRequestUsecase
+	roomRoleUsecase          usecase.RoomRoleUsecase
 	templates                *template.Template
 	session                  *session.Sessions
-	rooms                    *domain.Rooms
+	hub                      *Hub
 }
 
 func NewRoomHandler(
 	usecase usecase.UserUsecase,
 	participatingRoomUsecase usecase.ParticipatingRoomUsecase,
 	roomUsecase usecase.RoomUsecase,
+	messageUsecase usecase.MessageUsecase,
+	messageMentionUsecase usecase.MessageMentionUsecase,
+	messagePinUsecase usecase.MessagePinUsecase,
+	roomInviteUsecase usecase.RoomInviteUsecase,
+	joinRequestUsecase usecase.JoinRequestUsecase,
+	roomRoleUsecase usecase.RoomRoleUsecase,
 	s *session.Sessions,
-	rooms *domain.Rooms,
+	hub *Hub,
 ) *RoomHandler {
 	templates := template.Must(template.ParseGlob("internal/handler/templates/*.html"))
-	roomInit(rooms)
 	return &RoomHandler{
 		userUsecase:              usecase,
 		participatingRoomUsecase: participatingRoomUsecase,
 		roomUsecase:              roomUsecase,
+		messageUsecase:           messageUsecase,
+		messageMentionUsecase:    messageMentionUsecase,
+		messagePinUsecase:        messagePinUsecase,
+		roomInviteUsecase:        roomInviteUsecase,
+		joinRequestUsecase:       joinRequestUsecase,
+		roomRoleUsecase:          roomRoleUsecase,
 		templates:                templates,
 		session:                  s,
-		rooms:                    rooms,
+		hub:                      hub,
 	}
 }
 
@@ -107,7 +130,27 @@ func (h *RoomHandler) Top(w http.ResponseWriter, r *http.Request) {
 		}
 
 		// Room作成
-		room, err := h.roomUsecase.Create(ctx, &domain.Room{})
+		slug := r.FormValue("slug")
+		room, err := h.roomUsecase.Create(ctx, &domain.Room{
+			Slug:        &slug,
+			Name:        r.FormValue("name"),
+			Topic:       r.FormValue("topic"),
+			Description: r.FormValue("description"),
+			Visibility:  r.FormValue("visibility"),
+		})
+		if errors.Is(err, domain.ErrInvalidRoom) || errors.Is(err, domain.ErrSlugTaken) {
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = err.Error()
+
+			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
 		if err != nil {
 			log.Printf("roomUsecase.Create error: %v\n", err)
 			// メッセージをテンプレートに渡す
@@ -122,13 +165,13 @@ func (h *RoomHandler) Top(w http.ResponseWriter, r *http.Request) {
 			}
 			return
 		}
-		createRoom(room.ID)
+		h.hub.Create(room.ID)
 
-		// 参加中のルーム一覧にMasterとして追加
+		// 参加中のルーム一覧に作成者として追加
 		proom := domain.ParticipatingRoom{
-			RoomID:   room.ID,
-			IsMaster: true,
-			UserID:   user.ID,
+			RoomID: room.ID,
+			Role:   domain.RoleOwner,
+			UserID: user.ID,
 		}
 		err = h.participatingRoomUsecase.Create(ctx, &proom)
 		if err != nil {
@@ -148,7 +191,11 @@ func (h *RoomHandler) Top(w http.ResponseWriter, r *http.Request) {
 
 		// メッセージをテンプレートに渡す
 		var data Data
-		data.Message = "ルーム " + room.ID + " が作成されました。"
+		if room.Slug != nil {
+			data.Message = "ルーム " + room.Name + "（" + *room.Slug + "） が作成されました。"
+		} else {
+			data.Message = "ルーム " + room.Name + "（" + room.ID + "） が作成されました。"
+		}
 
 		err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
 		if err != nil {
@@ -191,12 +238,14 @@ func (h *RoomHandler) Room(w http.ResponseWriter, r *http.Request) {
 		}
 		roomid := r.URL.Query().Get("roomid")
 
-		intRoomID, err := strconv.Atoi(roomid)
-		if err != nil {
-			log.Printf("strconv.Atoi error: %v\n", err)
+		// IDまたはスラッグからRoomを取得
+		room, err := h.roomUsecase.Resolve(ctx, roomid)
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 指定した部屋が存在していなかったら
+			log.Println("This room was not found")
+
 			// メッセージをテンプレートに渡す
 			var data Data
-			data.Message = "ルームIDの形式が正しくありません。"
+			data.Message = "そのIDのルームは見つかりませんでした。"
 
 			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
 			if err != nil {
@@ -206,11 +255,11 @@ func (h *RoomHandler) Room(w http.ResponseWriter, r *http.Request) {
 			}
 			return
 		}
-		if intRoomID < 1 || 9999 < intRoomID {
-			log.Println("ルームIDの範囲外です。")
+		if err != nil {
+			log.Printf("roomUsecase.Resolve error: %v\n", err)
 			// メッセージをテンプレートに渡す
 			var data Data
-			data.Message = "ルームIDの範囲外です。"
+			data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
 
 			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
 			if err != nil {
@@ -221,36 +270,60 @@ func (h *RoomHandler) Room(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Roomが存在するか確認
-		exists, err := h.roomUsecase.IDExists(ctx, roomid)
-		if err != nil {
-			log.Printf("roomUsecase.IDExists error: %v\n", err)
-			// メッセージをテンプレートに渡す
-			var data Data
-			data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+		// スラッグで開いた場合は、ページ内で使うIDのURLに移動させる
+		if roomid != room.ID {
+			http.Redirect(w, r, "/room?roomid="+url.QueryEscape(room.ID), http.StatusFound)
+			return
+		}
 
-			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+		// 公開されていないRoomは参加者のみ開ける
+		if !room.IsPublic() {
+			// セッション読み取り
+			userID, _, err := h.session.GetUserData(r)
 			if err != nil {
-				log.Printf("templates.ExecuteTemplate error:%v\n", err)
-				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				log.Printf("session.GetUserData error: %v\n", err)
+				// メッセージをテンプレートに渡す
+				var data Data
+				data.Message = "再ログインしてください"
+
+				err = h.templates.ExecuteTemplate(w, "login.html", data)
+				if err != nil {
+					log.Printf("templates.ExecuteTemplate error:%v\n", err)
+					http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+					return
+				}
 				return
 			}
-			return
-		}
-		if !*exists { // 指定した部屋が存在していなかったら
-			log.Println("This room was not found")
 
-			// メッセージをテンプレートに渡す
-			var data Data
-			data.Message = "そのIDのルームは見つかりませんでした。"
+			_, err = h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, userID, room.ID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("not a member of room %s: %s\n", room.ID, userID)
+				// メッセージをテンプレートに渡す
+				var data Data
+				data.Message = notMemberMessage(room)
 
-			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+				err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+				if err != nil {
+					log.Printf("templates.ExecuteTemplate error:%v\n", err)
+					http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+					return
+				}
+				return
+			}
 			if err != nil {
-				log.Printf("templates.ExecuteTemplate error:%v\n", err)
-				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+				// メッセージをテンプレートに渡す
+				var data Data
+				data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+
+				err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+				if err != nil {
+					log.Printf("templates.ExecuteTemplate error:%v\n", err)
+					http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+					return
+				}
 				return
 			}
-			return
 		}
 
 		err = h.templates.ExecuteTemplate(w, "room.html", nil)
@@ -289,28 +362,14 @@ func (h *RoomHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			}
 			return
 		}
-		roomid := r.URL.Query().Get("roomid")
-
-		intRoomID, err := strconv.Atoi(roomid)
-		if err != nil {
-			log.Printf("strconv.Atoi error: %v\n", err)
-			// メッセージをテンプレートに渡す
-			var data Data
-			data.Message = "ルームIDの形式が正しくありません。"
+		// IDまたはスラッグからRoomを取得
+		room, err := h.roomUsecase.Resolve(ctx, r.URL.Query().Get("roomid"))
+		if errors.Is(err, gorm.ErrRecordNotFound) { // 指定した部屋が存在していなかったら
+			log.Println("This room was not found")
 
-			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
-			if err != nil {
-				log.Printf("templates.ExecuteTemplate error:%v\n", err)
-				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
-				return
-			}
-			return
-		}
-		if intRoomID < 1 || 9999 < intRoomID {
-			log.Println("ルームIDの範囲外です。")
 			// メッセージをテンプレートに渡す
 			var data Data
-			data.Message = "ルームIDの範囲外です。"
+			data.Message = "そのIDのルームは見つかりませんでした。"
 
 			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
 			if err != nil {
@@ -320,11 +379,8 @@ func (h *RoomHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			}
 			return
 		}
-
-		// Roomが存在するか確認
-		exists, err := h.roomUsecase.IDExists(ctx, roomid)
 		if err != nil {
-			log.Printf("roomUsecase.IDExists error: %v\n", err)
+			log.Printf("roomUsecase.Resolve error: %v\n", err)
 			// メッセージをテンプレートに渡す
 			var data Data
 			data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
@@ -337,12 +393,13 @@ func (h *RoomHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			}
 			return
 		}
-		if !*exists { // 指定した部屋が存在していなかったら
-			log.Println("This room was not found")
+		roomid := room.ID
 
+		// ダイレクトメッセージのRoomは2人の参加者を保つため、削除も離脱もできない
+		if room.IsDirect() {
 			// メッセージをテンプレートに渡す
 			var data Data
-			data.Message = "そのIDのルームは見つかりませんでした。"
+			data.Message = "ダイレクトメッセージは削除や離脱ができません。"
 
 			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
 			if err != nil {
@@ -416,8 +473,8 @@ func (h *RoomHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// 部屋の作成者ではない場合は、部屋から離脱
-		if !proom.IsMaster {
+		// 部屋を削除する権限がない場合は、部屋から離脱
+		if !proom.Can(domain.PermDeleteRoom) {
 			// 部屋離脱
 			err := h.participatingRoomUsecase.DeleteByUserIDAndRoomID(ctx, user.ID, roomid)
 			if err != nil {
@@ -465,6 +522,23 @@ func (h *RoomHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		// 部屋のメッセージ削除
+		err = h.messageUsecase.DeleteByRoomID(ctx, roomid)
+		if err != nil {
+			log.Printf("messageUsecase.DeleteByRoomID error: %v\n", err)
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+
+			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
 		// 部屋削除
 		err = h.roomUsecase.Delete(ctx, roomid)
 		if err != nil {
@@ -482,6 +556,8 @@ func (h *RoomHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		h.hub.Delete(roomid)
+
 		// メッセージをテンプレートに渡す
 		var data Data
 		data.Message = "部屋を削除しました。"
@@ -545,10 +621,74 @@ func (h *RoomHandler) JoinRoomsList(w http.ResponseWriter, r *http.Request) {
 			http.Error(w, fmt.Sprintf("participatingRoomUsecase.GetByUserID error: %v", err), http.StatusInternalServerError)
 			return
 		}
+
+		// ダイレクトメッセージのRoomは相手のユーザー名と一緒に別に返す
+		peers, err := h.participatingRoomUsecase.GetDirectPeersByUserID(ctx, user.ID)
+		if err != nil {
+			log.Printf("participatingRoomUsecase.GetDirectPeersByUserID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("participatingRoomUsecase.GetDirectPeersByUserID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		directRoomIDs := make(map[string]bool, len(*peers))
+		for _, peer := range *peers {
+			directRoomIDs[peer.RoomID] = true
+			joinroomslist.DirectRooms = append(joinroomslist.DirectRooms, SentDirectRoom{RoomID: peer.RoomID, Name: peer.UserName})
+		}
+
 		for _, proom := range *prooms {
+			if directRoomIDs[proom.RoomID] {
+				continue
+			}
 			joinroomslist.RoomsList = append(joinroomslist.RoomsList, proom.RoomID)
 		}
 
+		// Roomの名前、トピック、説明
+		rooms, err := h.roomUsecase.GetByIDs(ctx, joinroomslist.RoomsList)
+		if err != nil {
+			log.Printf("roomUsecase.GetByIDs error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomUsecase.GetByIDs error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, room := range *rooms {
+			joinroomslist.Rooms = append(joinroomslist.Rooms, toSentRoom(&room))
+		}
+
+		// Roomごとの未読メッセージ数と最後の発言日時
+		activities, err := h.participatingRoomUsecase.GetActivitiesByUserID(ctx, user.ID)
+		if err != nil {
+			log.Printf("participatingRoomUsecase.GetActivitiesByUserID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("participatingRoomUsecase.GetActivitiesByUserID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, activity := range *activities {
+			if activity.UnreadCount > 0 {
+				if joinroomslist.Unread == nil {
+					joinroomslist.Unread = make(map[string]int64)
+				}
+				joinroomslist.Unread[activity.RoomID] = activity.UnreadCount
+			}
+			if activity.LastActivityAt != nil {
+				if joinroomslist.LastActivity == nil {
+					joinroomslist.LastActivity = make(map[string]string)
+				}
+				joinroomslist.LastActivity[activity.RoomID] = timefmt.TimeToStr(*activity.LastActivityAt)
+			}
+		}
+
+		// Roomごとの未読メンション数
+		counts, err := h.messageMentionUsecase.CountUnreadByUserID(ctx, user.ID)
+		if err != nil {
+			log.Printf("messageMentionUsecase.CountUnreadByUserID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("messageMentionUsecase.CountUnreadByUserID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		for _, count := range *counts {
+			if joinroomslist.UnreadMentions == nil {
+				joinroomslist.UnreadMentions = make(map[string]int64)
+			}
+			joinroomslist.UnreadMentions[count.RoomID] = count.Count
+		}
+
 		// jsonに変換
 		sentjson, err := json.Marshal(joinroomslist)
 		if err != nil {
@@ -590,9 +730,13 @@ func (h *RoomHandler) RoomsList(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// Roomを格納
+		// Roomを格納。ダイレクトメッセージと招待制のRoomは含めない
 		for _, room := range *rooms {
+			if room.IsDirect() || room.Visibility == domain.RoomVisibilityInvite {
+				continue
+			}
 			roomslist.RoomsList = append(roomslist.RoomsList, room.ID)
+			roomslist.Rooms = append(roomslist.Rooms, toSentRoom(&room))
 		}
 
 		// jsonに変換
@@ -617,3 +761,1180 @@ func (h *RoomHandler) RoomsList(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 }
+
+// Roomの名前、トピック、説明を返す。POSTでは設定を変更する権限がある参加者のみ変更できる
+func (h *RoomHandler) RoomInfo(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, err := h.roomUsecase.Resolve(ctx, r.PathValue("id"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("roomUsecase.Resolve error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomUsecase.Resolve error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// 公開されていないRoomの情報は参加者のみ取得できる
+		if !room.IsPublic() {
+			// セッション読み取り
+			userID, _, err := h.session.GetUserData(r)
+			if err != nil {
+				log.Printf("session.GetUserData error: %v\n", err)
+				http.Error(w, "再ログインしてください", http.StatusUnauthorized)
+				return
+			}
+
+			_, err = h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, userID, room.ID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+				return
+			}
+			if err != nil {
+				log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+				http.Error(w, fmt.Sprintf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(toSentRoom(room))
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		// セッション読み取り
+		userID, userName, err := h.session.GetUserData(r)
+		if err != nil {
+			log.Printf("session.GetUserData error: %v\n", err)
+			http.Error(w, "再ログインしてください", http.StatusUnauthorized)
+			return
+		}
+
+		room, err := h.roomUsecase.Resolve(ctx, r.PathValue("id"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("roomUsecase.Resolve error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomUsecase.Resolve error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		roomID := room.ID
+
+		// 設定を変更する権限がある参加者のみ変更できる
+		_, err = h.roomRoleUsecase.Authorize(ctx, userID, roomID, domain.PermEditSettings)
+		if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrForbidden) {
+			http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			log.Printf("roomRoleUsecase.Authorize error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomRoleUsecase.Authorize error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		oldTopic := room.Topic
+		room.Name = r.FormValue("name")
+		room.Topic = r.FormValue("topic")
+		room.Description = r.FormValue("description")
+		// スラッグは送られてきた場合のみ変更する。空なら外す
+		if _, ok := r.Form["slug"]; ok {
+			slug := r.FormValue("slug")
+			room.Slug = &slug
+		}
+		if _, ok := r.Form["visibility"]; ok && !room.IsDirect() {
+			room.Visibility = r.FormValue("visibility")
+		}
+		err = h.roomUsecase.Update(ctx, room)
+		if errors.Is(err, domain.ErrInvalidRoom) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrSlugTaken) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("roomUsecase.Update error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomUsecase.Update error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Roomを開いているクライアントに変更を通知する
+		if roomHub, exists := h.hub.Get(roomID); exists {
+			if room.Topic != oldTopic {
+				roomHub.Broadcast(Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{RoomID: roomID, Message: userName + "がトピックを「" + room.Topic + "」に変更しました"}})
+			}
+			sent := toSentRoom(room)
+			roomHub.Broadcast(Event{Type: envelope.TypeRoomUpdate, Payload: &envelope.RoomUpdatePayload{RoomID: roomID, Slug: sent.Slug, Name: room.Name, Topic: room.Topic, Description: room.Description, Visibility: sent.Visibility}})
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(toSentRoom(room))
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+func toSentRoom(room *domain.Room) SentRoom {
+	sent := SentRoom{ID: room.ID, Name: room.Name, Topic: room.Topic, Description: room.Description}
+	if !room.IsDirect() {
+		sent.Visibility = room.Visibility
+	}
+	if room.Slug != nil {
+		sent.Slug = *room.Slug
+	}
+	return sent
+}
+
+// ユーザー名を指定してダイレクトメッセージのRoomを開始する。既にあればそのRoomを返す
+func (h *RoomHandler) Direct(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		// セッション読み取り
+		userID, _, err := h.session.GetUserData(r)
+		if err != nil {
+			log.Printf("session.GetUserData error: %v\n", err)
+			http.Error(w, "再ログインしてください", http.StatusUnauthorized)
+			return
+		}
+
+		name := r.FormValue("name")
+		peer, err := h.userUsecase.GetByName(ctx, name)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "ユーザーが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("userUsecase.GetByName error: %v\n", err)
+			http.Error(w, fmt.Sprintf("userUsecase.GetByName error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		room, err := h.roomUsecase.GetOrCreateDirect(ctx, userID, peer.ID)
+		if errors.Is(err, domain.ErrDirectSelf) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("roomUsecase.GetOrCreateDirect error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomUsecase.GetOrCreateDirect error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		h.hub.CreateDirect(room.ID)
+
+		// jsonに変換
+		sentjson, err := json.Marshal(SentDirectRoom{RoomID: room.ID, Name: peer.Name})
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// Roomにピン留めされているメッセージの一覧を返す
+func (h *RoomHandler) Pins(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		// セッション読み取り
+		userID, _, err := h.session.GetUserData(r)
+		if err != nil {
+			log.Printf("session.GetUserData error: %v\n", err)
+			http.Error(w, "再ログインしてください", http.StatusUnauthorized)
+			return
+		}
+
+		room, err := h.roomUsecase.Resolve(ctx, r.PathValue("id"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("roomUsecase.Resolve error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomUsecase.Resolve error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		roomID := room.ID
+
+		// 参加しているRoomのみ閲覧できる
+		_, err = h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, userID, roomID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomに参加していません。", http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		messages, err := h.messagePinUsecase.GetByRoomID(ctx, roomID)
+		if err != nil {
+			log.Printf("messagePinUsecase.GetByRoomID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("messagePinUsecase.GetByRoomID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		pins := envelope.PinUpdatePayload{RoomID: roomID, Pins: make([]envelope.ChatMessagePayload, 0, len(*messages))}
+		for i := range *messages {
+			pins.Pins = append(pins.Pins, *toChatMessagePayload(&(*messages)[i]))
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(pins)
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// 参加しているRoomのメッセージを全文検索する
+func (h *RoomHandler) Search(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		// セッション読み取り
+		userID, _, err := h.session.GetUserData(r)
+		if err != nil {
+			log.Printf("session.GetUserData error: %v\n", err)
+			http.Error(w, "再ログインしてください", http.StatusUnauthorized)
+			return
+		}
+
+		query := r.URL.Query().Get("q")
+		before := r.URL.Query().Get("before")
+
+		results, err := h.messageUsecase.Search(ctx, userID, query, before, searchLimit)
+		if errors.Is(err, domain.ErrInvalidSearch) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("messageUsecase.Search error: %v\n", err)
+			http.Error(w, fmt.Sprintf("messageUsecase.Search error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		sent := SentSearchResults{Results: make([]SearchResult, 0, len(*results))}
+		for _, result := range *results {
+			sent.Results = append(sent.Results, SearchResult{
+				ID:        result.ID,
+				RoomID:    result.RoomID,
+				Name:      result.UserName,
+				ToName:    result.ToName,
+				ParentID:  result.ParentID,
+				Snippet:   result.Snippet,
+				CreatedAt: timefmt.TimeToStr(result.CreatedAt),
+			})
+		}
+		// 件数が上限に達した場合は続きがある可能性がある
+		if len(sent.Results) == searchLimit {
+			sent.Next = sent.Results[len(sent.Results)-1].ID
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(sent)
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// 参加していない、公開されていないRoomを開こうとしたときのメッセージ
+func notMemberMessage(room *domain.Room) string {
+	switch room.Visibility {
+	case domain.RoomVisibilityInvite:
+		return "このルームは招待制です。招待リンクから参加してください。"
+	case domain.RoomVisibilityRequest:
+		return "このルームは参加承認制です。参加リクエストを送信してください。"
+	default:
+		return "このRoomには参加できません。"
+	}
+}
+
+// パスのIDまたはスラッグのRoomを取得し、ログイン中のユーザーが役割で操作を許可されているか確認する
+// 許可されていればRoomと参加情報を返す。許可されていなければエラーを返してfalseを返す
+func (h *RoomHandler) authorizedRoom(ctx context.Context, w http.ResponseWriter, r *http.Request, perm domain.Permission) (*domain.Room, *domain.ParticipatingRoom, bool) {
+	// セッション読み取り
+	userID, _, err := h.session.GetUserData(r)
+	if err != nil {
+		log.Printf("session.GetUserData error: %v\n", err)
+		http.Error(w, "再ログインしてください", http.StatusUnauthorized)
+		return nil, nil, false
+	}
+
+	room, err := h.roomUsecase.Resolve(ctx, r.PathValue("id"))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		http.Error(w, "Roomが見つかりません。", http.StatusNotFound)
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Printf("roomUsecase.Resolve error: %v\n", err)
+		http.Error(w, fmt.Sprintf("roomUsecase.Resolve error: %v", err), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+
+	proom, err := h.roomRoleUsecase.Authorize(ctx, userID, room.ID, perm)
+	if errors.Is(err, gorm.ErrRecordNotFound) || errors.Is(err, domain.ErrForbidden) {
+		http.Error(w, domain.ErrForbidden.Error(), http.StatusForbidden)
+		return nil, nil, false
+	}
+	if err != nil {
+		log.Printf("roomRoleUsecase.Authorize error: %v\n", err)
+		http.Error(w, fmt.Sprintf("roomRoleUsecase.Authorize error: %v", err), http.StatusInternalServerError)
+		return nil, nil, false
+	}
+	return room, proom, true
+}
+
+// Roomの招待リンク一覧の取得と作成。招待の権限がある参加者のみ
+func (h *RoomHandler) Invites(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, _, ok := h.authorizedRoom(ctx, w, r, domain.PermInvite)
+		if !ok {
+			return
+		}
+
+		invites, err := h.roomInviteUsecase.GetActiveByRoomID(ctx, room.ID)
+		if err != nil {
+			log.Printf("roomInviteUsecase.GetActiveByRoomID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomInviteUsecase.GetActiveByRoomID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sent := SentInvites{Invites: make([]SentInvite, 0, len(*invites))}
+		for i := range *invites {
+			sent.Invites = append(sent.Invites, h.toSentInvite(&(*invites)[i]))
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(sent)
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, proom, ok := h.authorizedRoom(ctx, w, r, domain.PermInvite)
+		if !ok {
+			return
+		}
+
+		var err error
+		// 有効期間（分）。指定がなければ1日
+		expires := 24 * 60
+		if v := r.FormValue("expires"); v != "" {
+			expires, err = strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, domain.ErrInvalidInviteSetting.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+		// 使用回数の上限。指定がなければ無制限
+		maxUses := 0
+		if v := r.FormValue("maxuses"); v != "" {
+			maxUses, err = strconv.Atoi(v)
+			if err != nil {
+				http.Error(w, domain.ErrInvalidInviteSetting.Error(), http.StatusBadRequest)
+				return
+			}
+		}
+
+		invite, err := h.roomInviteUsecase.Create(ctx, room.ID, proom.UserID, time.Duration(expires)*time.Minute, maxUses)
+		if errors.Is(err, domain.ErrInvalidInviteSetting) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("roomInviteUsecase.Create error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomInviteUsecase.Create error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(h.toSentInvite(invite))
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// 招待リンクを無効にする。招待の権限がある参加者のみ
+func (h *RoomHandler) Invite(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, _, ok := h.authorizedRoom(ctx, w, r, domain.PermInvite)
+		if !ok {
+			return
+		}
+
+		err := h.roomInviteUsecase.Delete(ctx, room.ID, r.PathValue("inviteid"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "招待リンクが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("roomInviteUsecase.Delete error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomInviteUsecase.Delete error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+func (h *RoomHandler) toSentInvite(invite *domain.RoomInvite) SentInvite {
+	return SentInvite{
+		ID:        invite.ID,
+		URL:       "/invite?token=" + url.QueryEscape(h.roomInviteUsecase.Token(invite)),
+		MaxUses:   invite.MaxUses,
+		Uses:      invite.Uses,
+		ExpiresAt: timefmt.TimeToStr(invite.ExpiresAt),
+	}
+}
+
+// 招待リンクからRoomに参加する
+func (h *RoomHandler) JoinByInvite(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		// セッション読み取り
+		userID, _, err := h.session.GetUserData(r)
+		if err != nil {
+			log.Printf("session.GetUserData error: %v\n", err)
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = "ログインしてから招待リンクを開いてください"
+
+			err = h.templates.ExecuteTemplate(w, "login.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		invite, err := h.roomInviteUsecase.Verify(ctx, r.URL.Query().Get("token"))
+		if errors.Is(err, domain.ErrInvalidInvite) || errors.Is(err, domain.ErrInviteExpired) || errors.Is(err, domain.ErrInviteUsedUp) {
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = err.Error()
+
+			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+		if err != nil {
+			log.Printf("roomInviteUsecase.Verify error: %v\n", err)
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+
+			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		// 既に参加していれば招待リンクを使わずにRoomに移動する
+		_, err = h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, userID, invite.RoomID)
+		if err == nil {
+			http.Redirect(w, r, "/room?roomid="+url.QueryEscape(invite.RoomID), http.StatusFound)
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+
+			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		err = h.roomInviteUsecase.Use(ctx, invite)
+		if errors.Is(err, domain.ErrInvalidInvite) || errors.Is(err, domain.ErrInviteExpired) || errors.Is(err, domain.ErrInviteUsedUp) {
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = err.Error()
+
+			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+		if err != nil {
+			log.Printf("roomInviteUsecase.Use error: %v\n", err)
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+
+			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		// 参加中のルーム一覧に参加者として追加
+		proom := domain.ParticipatingRoom{
+			RoomID: invite.RoomID,
+			Role:   domain.RoleMember,
+			UserID: userID,
+		}
+		err = h.participatingRoomUsecase.Create(ctx, &proom)
+		if err != nil {
+			log.Printf("participatingRoomUsecase.Create error: %v\n", err)
+			// メッセージをテンプレートに渡す
+			var data Data
+			data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+
+			err = h.templates.ExecuteTemplate(w, "roomtop.html", data)
+			if err != nil {
+				log.Printf("templates.ExecuteTemplate error:%v\n", err)
+				http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+				return
+			}
+			return
+		}
+
+		http.Redirect(w, r, "/room?roomid="+url.QueryEscape(invite.RoomID), http.StatusFound)
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// 参加リクエスト一覧の取得（招待の権限がある参加者のみ）と、参加リクエストの送信
+func (h *RoomHandler) JoinRequests(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, _, ok := h.authorizedRoom(ctx, w, r, domain.PermInvite)
+		if !ok {
+			return
+		}
+
+		requests, err := h.joinRequestUsecase.GetPendingByRoomID(ctx, room.ID)
+		if err != nil {
+			log.Printf("joinRequestUsecase.GetPendingByRoomID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("joinRequestUsecase.GetPendingByRoomID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sent := SentJoinRequests{Requests: make([]SentJoinRequest, 0, len(*requests))}
+		for i := range *requests {
+			sent.Requests = append(sent.Requests, toSentJoinRequest(&(*requests)[i]))
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(sent)
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		// セッション読み取り
+		userID, userName, err := h.session.GetUserData(r)
+		if err != nil {
+			log.Printf("session.GetUserData error: %v\n", err)
+			http.Error(w, "再ログインしてください", http.StatusUnauthorized)
+			return
+		}
+
+		room, err := h.roomUsecase.Resolve(ctx, r.PathValue("id"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("roomUsecase.Resolve error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomUsecase.Resolve error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		_, err = h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, userID, room.ID)
+		if err == nil {
+			http.Error(w, "既にRoomに参加しています。", http.StatusConflict)
+			return
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		request, err := h.joinRequestUsecase.Request(ctx, room, userID, userName)
+		if errors.Is(err, domain.ErrJoinRequestNotAllowed) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, domain.ErrJoinRequestExists) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("joinRequestUsecase.Request error: %v\n", err)
+			http.Error(w, fmt.Sprintf("joinRequestUsecase.Request error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// 参加リクエストを承認できる参加者に通知する
+		prooms, err := h.participatingRoomUsecase.GetByRoomID(ctx, room.ID)
+		if err != nil {
+			log.Printf("participatingRoomUsecase.GetByRoomID error: %v\n", err)
+		} else {
+			for _, proom := range *prooms {
+				if proom.Can(domain.PermInvite) {
+					h.hub.SendToUser(proom.UserID, Event{Type: envelope.TypeJoinRequest, Payload: &envelope.JoinRequestPayload{RoomID: room.ID, ID: request.ID, Name: userName}})
+				}
+			}
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(toSentJoinRequest(request))
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// 参加リクエストの承認（action=approve）または却下（action=deny）。招待の権限がある参加者のみ
+func (h *RoomHandler) JoinRequest(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, _, ok := h.authorizedRoom(ctx, w, r, domain.PermInvite)
+		if !ok {
+			return
+		}
+
+		var approve bool
+		switch r.FormValue("action") {
+		case "approve":
+			approve = true
+		case "deny":
+			approve = false
+		default:
+			http.Error(w, "actionにはapproveかdenyを指定してください。", http.StatusBadRequest)
+			return
+		}
+
+		request, err := h.joinRequestUsecase.Decide(ctx, room.ID, r.PathValue("requestid"), approve)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "参加リクエストが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrJoinRequestDecided) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			log.Printf("joinRequestUsecase.Decide error: %v\n", err)
+			http.Error(w, fmt.Sprintf("joinRequestUsecase.Decide error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		if approve {
+			// 参加中のルーム一覧に参加者として追加
+			_, err = h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, request.UserID, room.ID)
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				proom := domain.ParticipatingRoom{
+					RoomID: room.ID,
+					Role:   domain.RoleMember,
+					UserID: request.UserID,
+				}
+				err = h.participatingRoomUsecase.Create(ctx, &proom)
+			}
+			if err != nil {
+				log.Printf("participatingRoomUsecase.Create error: %v\n", err)
+				http.Error(w, fmt.Sprintf("participatingRoomUsecase.Create error: %v", err), http.StatusInternalServerError)
+				return
+			}
+		}
+
+		// リクエストしたユーザーに結果を通知する
+		h.hub.SendToUser(request.UserID, Event{Type: envelope.TypeJoinDecision, Payload: &envelope.JoinDecisionPayload{RoomID: room.ID, Approved: approve}})
+
+		// jsonに変換
+		sentjson, err := json.Marshal(toSentJoinRequest(request))
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+func toSentJoinRequest(request *domain.JoinRequest) SentJoinRequest {
+	return SentJoinRequest{
+		ID:        request.ID,
+		Name:      request.UserName,
+		Status:    request.Status,
+		CreatedAt: timefmt.TimeToStr(request.CreatedAt),
+	}
+}
+
+// Roomの参加者と役割の一覧を返す。参加者のみ
+func (h *RoomHandler) Members(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		// セッション読み取り
+		userID, _, err := h.session.GetUserData(r)
+		if err != nil {
+			log.Printf("session.GetUserData error: %v\n", err)
+			http.Error(w, "再ログインしてください", http.StatusUnauthorized)
+			return
+		}
+
+		room, err := h.roomUsecase.Resolve(ctx, r.PathValue("id"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("roomUsecase.Resolve error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomUsecase.Resolve error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// 参加しているRoomのみ閲覧できる
+		_, err = h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, userID, room.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomに参加していません。", http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		prooms, err := h.roomRoleUsecase.GetMembers(ctx, room.ID)
+		if err != nil {
+			log.Printf("roomRoleUsecase.GetMembers error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomRoleUsecase.GetMembers error: %v", err), http.StatusInternalServerError)
+			return
+		}
+		members := SentMembers{Members: make([]SentMember, 0, len(*prooms))}
+		for _, proom := range *prooms {
+			members.Members = append(members.Members, SentMember{UserID: proom.UserID, Name: proom.User.Name, Role: proom.Role})
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(members)
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// 参加者をRoomから退出させる。自分より弱い役割の参加者のみ
+func (h *RoomHandler) Member(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodDelete:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, proom, ok := h.authorizedRoom(ctx, w, r, domain.PermKick)
+		if !ok {
+			return
+		}
+
+		target, err := h.userUsecase.GetByID(ctx, r.PathValue("userid"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "ユーザーが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("userUsecase.GetByID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("userUsecase.GetByID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		err = h.roomRoleUsecase.Kick(ctx, proom.UserID, room.ID, target.ID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomに参加していないユーザーです。", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			log.Printf("roomRoleUsecase.Kick error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomRoleUsecase.Kick error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// 退出させたユーザーの接続を切断し、Roomに通知する
+		if roomHub, exists := h.hub.Get(room.ID); exists {
+			err = roomHub.CloseUser(target.ID, closeCodeKicked, "Roomから退出させられました。")
+			if err != nil {
+				log.Printf("roomHub.CloseUser error: %v\n", err)
+			}
+			roomHub.Broadcast(Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{RoomID: room.ID, Message: target.Name + "がRoomから退出させられました"}})
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// 参加者の役割を変更する。自分より弱い役割の参加者を、自分より弱い役割にのみ変更できる
+func (h *RoomHandler) MemberRole(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, proom, ok := h.authorizedRoom(ctx, w, r, domain.PermManageRoles)
+		if !ok {
+			return
+		}
+
+		target, err := h.userUsecase.GetByID(ctx, r.PathValue("userid"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "ユーザーが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("userUsecase.GetByID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("userUsecase.GetByID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		changed, err := h.roomRoleUsecase.ChangeRole(ctx, proom.UserID, room.ID, target.ID, r.FormValue("role"))
+		if errors.Is(err, domain.ErrInvalidRole) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomに参加していないユーザーです。", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			log.Printf("roomRoleUsecase.ChangeRole error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomRoleUsecase.ChangeRole error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Roomを開いているクライアントに変更を通知する
+		if roomHub, exists := h.hub.Get(room.ID); exists {
+			roomHub.Broadcast(Event{Type: envelope.TypeRoleUpdate, Payload: &envelope.RoleUpdatePayload{RoomID: room.ID, UserID: target.ID, Name: target.Name, Role: changed.Role}})
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(SentMember{UserID: target.ID, Name: target.Name, Role: changed.Role})
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
+
+// 作成者の役割を他の参加者に譲る。作成者のみ。元の作成者は管理者になる
+func (h *RoomHandler) Owner(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+		defer cancel()
+
+		room, proom, ok := h.authorizedRoom(ctx, w, r, domain.PermTransferOwner)
+		if !ok {
+			return
+		}
+
+		owner, err := h.userUsecase.GetByID(ctx, proom.UserID)
+		if err != nil {
+			log.Printf("userUsecase.GetByID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("userUsecase.GetByID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		target, err := h.userUsecase.GetByID(ctx, r.FormValue("userid"))
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "ユーザーが見つかりません。", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("userUsecase.GetByID error: %v\n", err)
+			http.Error(w, fmt.Sprintf("userUsecase.GetByID error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		changed, err := h.roomRoleUsecase.TransferOwnership(ctx, owner.ID, room.ID, target.ID)
+		if errors.Is(err, domain.ErrInvalidRole) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			http.Error(w, "Roomに参加していないユーザーです。", http.StatusNotFound)
+			return
+		}
+		if errors.Is(err, domain.ErrForbidden) {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if err != nil {
+			log.Printf("roomRoleUsecase.TransferOwnership error: %v\n", err)
+			http.Error(w, fmt.Sprintf("roomRoleUsecase.TransferOwnership error: %v", err), http.StatusInternalServerError)
+			return
+		}
+
+		// Roomを開いているクライアントに変更を通知する
+		if roomHub, exists := h.hub.Get(room.ID); exists {
+			roomHub.Broadcast(Event{Type: envelope.TypeRoleUpdate, Payload: &envelope.RoleUpdatePayload{RoomID: room.ID, UserID: target.ID, Name: target.Name, Role: changed.Role}})
+			roomHub.Broadcast(Event{Type: envelope.TypeRoleUpdate, Payload: &envelope.RoleUpdatePayload{RoomID: room.ID, UserID: owner.ID, Name: owner.Name, Role: domain.RoleAdmin}})
+			roomHub.Broadcast(Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{RoomID: room.ID, Message: owner.Name + "がRoomの作成者を" + target.Name + "に譲りました"}})
+		}
+
+		// jsonに変換
+		sentjson, err := json.Marshal(SentMember{UserID: target.ID, Name: target.Name, Role: changed.Role})
+		if err != nil {
+			log.Printf("json.Marshal error: %v\n", err)
+			http.Error(w, "json.Marshal error", http.StatusInternalServerError)
+			return
+		}
+
+		// jsonで送信
+		w.Header().Set("Content-Type", "application/json")
+		_, err = w.Write(sentjson)
+		if err != nil {
+			log.Printf("w.Write error: %v\n", err)
+			http.Error(w, "response write error", http.StatusInternalServerError)
+			return
+		}
+	default:
+		fmt.Fprintln(w, "Method not allowed")
+		http.Error(w, "そのメソッドは許可されていません。", http.StatusMethodNotAllowed)
+		return
+	}
+}
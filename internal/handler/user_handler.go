@@ -12,6 +12,7 @@ import (
 
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/usecase"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
 	"golang.org/x/crypto/bcrypt"
 	"gorm.io/gorm"
 
@@ -22,23 +23,32 @@ type UserHandler struct {
 	userUsecase              usecase.UserUsecase
 	participatingRoomUsecase usecase.ParticipatingRoomUsecase
 	roomUsecase              usecase.RoomUsecase
+	messageUsecase           usecase.MessageUsecase
+	roomRoleUsecase          usecase.RoomRoleUsecase
 	templates                *template.Template
 	session                  *session.Sessions
+	hub                      *Hub
 }
 
 func NewUserHandler(
 	usecase usecase.UserUsecase,
 	participatingRoomUsecase usecase.ParticipatingRoomUsecase,
 	roomUsecase usecase.RoomUsecase,
+	messageUsecase usecase.MessageUsecase,
+	roomRoleUsecase usecase.RoomRoleUsecase,
 	s *session.Sessions,
+	hub *Hub,
 ) *UserHandler {
 	templates := template.Must(template.ParseGlob("internal/handler/templates/*.html"))
 	return &UserHandler{
 		userUsecase:              usecase,
 		participatingRoomUsecase: participatingRoomUsecase,
 		roomUsecase:              roomUsecase,
+		messageUsecase:           messageUsecase,
+		roomRoleUsecase:          roomRoleUsecase,
 		templates:                templates,
 		session:                  s,
+		hub:                      hub,
 	}
 }
 
@@ -187,7 +197,7 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		// ユーザーが作成したRoomの削除
+		// ユーザーが作成者のRoomの引き継ぎまたは削除
 		prooms, err := h.participatingRoomUsecase.GetByUserID(ctx, user.ID)
 		if err != nil {
 			log.Printf("participatingRoomUsecase.GetByUserID error: %v\n", err)
@@ -205,12 +215,37 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 		}
 
 		for _, proom := range *prooms {
-			if !proom.IsMaster {
+			if !proom.IsOwner() {
 				continue
 			}
 
+			// 他の参加者がいれば作成者を引き継ぎ、Roomは残す
+			successor, err := h.roomRoleUsecase.Succeed(ctx, user.ID, proom.RoomID)
+			if err != nil {
+				log.Printf("roomRoleUsecase.Succeed error: %v\n", err)
+				// メッセージをテンプレートに渡す
+				var data Data
+				data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+
+				err := h.templates.ExecuteTemplate(w, "usermenu.html", data)
+				if err != nil {
+					log.Printf("templates.ExecuteTemplate error:%v\n", err)
+					http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+					return
+				}
+				return
+			}
+			if successor != nil {
+				if roomHub, exists := h.hub.Get(proom.RoomID); exists {
+					roomHub.Broadcast(Event{Type: envelope.TypeRoleUpdate, Payload: &envelope.RoleUpdatePayload{RoomID: proom.RoomID, UserID: successor.UserID, Name: successor.User.Name, Role: successor.Role}})
+					roomHub.Broadcast(Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{RoomID: proom.RoomID, Message: successor.User.Name + "がRoomの作成者を引き継ぎました"}})
+				}
+				continue
+			}
+
+			// 引き継げる参加者がいなければRoomを削除する
 			// ユーザーの参加中ルームリストからも削除
-			err := h.participatingRoomUsecase.DeleteByRoomID(ctx, proom.RoomID)
+			err = h.participatingRoomUsecase.DeleteByRoomID(ctx, proom.RoomID)
 			if err != nil {
 				log.Printf("participatingRoomUsecase.DeleteByRoomID error: %v\n", err)
 				// メッセージをテンプレートに渡す
@@ -226,6 +261,23 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 				return
 			}
 
+			// 部屋のメッセージ削除
+			err = h.messageUsecase.DeleteByRoomID(ctx, proom.RoomID)
+			if err != nil {
+				log.Printf("messageUsecase.DeleteByRoomID error: %v\n", err)
+				// メッセージをテンプレートに渡す
+				var data Data
+				data.Message = fmt.Sprintf("データベースとの接続に失敗しました。(%v)", err)
+
+				err := h.templates.ExecuteTemplate(w, "usermenu.html", data)
+				if err != nil {
+					log.Printf("templates.ExecuteTemplate error:%v\n", err)
+					http.Error(w, "ページの表示に失敗しました。", http.StatusInternalServerError)
+					return
+				}
+				return
+			}
+
 			// 部屋削除
 			err = h.roomUsecase.Delete(ctx, proom.RoomID)
 			if err != nil {
@@ -242,7 +294,7 @@ func (h *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 				}
 				return
 			}
-			deleteRoom(proom.RoomID)
+			h.hub.Delete(proom.RoomID)
 		}
 
 		// ユーザーの参加中ルームリストを削除
@@ -6,17 +6,17 @@ import (
 	"fmt"
 	"html/template"
 	"log"
-	"os"
+	"net"
+	"net/http"
 	"strings"
 	"time"
 
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/usecase"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/session"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/timefmt"
 	"github.com/microcosm-cc/bluemonday"
-	"github.com/russross/blackfriday/v2"
-	"golang.org/x/net/websocket"
 	"gorm.io/gorm"
 )
 
@@ -24,61 +24,129 @@ type WebsocketHandler struct {
 	userUsecase              usecase.UserUsecase
 	participatingRoomUsecase usecase.ParticipatingRoomUsecase
 	roomUsecase              usecase.RoomUsecase
+	messageUsecase           usecase.MessageUsecase
+	messageReactionUsecase   usecase.MessageReactionUsecase
+	messageMentionUsecase    usecase.MessageMentionUsecase
+	messagePinUsecase        usecase.MessagePinUsecase
+	roomRoleUsecase          usecase.RoomRoleUsecase
 	templates                *template.Template
 	session                  *session.Sessions
-	chatLogFile              *os.File
+	hub                      *Hub
+	pingInterval             time.Duration
+	pongTimeout              time.Duration
 }
 
 func NewWebsocketHandler(
 	usecase usecase.UserUsecase,
 	participatingRoomUsecase usecase.ParticipatingRoomUsecase,
 	roomUsecase usecase.RoomUsecase,
+	messageUsecase usecase.MessageUsecase,
+	messageReactionUsecase usecase.MessageReactionUsecase,
+	messageMentionUsecase usecase.MessageMentionUsecase,
+	messagePinUsecase usecase.MessagePinUsecase,
+	roomRoleUsecase usecase.RoomRoleUsecase,
 	session *session.Sessions,
-	chatLogFile *os.File,
+	hub *Hub,
+	pingInterval time.Duration,
+	pongTimeout time.Duration,
 ) *WebsocketHandler {
 	templates := template.Must(template.ParseGlob("internal/handler/templates/*.html"))
 	return &WebsocketHandler{
 		userUsecase:              usecase,
 		participatingRoomUsecase: participatingRoomUsecase,
 		roomUsecase:              roomUsecase,
+		messageUsecase:           messageUsecase,
+		messageReactionUsecase:   messageReactionUsecase,
+		messageMentionUsecase:    messageMentionUsecase,
+		messagePinUsecase:        messagePinUsecase,
+		roomRoleUsecase:          roomRoleUsecase,
 		templates:                templates,
 		session:                  session,
-		chatLogFile:              chatLogFile,
+		hub:                      hub,
+		pingInterval:             pingInterval,
+		pongTimeout:              pongTimeout,
 	}
 }
 
-var sentmessage = make(chan Message) // 各クライアントに送信するためのメッセージのチャネル
+const (
+	// 入室時や過去のメッセージ要求時に送信する履歴の件数
+	historyLimit = 50
+	// スレッドの返信の要求時に送信する件数
+	threadLimit = 50
+	// 1つのコネクションからtyping.startを受け付ける最短の間隔
+	typingThrottle = time.Second
+	// 再接続時に受け取っていないメッセージとして送信する最大件数
+	resumeLimit = 200
+)
 
 // WebsocketでRoom参加後のコネクション確立
-func (h *WebsocketHandler) HandleConnection(ws *websocket.Conn) {
-	ctx := context.Background()
-	ctx, cancel := context.WithTimeout(ctx, 24*time.Hour)
-	defer cancel()
-
-	defer ws.Close()
-
+func (h *WebsocketHandler) HandleConnection(w http.ResponseWriter, r *http.Request) {
 	// セッション読み取り
-	userID, userName, err := h.session.GetUserData(ws.Request())
+	userID, userName, err := h.session.GetUserData(r)
 	if err != nil {
 		log.Printf("session.GetUserData error: %v\n", err)
+		http.Error(w, "Unauthorized", http.StatusUnauthorized)
 		return
 	}
 
+	conn, err := upgrade(w, r)
+	if err != nil {
+		log.Printf("upgrade error: %v\n", err)
+		return
+	}
+
+	ctx := context.Background()
+	ctx, cancel := context.WithTimeout(ctx, 24*time.Hour)
+	defer cancel()
+
+	h.serveConn(ctx, conn, userID, userName)
+}
+
+// Roomへの参加からメッセージの送受信、退出までを処理する
+func (h *WebsocketHandler) serveConn(ctx context.Context, conn Conn, userID, userName string) {
+	// pongに限らず何か受信できればコネクションは生きているとみなし、受信の期限を延ばす
+	conn.SetPongHandler(func() {
+		_ = conn.SetReadDeadline(time.Now().Add(h.pongTimeout))
+	})
+
 	// クライアントから参加する部屋が指定されたメッセージ受信
-	var msg Message
-	err = websocket.JSON.Receive(ws, &msg)
+	_ = conn.SetReadDeadline(time.Now().Add(h.pongTimeout))
+	data, err := conn.ReadMessage()
 	if err != nil {
 		log.Printf("Receive room ID error:%v\n", err)
+		conn.Close(closeCodeNormal, "")
+		return
+	}
+	e, legacy, err := decodeFrame(data, true)
+	if err != nil {
+		log.Printf("decodeFrame error:%v\n", err)
+		conn.Close(closeCodeBadRequest, "参加するRoomの指定が不正です。")
+		return
+	}
+	if e.Type != envelope.TypeRoomJoin {
+		log.Printf("first frame is not %s: %s\n", envelope.TypeRoomJoin, e.Type)
+		conn.Close(closeCodeBadRequest, "参加するRoomの指定が不正です。")
+		return
+	}
+	var join envelope.RoomJoinPayload
+	err = e.DecodePayload(&join)
+	if err != nil {
+		log.Printf("DecodePayload error:%v\n", err)
+		conn.Close(closeCodeBadRequest, "参加するRoomの指定が不正です。")
+		return
+	}
+	err = verifyIdentity(data, e, legacy, userID, userName, "")
+	if err != nil {
+		log.Printf("verifyIdentity error: %s: %v\n", userName, err)
+		conn.Close(closeCodeForbidden, "送信者が一致しません。")
 		return
 	}
-
-	// Room一覧取得
-	rooms := getRooms()
 
 	// 部屋が存在しているかどうか
-	room, exists := rooms[msg.RoomID]
+	room, exists := h.hub.Get(join.RoomID)
 	if !exists {
 		log.Printf("This room was not found\n")
+		conn.Close(closeCodeRoomNotFound, "Roomが見つかりません。")
 		return
 	}
 
@@ -89,200 +157,723 @@ func (h *WebsocketHandler) HandleConnection(ws *websocket.Conn) {
 	}
 	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+		conn.Close(closeCodeInternal, "")
+		return
+	}
+
+	if notExists && room.Direct {
+		log.Printf("not a member of direct room: %s\n", userName)
+		conn.Close(closeCodeNotMember, "このRoomには参加できません。")
 		return
 	}
 
 	if notExists {
+		// 招待制や参加承認制のRoomには、招待リンクか承認で参加者になったユーザーのみ参加できる
+		info, err := h.roomUsecase.GetByID(ctx, room.ID)
+		if err != nil {
+			log.Printf("roomUsecase.GetByID error: %v\n", err)
+			conn.Close(closeCodeInternal, "")
+			return
+		}
+		if !info.IsPublic() {
+			log.Printf("not a member of room %s: %s\n", room.ID, userName)
+			conn.Close(closeCodeNotMember, "このRoomには参加できません。")
+			return
+		}
+
 		// 参加中のルーム一覧に参加者として追加
 		proom := domain.ParticipatingRoom{
-			RoomID:   room.ID,
-			IsMaster: false,
-			UserID:   userID,
+			RoomID: room.ID,
+			Role:   domain.RoleMember,
+			UserID: userID,
 		}
-		err := h.participatingRoomUsecase.Create(ctx, &proom)
+		err = h.participatingRoomUsecase.Create(ctx, &proom)
 		if err != nil {
 			log.Printf("participatingRoomUsecase.Create: %v\n", err)
+			conn.Close(closeCodeInternal, "")
 			return
 		}
 	}
 
 	// Roomに参加
-	room.Clients[ws] = userName
-
-	// 参加しているユーザー一覧とオンラインのユーザー一覧の取得
-	allusersChan := make(chan interface{})
-	onlineusersChan := make(chan interface{})
-	var allusers []string
-	var onlineusers []string
-	go func() {
-		users, err := h.participatingRoomUsecase.GetUsersByRoomID(ctx, room.ID)
-		if err != nil {
-			err = fmt.Errorf("participatingRoomUsecase.GetUsersByRoomID error: %v", err)
-			allusersChan <- err
-			return
-		}
-		var aus []string
-		aus = append(aus, "匿名")
-		for _, user := range *users {
-			aus = append(aus, user.Name)
-		}
-		allusersChan <- aus
-	}()
-	go func() {
-		ous, err := getOnlineUsers(room.ID)
-		if err != nil {
-			err = fmt.Errorf("getOnlineUsers error: %v", err)
-			onlineusersChan <- err
-			return
-		}
-		onlineusersChan <- ous
-	}()
-
-	auc := <-allusersChan
-	ouc := <-onlineusersChan
-	switch auctype := auc.(type) {
-	case error:
-		log.Println(auctype)
+	// 参加から履歴の送信までの間に保存されたメッセージを取りこぼさないよう、メッセージの保存を止めておく
+	client := NewClient(userID, userName, legacy, conn)
+	defer client.Close(closeCodeNormal, "")
+	room.seqMu.Lock()
+	if !room.Register(client) {
+		room.seqMu.Unlock()
+		log.Printf("This room was deleted\n")
+		client.Close(closeCodeRoomNotFound, "Roomが見つかりません。")
 		return
-	case []string:
-		allusers = auctype
 	}
+	h.hub.addClient(client)
+	defer h.hub.removeClient(client)
 
-	switch ouctype := ouc.(type) {
-	case error:
-		log.Println(ouctype)
-		return
-	case []string:
-		onlineusers = ouctype
+	// サーバ側からクライアントにWellcomeメッセージを送信
+	h.sendToClient(client, Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{RoomID: room.ID, Message: "ルーム" + room.ID + "へようこそ"}})
+
+	// 再接続であれば受け取っていないメッセージを、そうでなければ直近のメッセージ履歴を送信
+	if join.LastSeq > 0 {
+		err = h.sendResume(ctx, client, room.ID, join.LastSeq)
+	} else {
+		err = h.sendHistory(ctx, client, room.ID, "", "")
 	}
+	if err != nil {
+		log.Printf("send history error:%v\n", err)
+	}
+	pins, err := h.pinPayload(ctx, room.ID)
+	if err != nil {
+		log.Println(err)
+	} else {
+		h.sendToClient(client, Event{Type: envelope.TypePinUpdate, Payload: pins})
+	}
+	room.seqMu.Unlock()
 
-	// Roomに参加したことをそのRoomのクライアントにブロードキャスト
-	entermsg := Message{RoomID: room.ID, Message: userName + "が入室しました", Name: "Server", ToName: "", AllUsers: allusers, OnlineUsers: onlineusers}
-	sentmessage <- entermsg
+	// Roomを開いている間のメンションは未読として数えない
+	h.markMentionsRead(ctx, userID, room.ID)
 
-	// サーバ側からクライアントにWellcomeメッセージを送信
-	err = websocket.JSON.Send(ws, Message{RoomID: room.ID, Message: "ルーム" + room.ID + "へようこそ", Name: "Server", ToName: msg.Name, AllUsers: nil, OnlineUsers: nil})
+	// Roomに参加したことをそのRoomのクライアントにブロードキャスト
+	err = h.broadcastPresence(ctx, room, userName+"が入室しました")
 	if err != nil {
-		log.Printf("server wellcome Send error:%v\n", err)
+		log.Println(err)
+		room.Unregister(client)
+		return
 	}
 
+	go h.heartbeat(client)
+
+	var lastTyping time.Time // 最後に受け付けたtyping.startの日時
+
 	// クライアントからメッセージが来るまで受信待ちする
 	for {
 		// クライアントからのメッセージを受信
-		err = websocket.JSON.Receive(ws, &msg)
+		_ = conn.SetReadDeadline(time.Now().Add(h.pongTimeout))
+		data, err = conn.ReadMessage()
+		if err != nil { // Roomを退出した、サーバーから切断した、または応答がなくなったら
+			var netErr net.Error
+			if errors.As(err, &netErr) && netErr.Timeout() {
+				log.Printf("ping timeout: %s\n", userName)
+				client.Close(closeCodePingTimeout, "応答がないため切断しました。")
+			} else {
+				log.Printf("Receive error:%v\n", err)
+			}
+			room.Unregister(client) // Roomからそのクライアントを削除
+			h.markMentionsRead(ctx, userID, room.ID)
+
+			// そのクライアントがRoomから退出したことをそのRoomにブロードキャスト
+			err = h.broadcastPresence(ctx, room, userName+"が退出しました")
+			if err != nil {
+				log.Println(err)
+				return
+			}
+			break
+		}
+
+		e, frameLegacy, err := decodeFrame(data, false)
 		if err != nil {
-			if err.Error() == "EOF" { // Roomを退出したことを示すメッセージが来たら
-				log.Printf("EOF error:%v\n", err)
-				delete(room.Clients, ws) // Roomからそのクライアントを削除
-
-				// 参加しているユーザー一覧とオンラインのユーザー一覧の取得
-				allusersChan := make(chan interface{})
-				onlineusersChan := make(chan interface{})
-				var allusers []string
-				var onlineusers []string
-				go func() {
-					users, err := h.participatingRoomUsecase.GetUsersByRoomID(ctx, room.ID)
-					if err != nil {
-						err = fmt.Errorf("participatingRoomUsecase.GetUsersByRoomID error: %v", err)
-						allusersChan <- err
-						return
-					}
-					var aus []string
-					aus = append(aus, "匿名")
-					for _, user := range *users {
-						aus = append(aus, user.Name)
-					}
-					allusersChan <- aus
-				}()
-				go func() {
-					ous, err := getOnlineUsers(room.ID)
-					if err != nil {
-						err = fmt.Errorf("getOnlineUsers error: %v", err)
-						onlineusersChan <- err
-						return
-					}
-					onlineusersChan <- ous
-				}()
-
-				auc := <-allusersChan
-				ouc := <-onlineusersChan
-				switch auctype := auc.(type) {
-				case error:
-					log.Println(auctype)
-					return
-				case []string:
-					allusers = auctype
-				}
+			log.Printf("decodeFrame error:%v\n", err)
+			h.sendError(client, "", envelope.ErrCodeBadRequest, err.Error())
+			continue
+		}
+
+		// 他のユーザーへのなりすましや、参加していないRoomへの送信を拒否する
+		err = verifyIdentity(data, e, frameLegacy, userID, userName, room.ID)
+		if err != nil {
+			log.Printf("verifyIdentity error: %s: %v\n", userName, err)
+			h.sendError(client, e.ID, envelope.ErrCodeForbidden, err.Error())
+			continue
+		}
 
-				switch ouctype := ouc.(type) {
-				case error:
-					log.Println(ouctype)
-					return
-				case []string:
-					onlineusers = ouctype
+		switch e.Type {
+		case envelope.TypeHistoryRequest: // 過去のメッセージの要求
+			var req envelope.HistoryRequestPayload
+			err = e.DecodePayload(&req)
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
+			}
+			err = h.sendHistory(ctx, client, room.ID, e.ID, req.Before)
+			if errors.Is(err, domain.ErrInvalidCursor) {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, domain.ErrInvalidCursor.Error())
+				continue
+			}
+			if err != nil {
+				log.Printf("sendHistory error:%v\n", err)
+				h.sendError(client, e.ID, envelope.ErrCodeInternal, "過去のメッセージの取得に失敗しました。")
+			}
+		case envelope.TypeThreadRequest: // スレッドの返信の要求
+			var req envelope.ThreadRequestPayload
+			err = e.DecodePayload(&req)
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
+			}
+			err = h.sendThread(ctx, client, room.ID, e.ID, &req)
+			if err != nil {
+				log.Printf("sendThread error:%v\n", err)
+				h.sendMessageError(client, e.ID, err)
+			}
+		case envelope.TypeChatSend: // メッセージの送信
+			var req envelope.ChatSendPayload
+			err = e.DecodePayload(&req)
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
+			}
+
+			// 閲覧のみの参加者や、退出させられた参加者は送信できない
+			_, err = h.roomRoleUsecase.Authorize(ctx, userID, room.ID, domain.PermPost)
+			if errors.Is(err, domain.ErrForbidden) || errors.Is(err, gorm.ErrRecordNotFound) {
+				h.sendError(client, e.ID, envelope.ErrCodeForbidden, domain.ErrForbidden.Error())
+				continue
+			}
+			if err != nil {
+				log.Printf("roomRoleUsecase.Authorize error: %v\n", err)
+				h.sendError(client, e.ID, envelope.ErrCodeInternal, "メッセージの保存に失敗しました。")
+				continue
+			}
+
+			// ブロードキャストする前にメッセージをDBに保存
+			// エンベロープのidをclientidとして、再送されたメッセージの重複を排除する
+			message := domain.Message{
+				RoomID:     room.ID,
+				UserID:     userID,
+				ClientID:   e.ID,
+				UserName:   userName,
+				ToName:     req.ToName,
+				ParentID:   req.ParentID,
+				AlsoToRoom: req.AlsoToRoom,
+				Markdown:   req.Message,
+			}
+			err = message.Validate()
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
+			}
+			if req.ToName != "" {
+				target, err := h.whisperTarget(ctx, room, req.ToName)
+				if errors.Is(err, domain.ErrWhisperTargetNotFound) || errors.Is(err, domain.ErrWhisperTargetNotMember) || errors.Is(err, domain.ErrWhisperTargetOffline) {
+					h.sendMessageError(client, e.ID, err)
+					continue
 				}
+				if err != nil {
+					log.Println(err)
+					h.sendError(client, e.ID, envelope.ErrCodeInternal, "メッセージの保存に失敗しました。")
+					continue
+				}
+				message.ToUserID = target.ID
+			}
+			participants, err := h.mentionCandidates(ctx, room.ID, req.Message)
+			if err != nil {
+				log.Println(err)
+				h.sendError(client, e.ID, envelope.ErrCodeInternal, "メッセージの保存に失敗しました。")
+				continue
+			}
+			message.SetMentions(participants)
+			err = h.postMessage(ctx, room, &message)
+			if errors.Is(err, domain.ErrDuplicateMessage) {
+				// 保存済みのメッセージは既にブロードキャストしているため、応答のみ返す
+				h.sendAck(client, e.ID, &message, true)
+				continue
+			}
+			if errors.Is(err, domain.ErrParentNotFound) || errors.Is(err, domain.ErrPrivateThread) {
+				h.sendMessageError(client, e.ID, err)
+				continue
+			}
+			if err != nil {
+				log.Printf("messageUsecase.Create error: %v\n", err)
+				h.sendError(client, e.ID, envelope.ErrCodeInternal, "メッセージの保存に失敗しました。")
+				continue
+			}
 
-				// そのクライアントがRoomから退出したことをそのRoomにブロードキャスト
-				exitmsg := Message{RoomID: msg.RoomID, Message: msg.Name + "が退出しました", Name: "Server", ToName: "", AllUsers: allusers, OnlineUsers: onlineusers}
-				sentmessage <- exitmsg
-				break
+			h.sendAck(client, e.ID, &message, false)
+			// 送信したら入力中の表示を終える
+			lastTyping = time.Time{}
+			room.SetTyping(client, false)
+		case envelope.TypeChatEdit: // メッセージの編集
+			var req envelope.ChatEditPayload
+			err = e.DecodePayload(&req)
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
+			}
+			participants, err := h.mentionCandidates(ctx, room.ID, req.Message)
+			if err != nil {
+				log.Println(err)
+				h.sendError(client, e.ID, envelope.ErrCodeInternal, "メッセージの変更に失敗しました。")
+				continue
+			}
+			h.modifyMessage(ctx, client, room, e.ID, func(moderator bool) (*domain.Message, error) {
+				return h.messageUsecase.Edit(ctx, room.ID, req.ID, userID, moderator, req.Message, participants)
+			})
+		case envelope.TypeChatDelete: // メッセージの削除
+			var req envelope.ChatDeletePayload
+			err = e.DecodePayload(&req)
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
 			}
-			log.Printf("Receive error:%v\n", err)
+			h.modifyMessage(ctx, client, room, e.ID, func(moderator bool) (*domain.Message, error) {
+				return h.messageUsecase.Delete(ctx, room.ID, req.ID, userID, moderator)
+			})
+		case envelope.TypeReactionToggle: // リアクションの追加、削除
+			var req envelope.ReactionTogglePayload
+			err = e.DecodePayload(&req)
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
+			}
+			h.toggleReaction(ctx, client, room, e.ID, &req)
+		case envelope.TypePinAdd, envelope.TypePinRemove: // メッセージのピン留めとその解除
+			var req envelope.PinPayload
+			err = e.DecodePayload(&req)
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
+			}
+			h.pinMessage(ctx, client, room, e.ID, req.ID, e.Type == envelope.TypePinAdd)
+		case envelope.TypeTypingStart: // 入力中の表示の開始。保存せずRoomの他のクライアントに中継する
+			if time.Since(lastTyping) < typingThrottle {
+				continue
+			}
+			lastTyping = time.Now()
+			room.SetTyping(client, true)
+		case envelope.TypeTypingStop: // 入力中の表示の終了
+			lastTyping = time.Time{}
+			room.SetTyping(client, false)
+		case envelope.TypeReadMark: // 既読位置の更新
+			var req envelope.ReadMarkPayload
+			err = e.DecodePayload(&req)
+			if err != nil {
+				h.sendError(client, e.ID, envelope.ErrCodeBadRequest, err.Error())
+				continue
+			}
+			err = h.participatingRoomUsecase.UpdateLastReadSeq(ctx, userID, room.ID, req.Seq)
+			if err != nil {
+				log.Printf("participatingRoomUsecase.UpdateLastReadSeq error: %v\n", err)
+				h.sendError(client, e.ID, envelope.ErrCodeInternal, "既読の更新に失敗しました。")
+				continue
+			}
+		default:
+			h.sendError(client, e.ID, envelope.ErrCodeBadRequest, "このtypeは送信できません。("+e.Type+")")
 		}
+	}
+}
 
-		htmlmsg := blackfriday.Run([]byte(msg.Message))
-		policy := bluemonday.UGCPolicy()
-		sanitizedHTML := policy.SanitizeBytes(htmlmsg)
-		msg.Message = string(sanitizedHTML)
+// goroutineで一定間隔でクライアントにpingを送信する
+func (h *WebsocketHandler) heartbeat(client *Client) {
+	ticker := time.NewTicker(h.pingInterval)
+	defer ticker.Stop()
 
-		// goroutineでチャネルを待っているとこへメッセージを渡す
-		sentmessage <- msg
+	for {
+		select {
+		case <-ticker.C:
+			client.ping()
+		case <-client.closed:
+			return
+		}
 	}
 }
 
-// goroutineでメッセージのチャネルが来るまで待ち、Roomにメッセージを送信する
-func (h *WebsocketHandler) HandleMessages() {
-	for {
-		// sentmessageチャネルからメッセージを受け取る
-		msg := <-sentmessage
+// メッセージを保存してRoomにブロードキャストする
+// seqの順に配信されるよう、保存からRoomHubへ渡すまでをRoomごとに直列にする
+func (h *WebsocketHandler) postMessage(ctx context.Context, room *RoomHub, message *domain.Message) error {
+	room.seqMu.Lock()
+	defer room.seqMu.Unlock()
 
-		// Room一覧取得
-		rooms := getRooms()
+	err := h.messageUsecase.Create(ctx, message)
+	if err != nil {
+		return err
+	}
 
-		// 部屋が存在しているかどうか
-		room, exists := rooms[msg.RoomID]
-		if !exists {
+	// RoomHubのgoroutineへメッセージを渡す
+	room.Broadcast(Event{Type: envelope.TypeChatMessage, Payload: toChatMessagePayload(message), fromID: message.UserID, toID: message.ToUserID, toName: message.ToName})
+
+	// メンションされたユーザーには、他のRoomにいても通知する
+	for _, mention := range message.Mentions {
+		h.hub.SendToUser(mention.UserID, Event{Type: envelope.TypeMentionNotify, Payload: &envelope.MentionNotifyPayload{RoomID: room.ID, ID: message.ID, Name: message.UserName}})
+	}
+
+	// ダイレクトメッセージは、相手が他のRoomを開いていても届ける
+	if room.Direct {
+		h.notifyDirectMessage(ctx, room, message)
+	}
+
+	// スレッドへの返信であれば、返信先のメッセージの返信数などを更新する
+	if message.IsReply() {
+		summaries, err := h.messageUsecase.GetThreadSummaries(ctx, []string{message.ParentID})
+		if err != nil {
+			log.Printf("messageUsecase.GetThreadSummaries error: %v\n", err)
+			return nil
+		}
+		thread, ok := toThreadSummaryPayloads(summaries)[message.ParentID]
+		if ok {
+			room.Broadcast(Event{Type: envelope.TypeThreadUpdate, Payload: &envelope.ThreadUpdatePayload{RoomID: room.ID, ID: message.ParentID, Thread: *thread}})
+		}
+	}
+	return nil
+}
+
+// ダイレクトメッセージの相手の接続中のすべてのクライアントにメッセージを送信する
+func (h *WebsocketHandler) notifyDirectMessage(ctx context.Context, room *RoomHub, message *domain.Message) {
+	users, err := h.participatingRoomUsecase.GetUsersByRoomID(ctx, room.ID)
+	if err != nil {
+		log.Printf("participatingRoomUsecase.GetUsersByRoomID error: %v\n", err)
+		return
+	}
+	for _, user := range *users {
+		if user.ID == message.UserID {
 			continue
 		}
+		h.hub.SendToUser(user.ID, Event{Type: envelope.TypeDirectMessage, Payload: toChatMessagePayload(message)})
+	}
+}
 
-		// チャットログを出力と保存 日時、サーバー名、ユーザー名、宛先、メッセージ
-		replaceNlMsg := strings.ReplaceAll(msg.Message, "\n", " ") // 改行があるとログが改行されてしまうため、改行を削除
-		chatlog := fmt.Sprintf("%s: [S%s] From(%s) To (%s) Msg(%s)\n", timefmt.TimeToStr(time.Now()), msg.RoomID, msg.Name, msg.ToName, replaceNlMsg)
-		fmt.Print(chatlog)
-		fmt.Fprint(h.chatLogFile, chatlog)
-
-		if msg.ToName != "" {
-			// 接続中のクライアントにメッセージを送る
-			for client, name := range room.Clients {
-				if msg.ToName == name || msg.Name == name {
-					// メッセージを返信する
-					policy := bluemonday.UGCPolicy()
-					msg.ToName = policy.Sanitize(msg.ToName)
-					err := websocket.JSON.Send(client, Message{RoomID: room.ID, Message: msg.Message, Name: msg.Name, ToName: msg.ToName, AllUsers: msg.AllUsers, OnlineUsers: msg.OnlineUsers})
-					if err != nil {
-						log.Printf("Send error:%v\n", err)
-					}
-				}
-			}
-		} else {
-			// 接続中のクライアントにメッセージを送る
-			for client := range room.Clients {
-				// メッセージを返信する
-				err := websocket.JSON.Send(client, Message{RoomID: room.ID, Message: msg.Message, Name: msg.Name, ToName: "", AllUsers: msg.AllUsers, OnlineUsers: msg.OnlineUsers})
-				if err != nil {
-					log.Printf("Send error:%v\n", err)
-				}
-			}
+// ささやきの宛先のユーザー名をユーザーに解決し、Roomの参加者で接続中であることを確認する
+func (h *WebsocketHandler) whisperTarget(ctx context.Context, room *RoomHub, toName string) (*domain.User, error) {
+	user, err := h.userUsecase.GetByName(ctx, toName)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrWhisperTargetNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("userUsecase.GetByName error: %v", err)
+	}
+
+	_, err = h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, user.ID, room.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrWhisperTargetNotMember
+	}
+	if err != nil {
+		return nil, fmt.Errorf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v", err)
+	}
+
+	online, err := room.IsOnline(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !online {
+		return nil, domain.ErrWhisperTargetOffline
+	}
+	return user, nil
+}
+
+// メッセージに@が含まれていれば、メンションできるRoomの参加者を返す
+func (h *WebsocketHandler) mentionCandidates(ctx context.Context, roomID, markdown string) (domain.Users, error) {
+	if !strings.Contains(markdown, "@") {
+		return nil, nil
+	}
+
+	users, err := h.participatingRoomUsecase.GetUsersByRoomID(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("participatingRoomUsecase.GetUsersByRoomID error: %v", err)
+	}
+	return *users, nil
+}
+
+// ユーザーのRoomでのメンションを既読にする
+func (h *WebsocketHandler) markMentionsRead(ctx context.Context, userID, roomID string) {
+	err := h.messageMentionUsecase.MarkReadByUserIDAndRoomID(ctx, userID, roomID)
+	if err != nil {
+		log.Printf("messageMentionUsecase.MarkReadByUserIDAndRoomID error: %v\n", err)
+	}
+}
+
+// メッセージの編集、削除を行い、変更後のメッセージをRoomにブロードキャストする
+// 送信者本人の他に、他のユーザーのメッセージを管理できる役割の参加者も変更できる
+func (h *WebsocketHandler) modifyMessage(ctx context.Context, client *Client, room *RoomHub, id string, modify func(moderator bool) (*domain.Message, error)) {
+	proom, err := h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, client.UserID, room.ID)
+	if err != nil {
+		log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+		h.sendError(client, id, envelope.ErrCodeInternal, "メッセージの変更に失敗しました。")
+		return
+	}
+
+	// 編集と新しいメッセージの配信が前後しないよう、保存からRoomHubへ渡すまでを直列にする
+	room.seqMu.Lock()
+	defer room.seqMu.Unlock()
+
+	message, err := modify(proom.Can(domain.PermDeleteMessages))
+	if err != nil {
+		log.Printf("messageUsecase modify error: %v\n", err)
+		h.sendMessageError(client, id, err)
+		return
+	}
+
+	h.sendAck(client, id, message, false)
+
+	// 編集後もリアクションの表示が消えないよう、リアクションの数も合わせて送る
+	payload := toChatMessagePayload(message)
+	payloads, err := h.toChatMessagePayloads(ctx, domain.Messages{*message})
+	if err != nil {
+		log.Println(err)
+	} else {
+		payload = &payloads[0]
+	}
+	room.Broadcast(Event{Type: envelope.TypeChatUpdate, Payload: payload, fromID: message.UserID, toID: message.ToUserID, toName: message.ToName})
+}
+
+// メッセージのピン留めを変更し、変更をRoomにブロードキャストする
+func (h *WebsocketHandler) pinMessage(ctx context.Context, client *Client, room *RoomHub, id, messageID string, pin bool) {
+	proom, err := h.participatingRoomUsecase.GetByUserIDAndRoomID(ctx, client.UserID, room.ID)
+	if err != nil {
+		log.Printf("participatingRoomUsecase.GetByUserIDAndRoomID error: %v\n", err)
+		h.sendError(client, id, envelope.ErrCodeInternal, "ピン留めの変更に失敗しました。")
+		return
+	}
+	if !proom.Can(domain.PermPin) {
+		h.sendMessageError(client, id, domain.ErrPinForbidden)
+		return
+	}
+
+	// ピン留めの変更とメッセージの配信が前後しないよう、保存からRoomHubへ渡すまでを直列にする
+	room.seqMu.Lock()
+	defer room.seqMu.Unlock()
+
+	notice := client.Name + "がメッセージをピン留めしました"
+	if pin {
+		_, err = h.messagePinUsecase.Pin(ctx, room.ID, messageID, client.UserID)
+	} else {
+		err = h.messagePinUsecase.Unpin(ctx, room.ID, messageID)
+		notice = client.Name + "がメッセージのピン留めを外しました"
+	}
+	if err != nil {
+		log.Printf("messagePinUsecase error: %v\n", err)
+		h.sendMessageError(client, id, err)
+		return
+	}
+
+	room.Broadcast(Event{Type: envelope.TypeSystemNotice, Payload: &envelope.SystemNoticePayload{RoomID: room.ID, Message: notice}})
+
+	pins, err := h.pinPayload(ctx, room.ID)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	room.Broadcast(Event{Type: envelope.TypePinUpdate, Payload: pins})
+}
+
+// Roomにピン留めされているメッセージの一覧を作成する
+func (h *WebsocketHandler) pinPayload(ctx context.Context, roomID string) (*envelope.PinUpdatePayload, error) {
+	messages, err := h.messagePinUsecase.GetByRoomID(ctx, roomID)
+	if err != nil {
+		return nil, fmt.Errorf("messagePinUsecase.GetByRoomID error: %v", err)
+	}
+
+	pins, err := h.toChatMessagePayloads(ctx, *messages)
+	if err != nil {
+		return nil, err
+	}
+	return &envelope.PinUpdatePayload{RoomID: roomID, Pins: pins}, nil
+}
+
+// メッセージへのリアクションを付け外しし、変更後のリアクションの数をRoomにブロードキャストする
+func (h *WebsocketHandler) toggleReaction(ctx context.Context, client *Client, room *RoomHub, id string, req *envelope.ReactionTogglePayload) {
+	// 同じメッセージへのリアクションの更新が前後しないよう、保存からRoomHubへ渡すまでを直列にする
+	room.seqMu.Lock()
+	defer room.seqMu.Unlock()
+
+	message, counts, err := h.messageReactionUsecase.Toggle(ctx, room.ID, req.ID, client.UserID, req.Emoji)
+	if err != nil {
+		log.Printf("messageReactionUsecase.Toggle error: %v\n", err)
+		h.sendMessageError(client, id, err)
+		return
+	}
+
+	reactions := toReactionPayloads(counts)[message.ID]
+	if reactions == nil {
+		reactions = []envelope.ReactionPayload{}
+	}
+	room.Broadcast(Event{Type: envelope.TypeReactionUpdate, Payload: &envelope.ReactionUpdatePayload{RoomID: room.ID, ID: message.ID, Reactions: reactions}, fromID: message.UserID, toID: message.ToUserID, toName: message.ToName})
+}
+
+// メッセージの変更に失敗した理由をクライアントに通知する
+func (h *WebsocketHandler) sendMessageError(client *Client, id string, err error) {
+	switch {
+	case errors.Is(err, domain.ErrMessageForbidden), errors.Is(err, domain.ErrPinForbidden):
+		h.sendError(client, id, envelope.ErrCodeForbidden, err.Error())
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		h.sendError(client, id, envelope.ErrCodeNotFound, "メッセージが見つかりません。")
+	case errors.Is(err, domain.ErrParentNotFound), errors.Is(err, domain.ErrWhisperTargetNotFound), errors.Is(err, domain.ErrWhisperTargetNotMember):
+		h.sendError(client, id, envelope.ErrCodeNotFound, err.Error())
+	case errors.Is(err, domain.ErrWhisperTargetOffline):
+		h.sendError(client, id, envelope.ErrCodeOffline, err.Error())
+	default:
+		h.sendError(client, id, envelope.ErrCodeBadRequest, err.Error())
+	}
+}
+
+// 参加しているユーザー一覧とオンラインのユーザー一覧をRoomにブロードキャスト
+func (h *WebsocketHandler) broadcastPresence(ctx context.Context, room *RoomHub, message string) error {
+	users, err := h.participatingRoomUsecase.GetUsersByRoomID(ctx, room.ID)
+	if err != nil {
+		return fmt.Errorf("participatingRoomUsecase.GetUsersByRoomID error: %v", err)
+	}
+	var allusers []string
+	allusers = append(allusers, "匿名")
+	for _, user := range *users {
+		allusers = append(allusers, user.Name)
+	}
+
+	ous, err := room.OnlineUsers()
+	if err != nil {
+		return fmt.Errorf("room.OnlineUsers error: %v", err)
+	}
+	var onlineusers []string
+	onlineusers = append(onlineusers, "匿名")
+	onlineusers = append(onlineusers, ous...)
+
+	room.Broadcast(Event{Type: envelope.TypePresenceUpdate, Payload: &envelope.PresenceUpdatePayload{RoomID: room.ID, Message: message, AllUsers: allusers, OnlineUsers: onlineusers}})
+	return nil
+}
+
+// RoomHubを通さずにクライアント1人へイベントを送信する
+func (h *WebsocketHandler) sendToClient(client *Client, ev Event) {
+	if !client.enqueue(ev) {
+		h.hub.evict(client)
+	}
+}
+
+// 要求の処理に失敗したことをクライアントに通知する
+func (h *WebsocketHandler) sendError(client *Client, id, code, message string) {
+	h.sendToClient(client, Event{Type: envelope.TypeError, ID: id, Payload: &envelope.ErrorPayload{Code: code, Message: message}})
+}
+
+// メッセージが保存されたことを送信者に通知する。旧形式のクライアントには送らない
+func (h *WebsocketHandler) sendAck(client *Client, id string, message *domain.Message, duplicate bool) {
+	if client.legacy {
+		return
+	}
+	h.sendToClient(client, Event{Type: envelope.TypeChatAck, ID: id, Payload: &envelope.ChatAckPayload{ID: message.ID, RoomID: message.RoomID, Seq: message.Seq, CreatedAt: timefmt.TimeToStr(message.CreatedAt), Duplicate: duplicate}})
+}
+
+// 保存済みのメッセージのうち、ユーザーが閲覧できるものを履歴として送信する
+func (h *WebsocketHandler) sendHistory(ctx context.Context, client *Client, roomID, id, before string) error {
+	messages, err := h.messageUsecase.GetHistory(ctx, roomID, client.UserID, before, historyLimit)
+	if err != nil {
+		return fmt.Errorf("messageUsecase.GetHistory error: %w", err)
+	}
+
+	history, err := h.toChatMessagePayloads(ctx, *messages)
+	if err != nil {
+		return err
+	}
+
+	h.sendToClient(client, Event{Type: envelope.TypeHistoryPage, ID: id, Payload: &envelope.HistoryPagePayload{RoomID: roomID, Before: before, Messages: history, HasMore: len(history) == historyLimit}})
+	return nil
+}
+
+// スレッドの返信を送信する
+func (h *WebsocketHandler) sendThread(ctx context.Context, client *Client, roomID, id string, req *envelope.ThreadRequestPayload) error {
+	parent, messages, err := h.messageUsecase.GetThread(ctx, roomID, req.ID, req.Before, threadLimit)
+	if err != nil {
+		return err
+	}
+
+	parents, err := h.toChatMessagePayloads(ctx, domain.Messages{*parent})
+	if err != nil {
+		return err
+	}
+	replies, err := h.toChatMessagePayloads(ctx, *messages)
+	if err != nil {
+		return err
+	}
+
+	h.sendToClient(client, Event{Type: envelope.TypeThreadPage, ID: id, Payload: &envelope.ThreadPagePayload{RoomID: roomID, Parent: parents[0], Before: req.Before, Messages: replies, HasMore: len(replies) == threadLimit}})
+	return nil
+}
+
+// 再接続したクライアントに、lastSeqより後のメッセージを送信する
+// 取りこぼしが多すぎる場合は直近のメッセージ履歴を送り直す
+func (h *WebsocketHandler) sendResume(ctx context.Context, client *Client, roomID string, lastSeq int64) error {
+	messages, err := h.messageUsecase.GetSinceSeq(ctx, roomID, client.UserID, lastSeq, resumeLimit+1)
+	if err != nil {
+		return fmt.Errorf("messageUsecase.GetSinceSeq error: %v", err)
+	}
+	if len(*messages) > resumeLimit {
+		return h.sendHistory(ctx, client, roomID, "", "")
+	}
+
+	missed, err := h.toChatMessagePayloads(ctx, *messages)
+	if err != nil {
+		return err
+	}
+
+	h.sendToClient(client, Event{Type: envelope.TypeHistoryResume, Payload: &envelope.HistoryResumePayload{RoomID: roomID, LastSeq: lastSeq, Messages: missed}})
+	return nil
+}
+
+// 保存済みのメッセージをリアクションの数やスレッドの概要と合わせて送信用のペイロードに変換する
+func (h *WebsocketHandler) toChatMessagePayloads(ctx context.Context, messages domain.Messages) ([]envelope.ChatMessagePayload, error) {
+	payloads := make([]envelope.ChatMessagePayload, 0, len(messages))
+	if len(messages) == 0 {
+		return payloads, nil
+	}
+
+	ids := make([]string, 0, len(messages))
+	var parentIDs []string
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+		if !message.IsReply() {
+			parentIDs = append(parentIDs, message.ID)
 		}
 	}
+	counts, err := h.messageReactionUsecase.GetCounts(ctx, ids)
+	if err != nil {
+		return nil, fmt.Errorf("messageReactionUsecase.GetCounts error: %v", err)
+	}
+	reactions := toReactionPayloads(counts)
+
+	threads := make(map[string]*envelope.ThreadSummaryPayload)
+	if len(parentIDs) > 0 {
+		summaries, err := h.messageUsecase.GetThreadSummaries(ctx, parentIDs)
+		if err != nil {
+			return nil, fmt.Errorf("messageUsecase.GetThreadSummaries error: %v", err)
+		}
+		threads = toThreadSummaryPayloads(summaries)
+	}
+
+	for i := range messages {
+		payload := toChatMessagePayload(&messages[i])
+		payload.Reactions = reactions[payload.ID]
+		payload.Thread = threads[payload.ID]
+		payloads = append(payloads, *payload)
+	}
+	return payloads, nil
+}
+
+// スレッドの概要を返信先のメッセージのIDごとにまとめる
+func toThreadSummaryPayloads(summaries *domain.ThreadSummaries) map[string]*envelope.ThreadSummaryPayload {
+	threads := make(map[string]*envelope.ThreadSummaryPayload)
+	for _, s := range *summaries {
+		threads[s.ParentID] = &envelope.ThreadSummaryPayload{ReplyCount: s.ReplyCount, LastReplyAt: timefmt.TimeToStr(s.LastReplyAt), Participants: s.Participants}
+	}
+	return threads
+}
+
+// リアクションの数をメッセージのIDごとにまとめる
+func toReactionPayloads(counts *domain.ReactionCounts) map[string][]envelope.ReactionPayload {
+	reactions := make(map[string][]envelope.ReactionPayload)
+	for _, c := range *counts {
+		reactions[c.MessageID] = append(reactions[c.MessageID], envelope.ReactionPayload{Emoji: c.Emoji, Count: c.Count})
+	}
+	return reactions
+}
+
+// 保存済みのメッセージを送信用のペイロードに変換する
+func toChatMessagePayload(message *domain.Message) *envelope.ChatMessagePayload {
+	policy := bluemonday.UGCPolicy()
+	return &envelope.ChatMessagePayload{
+		ID:         message.ID,
+		RoomID:     message.RoomID,
+		Seq:        message.Seq,
+		Name:       message.UserName,
+		ToName:     policy.Sanitize(message.ToName),
+		ParentID:   message.ParentID,
+		AlsoToRoom: message.AlsoToRoom,
+		Message:    message.HTML,
+		Markdown:   message.Markdown,
+		Edited:     message.Edited,
+		Deleted:    message.Deleted,
+		CreatedAt:  timefmt.TimeToStr(message.CreatedAt),
+	}
 }
@@ -0,0 +1,1151 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	mock_usecase "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/usecase"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/envelope"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/timefmt"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func TestWebsocketHandler_postMessage_SeqOrder(t *testing.T) {
+	const senders = 50
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	// 保存ごとにseqを割り当て、保存にかかる時間をばらつかせる
+	var seqMu sync.Mutex
+	var lastSeq int64
+	messageUsecase := mock_usecase.NewMockMessageUsecase(ctrl)
+	messageUsecase.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, message *domain.Message) error {
+		seqMu.Lock()
+		lastSeq++
+		message.Seq = lastSeq
+		seqMu.Unlock()
+		time.Sleep(time.Duration(rand.Intn(500)) * time.Microsecond)
+		return nil
+	}).Times(senders)
+
+	hub := NewHub(io.Discard)
+	room := hub.Create("1234")
+	conn := &fakeConn{}
+	room.Register(NewClient("id0", "receiver", false, conn))
+
+	h := &WebsocketHandler{messageUsecase: messageUsecase, hub: hub}
+
+	var wg sync.WaitGroup
+	for i := 0; i < senders; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			message := domain.Message{RoomID: room.ID, UserID: fmt.Sprintf("id%d", i), UserName: fmt.Sprintf("user%d", i), Markdown: "hello"}
+			if err := h.postMessage(context.Background(), room, &message); err != nil {
+				t.Errorf("postMessage() error = %v", err)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	// 受信したメッセージのseqが1から順に並んでいる
+	msgs := waitMessages(t, conn, senders)
+	if len(msgs) != senders {
+		t.Fatalf("client received %d messages, want %d", len(msgs), senders)
+	}
+	for i, msg := range msgs {
+		var p envelope.ChatMessagePayload
+		if err := msg.DecodePayload(&p); err != nil {
+			t.Fatalf("DecodePayload() error = %v", err)
+		}
+		if p.Seq != int64(i+1) {
+			t.Errorf("message %d seq = %d, want %d", i, p.Seq, i+1)
+		}
+	}
+}
+
+func TestWebsocketHandler_heartbeat(t *testing.T) {
+	h := &WebsocketHandler{hub: NewHub(io.Discard), pingInterval: time.Millisecond}
+	conn := &fakeConn{}
+	client := NewClient("id1", "user", false, conn)
+
+	done := make(chan struct{})
+	go func() {
+		h.heartbeat(client)
+		close(done)
+	}()
+
+	// 一定間隔でpingが送信される
+	deadline := time.Now().Add(5 * time.Second)
+	for conn.pingCount() < 3 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if got := conn.pingCount(); got < 3 {
+		t.Fatalf("client received %d pings, want at least 3", got)
+	}
+
+	// 切断されたら停止する
+	client.Close(closeCodeNormal, "")
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("heartbeat did not stop after client was closed")
+	}
+}
+
+// テスト用のWebsocketHandlerが使うusecaseのモック
+type testUsecases struct {
+	user              *mock_usecase.MockUserUsecase
+	participatingRoom *mock_usecase.MockParticipatingRoomUsecase
+	room              *mock_usecase.MockRoomUsecase
+	message           *mock_usecase.MockMessageUsecase
+	messageReaction   *mock_usecase.MockMessageReactionUsecase
+	messageMention    *mock_usecase.MockMessageMentionUsecase
+	messagePin        *mock_usecase.MockMessagePinUsecase
+	roomRole          *mock_usecase.MockRoomRoleUsecase
+}
+
+// serveConnのテスト用のハンドラーとRoomを作成する
+func newTestWebsocketHandler(ctrl *gomock.Controller, pongTimeout time.Duration) (*WebsocketHandler, *testUsecases) {
+	u := &testUsecases{
+		user:              mock_usecase.NewMockUserUsecase(ctrl),
+		participatingRoom: mock_usecase.NewMockParticipatingRoomUsecase(ctrl),
+		room:              mock_usecase.NewMockRoomUsecase(ctrl),
+		message:           mock_usecase.NewMockMessageUsecase(ctrl),
+		messageReaction:   mock_usecase.NewMockMessageReactionUsecase(ctrl),
+		messageMention:    mock_usecase.NewMockMessageMentionUsecase(ctrl),
+		messagePin:        mock_usecase.NewMockMessagePinUsecase(ctrl),
+		roomRole:          mock_usecase.NewMockRoomRoleUsecase(ctrl),
+	}
+	hub := NewHub(io.Discard)
+	hub.Create("1234")
+
+	h := &WebsocketHandler{
+		userUsecase:              u.user,
+		participatingRoomUsecase: u.participatingRoom,
+		roomUsecase:              u.room,
+		messageUsecase:           u.message,
+		messageReactionUsecase:   u.messageReaction,
+		messageMentionUsecase:    u.messageMention,
+		messagePinUsecase:        u.messagePin,
+		roomRoleUsecase:          u.roomRole,
+		hub:                      hub,
+		pingInterval:             time.Hour,
+		pongTimeout:              pongTimeout,
+	}
+	return h, u
+}
+
+// serveConnをgoroutineで動かし、終了したら閉じるチャネルを返す
+func startServeConn(h *WebsocketHandler, conn *fakeConn) chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		h.serveConn(context.Background(), conn, "id1", "user")
+		close(done)
+	}()
+	return done
+}
+
+// Roomに参加した状態のコネクションを作成する。切断時までのusecaseの呼び出しを設定する
+func joinTestRoom(t *testing.T, h *WebsocketHandler, u *testUsecases) (*fakeConn, chan struct{}) {
+	t.Helper()
+	u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1"}, nil)
+	u.participatingRoom.EXPECT().GetUsersByRoomID(gomock.Any(), "1234").Return(&domain.Users{domain.User{ID: "id1", Name: "user"}}, nil).Times(2)
+	u.messageMention.EXPECT().MarkReadByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(nil).Times(2)
+	u.message.EXPECT().GetHistory(gomock.Any(), "1234", "id1", "", historyLimit).Return(&domain.Messages{}, nil)
+	u.messagePin.EXPECT().GetByRoomID(gomock.Any(), "1234").Return(&domain.Messages{}, nil)
+
+	conn := &fakeConn{}
+	done := startServeConn(h, conn)
+	conn.send(`{"v":1,"type":"room.join","payload":{"roomid":"1234"}}`)
+	waitEvent(t, conn, envelope.TypePresenceUpdate)
+	return conn, done
+}
+
+// ユーザーがRoomでメッセージを送信できるようにする
+func allowPost(u *testUsecases, roomID string) {
+	u.roomRole.EXPECT().Authorize(gomock.Any(), "id1", roomID, domain.PermPost).Return(&domain.ParticipatingRoom{RoomID: roomID, UserID: "id1", Role: domain.RoleMember}, nil).AnyTimes()
+}
+
+func waitDone(t *testing.T, done chan struct{}) {
+	t.Helper()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("serveConn did not return")
+	}
+}
+
+// 指定したtypeのイベントが届くまで待つ
+func waitEvent(t *testing.T, conn *fakeConn, typ string) *envelope.Envelope {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, msg := range conn.messages() {
+			if msg.Type == typ {
+				return msg
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("event %s was not received: %v", typ, conn.messages())
+	return nil
+}
+
+// 指定したtypeとidのイベントが届くまで待つ
+func waitEventID(t *testing.T, conn *fakeConn, typ, id string) *envelope.Envelope {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		for _, msg := range conn.messages() {
+			if msg.Type == typ && msg.ID == id {
+				return msg
+			}
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("event %s (id %s) was not received: %v", typ, id, conn.messages())
+	return nil
+}
+
+func TestWebsocketHandler_serveConn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, time.Minute)
+	allowPost(u, "1234")
+	u.message.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, message *domain.Message) error {
+		if message.ClientID != "c1" || message.Markdown != "hello" {
+			t.Errorf("Create() message = %+v, want clientid c1 and markdown hello", message)
+		}
+		message.ID = "01J00000000000000000000001"
+		message.Seq = 1
+		message.HTML = "<p>hello</p>\n"
+		return nil
+	})
+
+	conn, done := joinTestRoom(t, h, u)
+
+	conn.send(`{"v":1,"type":"chat.send","id":"c1","payload":{"message":"hello"}}`)
+	ack := waitEvent(t, conn, envelope.TypeChatAck)
+	var ackPayload envelope.ChatAckPayload
+	if err := ack.DecodePayload(&ackPayload); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if ack.ID != "c1" || ackPayload.ID != "01J00000000000000000000001" || ackPayload.Seq != 1 {
+		t.Errorf("chat.ack = %s %+v, want id c1 for message 01J00000000000000000000001 seq 1", ack.ID, ackPayload)
+	}
+	// 送信者とRoomはセッションと参加したRoomのものになる
+	chat := waitEvent(t, conn, envelope.TypeChatMessage)
+	var chatPayload envelope.ChatMessagePayload
+	if err := chat.DecodePayload(&chatPayload); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if chatPayload.Name != "user" || chatPayload.RoomID != "1234" {
+		t.Errorf("chat.message = %+v, want name user in room 1234", chatPayload)
+	}
+
+	// 不明なtypeにはエラーを返し、接続は維持する
+	conn.send(`{"v":1,"type":"chat.message","id":"c2","payload":{}}`)
+	if e := waitEvent(t, conn, envelope.TypeError); e.ID != "c2" {
+		t.Errorf("error id = %s, want c2", e.ID)
+	}
+
+	// クライアントが切断すると退出の処理をして終了する
+	conn.hangup()
+	waitDone(t, done)
+	if online, err := h.hub.rooms["1234"].OnlineUsers(); err != nil || len(online) != 0 {
+		t.Errorf("OnlineUsers() = %v, %v, want empty", online, err)
+	}
+}
+
+func TestWebsocketHandler_serveConn_Post(t *testing.T) {
+	tests := []struct {
+		name     string
+		mockFn   func(u *testUsecases)
+		wantCode string
+	}{
+		{
+			name: "[正常系] メンバーは送信できる",
+			mockFn: func(u *testUsecases) {
+				u.roomRole.EXPECT().Authorize(gomock.Any(), "id1", "1234", domain.PermPost).Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1", Role: domain.RoleMember}, nil)
+				u.message.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, message *domain.Message) error {
+					message.ID = "01J00000000000000000000001"
+					message.Seq = 1
+					return nil
+				})
+			},
+		},
+		{
+			name: "[異常系] 閲覧のみの参加者は送信できない",
+			mockFn: func(u *testUsecases) {
+				u.roomRole.EXPECT().Authorize(gomock.Any(), "id1", "1234", domain.PermPost).Return(nil, domain.ErrForbidden)
+			},
+			wantCode: envelope.ErrCodeForbidden,
+		},
+		{
+			name: "[異常系] 退出させられた参加者は送信できない",
+			mockFn: func(u *testUsecases) {
+				u.roomRole.EXPECT().Authorize(gomock.Any(), "id1", "1234", domain.PermPost).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: envelope.ErrCodeForbidden,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			h, u := newTestWebsocketHandler(ctrl, time.Minute)
+			conn, done := joinTestRoom(t, h, u)
+			tt.mockFn(u)
+
+			conn.send(`{"v":1,"type":"chat.send","id":"c1","payload":{"message":"hello"}}`)
+			if tt.wantCode != "" {
+				e := waitEvent(t, conn, envelope.TypeError)
+				var p envelope.ErrorPayload
+				if err := e.DecodePayload(&p); err != nil {
+					t.Fatalf("DecodePayload() error = %v", err)
+				}
+				if e.ID != "c1" || p.Code != tt.wantCode {
+					t.Errorf("error = %s %+v, want id c1 code %s", e.ID, p, tt.wantCode)
+				}
+			} else {
+				waitEvent(t, conn, envelope.TypeChatMessage)
+			}
+
+			conn.hangup()
+			waitDone(t, done)
+		})
+	}
+}
+
+func TestWebsocketHandler_serveConn_Reject(t *testing.T) {
+	tests := []struct {
+		name     string
+		join     string
+		wantCode int
+	}{
+		{
+			name:     "[異常系] 最初のフレームが参加ではない",
+			join:     `{"v":1,"type":"chat.send","payload":{"message":"hello"}}`,
+			wantCode: closeCodeBadRequest,
+		},
+		{
+			name:     "[異常系] roomidが空",
+			join:     `{"v":1,"type":"room.join","payload":{"roomid":""}}`,
+			wantCode: closeCodeBadRequest,
+		},
+		{
+			name:     "[異常系] Roomが存在しない",
+			join:     `{"v":1,"type":"room.join","payload":{"roomid":"9999"}}`,
+			wantCode: closeCodeRoomNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			h, _ := newTestWebsocketHandler(ctrl, time.Minute)
+			conn := &fakeConn{}
+			done := startServeConn(h, conn)
+
+			conn.send(tt.join)
+			waitDone(t, done)
+			if code, _ := conn.closeStatus(); code != tt.wantCode {
+				t.Errorf("close code = %d, want %d", code, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestWebsocketHandler_serveConn_PingTimeout(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, 50*time.Millisecond)
+	u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1"}, nil)
+	u.participatingRoom.EXPECT().GetUsersByRoomID(gomock.Any(), "1234").Return(&domain.Users{}, nil).Times(2)
+	u.messageMention.EXPECT().MarkReadByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(nil).Times(2)
+	u.message.EXPECT().GetHistory(gomock.Any(), "1234", "id1", "", historyLimit).Return(&domain.Messages{}, nil)
+	u.messagePin.EXPECT().GetByRoomID(gomock.Any(), "1234").Return(&domain.Messages{}, nil)
+
+	conn := &fakeConn{}
+	done := startServeConn(h, conn)
+	conn.send(`{"v":1,"type":"room.join","payload":{"roomid":"1234"}}`)
+
+	// pongが届いている間は切断されない
+	for i := 0; i < 5; i++ {
+		time.Sleep(20 * time.Millisecond)
+		conn.pong()
+	}
+	if conn.isClosed() {
+		t.Fatalf("connection was closed while receiving pongs")
+	}
+
+	// 応答がなくなると切断され、Roomから退出する
+	waitDone(t, done)
+	waitClosed(t, conn)
+	if code, _ := conn.closeStatus(); code != closeCodePingTimeout {
+		t.Errorf("close code = %d, want %d", code, closeCodePingTimeout)
+	}
+	if online, err := h.hub.rooms["1234"].OnlineUsers(); err != nil || len(online) != 0 {
+		t.Errorf("OnlineUsers() = %v, %v, want empty", online, err)
+	}
+}
+
+func TestWebsocketHandler_serveConn_ModifyMessage(t *testing.T) {
+	edited := &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", Seq: 1, UserID: "id2", UserName: "other", Markdown: "edited", HTML: "<p>edited</p>\n", Edited: true}
+	deleted := &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", Seq: 1, UserID: "id1", UserName: "user", Deleted: true}
+	tests := []struct {
+		name     string
+		frame    string
+		mockFn   func(pr *mock_usecase.MockParticipatingRoomUsecase, mu *mock_usecase.MockMessageUsecase, mr *mock_usecase.MockMessageReactionUsecase)
+		want     *envelope.ChatMessagePayload
+		wantCode string
+	}{
+		{
+			name:  "[正常系] モデレーターによる編集",
+			frame: `{"v":1,"type":"chat.edit","id":"c1","payload":{"id":"01J00000000000000000000001","message":"edited"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mu *mock_usecase.MockMessageUsecase, mr *mock_usecase.MockMessageReactionUsecase) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1", Role: domain.RoleModerator}, nil)
+				mu.EXPECT().Edit(gomock.Any(), "1234", "01J00000000000000000000001", "id1", true, "edited", domain.Users(nil)).Return(edited, nil)
+				mr.EXPECT().GetCounts(gomock.Any(), []string{"01J00000000000000000000001"}).Return(&domain.ReactionCounts{domain.ReactionCount{MessageID: "01J00000000000000000000001", Emoji: "👍", Count: 2}}, nil)
+				mu.EXPECT().GetThreadSummaries(gomock.Any(), []string{"01J00000000000000000000001"}).Return(&domain.ThreadSummaries{}, nil)
+			},
+			want: func() *envelope.ChatMessagePayload {
+				p := toChatMessagePayload(edited)
+				p.Reactions = []envelope.ReactionPayload{{Emoji: "👍", Count: 2}}
+				return p
+			}(),
+		},
+		{
+			name:  "[正常系] 送信者本人による削除",
+			frame: `{"v":1,"type":"chat.delete","id":"c1","payload":{"id":"01J00000000000000000000001"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mu *mock_usecase.MockMessageUsecase, mr *mock_usecase.MockMessageReactionUsecase) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1"}, nil)
+				mu.EXPECT().Delete(gomock.Any(), "1234", "01J00000000000000000000001", "id1", false).Return(deleted, nil)
+				mr.EXPECT().GetCounts(gomock.Any(), []string{"01J00000000000000000000001"}).Return(&domain.ReactionCounts{}, nil)
+				mu.EXPECT().GetThreadSummaries(gomock.Any(), []string{"01J00000000000000000000001"}).Return(&domain.ThreadSummaries{}, nil)
+			},
+			want: toChatMessagePayload(deleted),
+		},
+		{
+			name:  "[異常系] 送信者でもモデレーターでもない",
+			frame: `{"v":1,"type":"chat.edit","id":"c1","payload":{"id":"01J00000000000000000000001","message":"edited"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mu *mock_usecase.MockMessageUsecase, mr *mock_usecase.MockMessageReactionUsecase) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1", Role: domain.RoleMember}, nil)
+				mu.EXPECT().Edit(gomock.Any(), "1234", "01J00000000000000000000001", "id1", false, "edited", domain.Users(nil)).Return(nil, domain.ErrMessageForbidden)
+			},
+			wantCode: envelope.ErrCodeForbidden,
+		},
+		{
+			name:  "[異常系] メッセージが存在しない",
+			frame: `{"v":1,"type":"chat.delete","id":"c1","payload":{"id":"01J00000000000000000000001"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mu *mock_usecase.MockMessageUsecase, mr *mock_usecase.MockMessageReactionUsecase) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1"}, nil)
+				mu.EXPECT().Delete(gomock.Any(), "1234", "01J00000000000000000000001", "id1", false).Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: envelope.ErrCodeNotFound,
+		},
+		{
+			name:  "[異常系] 編集するメッセージのidが空",
+			frame: `{"v":1,"type":"chat.edit","id":"c1","payload":{"message":"edited"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mu *mock_usecase.MockMessageUsecase, mr *mock_usecase.MockMessageReactionUsecase) {
+			},
+			wantCode: envelope.ErrCodeBadRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			h, u := newTestWebsocketHandler(ctrl, time.Minute)
+			conn, done := joinTestRoom(t, h, u)
+			tt.mockFn(u.participatingRoom, u.message, u.messageReaction)
+
+			conn.send(tt.frame)
+			if tt.wantCode != "" {
+				e := waitEvent(t, conn, envelope.TypeError)
+				var p envelope.ErrorPayload
+				if err := e.DecodePayload(&p); err != nil {
+					t.Fatalf("DecodePayload() error = %v", err)
+				}
+				if e.ID != "c1" || p.Code != tt.wantCode {
+					t.Errorf("error = %s %+v, want id c1 with code %s", e.ID, p, tt.wantCode)
+				}
+			} else {
+				e := waitEvent(t, conn, envelope.TypeChatUpdate)
+				var got envelope.ChatMessagePayload
+				if err := e.DecodePayload(&got); err != nil {
+					t.Fatalf("DecodePayload() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, *tt.want) {
+					t.Errorf("chat.update = %+v, want %+v", got, *tt.want)
+				}
+				if ack := waitEvent(t, conn, envelope.TypeChatAck); ack.ID != "c1" {
+					t.Errorf("chat.ack id = %s, want c1", ack.ID)
+				}
+			}
+
+			conn.hangup()
+			waitDone(t, done)
+		})
+	}
+}
+
+func TestWebsocketHandler_serveConn_ToggleReaction(t *testing.T) {
+	message := &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", Seq: 1, UserID: "id2", UserName: "other", HTML: "<p>hello</p>\n"}
+	tests := []struct {
+		name     string
+		frame    string
+		mockFn   func(mr *mock_usecase.MockMessageReactionUsecase)
+		want     *envelope.ReactionUpdatePayload
+		wantCode string
+	}{
+		{
+			name:  "[正常系] リアクションを付ける",
+			frame: `{"v":1,"type":"reaction.toggle","id":"c1","payload":{"id":"01J00000000000000000000001","emoji":"👍"}}`,
+			mockFn: func(mr *mock_usecase.MockMessageReactionUsecase) {
+				mr.EXPECT().Toggle(gomock.Any(), "1234", "01J00000000000000000000001", "id1", "👍").Return(message, &domain.ReactionCounts{domain.ReactionCount{MessageID: "01J00000000000000000000001", Emoji: "👍", Count: 1}}, nil)
+			},
+			want: &envelope.ReactionUpdatePayload{RoomID: "1234", ID: "01J00000000000000000000001", Reactions: []envelope.ReactionPayload{{Emoji: "👍", Count: 1}}},
+		},
+		{
+			name:  "[正常系] 最後のリアクションを外す",
+			frame: `{"v":1,"type":"reaction.toggle","id":"c1","payload":{"id":"01J00000000000000000000001","emoji":"👍"}}`,
+			mockFn: func(mr *mock_usecase.MockMessageReactionUsecase) {
+				mr.EXPECT().Toggle(gomock.Any(), "1234", "01J00000000000000000000001", "id1", "👍").Return(message, &domain.ReactionCounts{}, nil)
+			},
+			want: &envelope.ReactionUpdatePayload{RoomID: "1234", ID: "01J00000000000000000000001", Reactions: []envelope.ReactionPayload{}},
+		},
+		{
+			name:  "[異常系] リアクションの種類数の上限",
+			frame: `{"v":1,"type":"reaction.toggle","id":"c1","payload":{"id":"01J00000000000000000000001","emoji":"👍"}}`,
+			mockFn: func(mr *mock_usecase.MockMessageReactionUsecase) {
+				mr.EXPECT().Toggle(gomock.Any(), "1234", "01J00000000000000000000001", "id1", "👍").Return(nil, nil, domain.ErrTooManyReactions)
+			},
+			wantCode: envelope.ErrCodeBadRequest,
+		},
+		{
+			name:  "[異常系] メッセージが存在しない",
+			frame: `{"v":1,"type":"reaction.toggle","id":"c1","payload":{"id":"01J00000000000000000000001","emoji":"👍"}}`,
+			mockFn: func(mr *mock_usecase.MockMessageReactionUsecase) {
+				mr.EXPECT().Toggle(gomock.Any(), "1234", "01J00000000000000000000001", "id1", "👍").Return(nil, nil, gorm.ErrRecordNotFound)
+			},
+			wantCode: envelope.ErrCodeNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			h, u := newTestWebsocketHandler(ctrl, time.Minute)
+			conn, done := joinTestRoom(t, h, u)
+			tt.mockFn(u.messageReaction)
+
+			conn.send(tt.frame)
+			if tt.wantCode != "" {
+				e := waitEvent(t, conn, envelope.TypeError)
+				var p envelope.ErrorPayload
+				if err := e.DecodePayload(&p); err != nil {
+					t.Fatalf("DecodePayload() error = %v", err)
+				}
+				if e.ID != "c1" || p.Code != tt.wantCode {
+					t.Errorf("error = %s %+v, want id c1 with code %s", e.ID, p, tt.wantCode)
+				}
+			} else {
+				e := waitEvent(t, conn, envelope.TypeReactionUpdate)
+				var got envelope.ReactionUpdatePayload
+				if err := e.DecodePayload(&got); err != nil {
+					t.Fatalf("DecodePayload() error = %v", err)
+				}
+				if !reflect.DeepEqual(got, *tt.want) {
+					t.Errorf("reaction.update = %+v, want %+v", got, *tt.want)
+				}
+			}
+
+			conn.hangup()
+			waitDone(t, done)
+		})
+	}
+}
+
+func TestWebsocketHandler_sendHistory(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, time.Minute)
+	messages := domain.Messages{
+		domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", Seq: 1, UserID: "id1", UserName: "user", Markdown: "**a**", HTML: "<p><strong>a</strong></p>\n"},
+		domain.Message{ID: "01J00000000000000000000002", RoomID: "1234", Seq: 2, UserID: "id1", UserName: "user", HTML: "<p>b</p>\n"},
+		domain.Message{ID: "01J00000000000000000000004", RoomID: "1234", Seq: 4, UserID: "id1", UserName: "user", ParentID: "01J00000000000000000000001", AlsoToRoom: true, HTML: "<p>d</p>\n"},
+	}
+	lastReplyAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.Local)
+	u.message.EXPECT().GetHistory(gomock.Any(), "1234", "id1", "", historyLimit).Return(&messages, nil)
+	u.message.EXPECT().GetThreadSummaries(gomock.Any(), []string{"01J00000000000000000000001", "01J00000000000000000000002"}).Return(&domain.ThreadSummaries{
+		domain.ThreadSummary{ParentID: "01J00000000000000000000001", ReplyCount: 2, LastReplyAt: lastReplyAt, Participants: []string{"other", "user"}},
+	}, nil)
+	u.messageReaction.EXPECT().GetCounts(gomock.Any(), []string{"01J00000000000000000000001", "01J00000000000000000000002", "01J00000000000000000000004"}).Return(&domain.ReactionCounts{
+		domain.ReactionCount{MessageID: "01J00000000000000000000002", Emoji: "🎉", Count: 3},
+		domain.ReactionCount{MessageID: "01J00000000000000000000002", Emoji: "👍", Count: 1},
+	}, nil)
+
+	conn := &fakeConn{}
+	client := NewClient("id1", "user", false, conn)
+	defer client.Close(closeCodeNormal, "")
+	if err := h.sendHistory(context.Background(), client, "1234", "", ""); err != nil {
+		t.Fatalf("sendHistory() error = %v", err)
+	}
+
+	e := waitEvent(t, conn, envelope.TypeHistoryPage)
+	var got envelope.HistoryPagePayload
+	if err := e.DecodePayload(&got); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if len(got.Messages) != 3 {
+		t.Fatalf("history.page messages = %d, want 3", len(got.Messages))
+	}
+	if got.Messages[0].Markdown != "**a**" {
+		t.Errorf("messages[0].markdown = %q, want %q", got.Messages[0].Markdown, "**a**")
+	}
+	if got.Messages[0].Reactions != nil {
+		t.Errorf("messages[0].reactions = %+v, want none", got.Messages[0].Reactions)
+	}
+	want := []envelope.ReactionPayload{{Emoji: "🎉", Count: 3}, {Emoji: "👍", Count: 1}}
+	if !reflect.DeepEqual(got.Messages[1].Reactions, want) {
+		t.Errorf("messages[1].reactions = %+v, want %+v", got.Messages[1].Reactions, want)
+	}
+
+	wantThread := &envelope.ThreadSummaryPayload{ReplyCount: 2, LastReplyAt: timefmt.TimeToStr(lastReplyAt), Participants: []string{"other", "user"}}
+	if !reflect.DeepEqual(got.Messages[0].Thread, wantThread) {
+		t.Errorf("messages[0].thread = %+v, want %+v", got.Messages[0].Thread, wantThread)
+	}
+	if got.Messages[1].Thread != nil {
+		t.Errorf("messages[1].thread = %+v, want none", got.Messages[1].Thread)
+	}
+	if got.Messages[2].ParentID != "01J00000000000000000000001" || !got.Messages[2].AlsoToRoom {
+		t.Errorf("messages[2] = %+v, want reply also sent to room", got.Messages[2])
+	}
+}
+
+func TestWebsocketHandler_serveConn_HistoryError(t *testing.T) {
+	tests := []struct {
+		name        string
+		err         error
+		wantCode    string
+		wantMessage string
+	}{
+		{
+			name:        "[異常系] beforeが不正",
+			err:         domain.ErrInvalidCursor,
+			wantCode:    envelope.ErrCodeBadRequest,
+			wantMessage: domain.ErrInvalidCursor.Error(),
+		},
+		{
+			name:        "[異常系] DB処理失敗（GetHistory）",
+			err:         errors.New("test error"),
+			wantCode:    envelope.ErrCodeInternal,
+			wantMessage: "過去のメッセージの取得に失敗しました。",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			h, u := newTestWebsocketHandler(ctrl, time.Minute)
+			conn, done := joinTestRoom(t, h, u)
+			u.message.EXPECT().GetHistory(gomock.Any(), "1234", "id1", "01J00000000000000000000001", historyLimit).Return(nil, tt.err)
+
+			conn.send(`{"v":1,"type":"history.request","id":"c1","payload":{"before":"01J00000000000000000000001"}}`)
+			e := waitEvent(t, conn, envelope.TypeError)
+			var p envelope.ErrorPayload
+			if err := e.DecodePayload(&p); err != nil {
+				t.Fatalf("DecodePayload() error = %v", err)
+			}
+			if e.ID != "c1" || p.Code != tt.wantCode || p.Message != tt.wantMessage {
+				t.Errorf("error = %s %+v, want id c1 with code %s and message %s", e.ID, p, tt.wantCode, tt.wantMessage)
+			}
+
+			conn.hangup()
+			waitDone(t, done)
+		})
+	}
+}
+
+func TestWebsocketHandler_serveConn_Thread(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, time.Minute)
+	parent := &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", Seq: 1, UserID: "id2", UserName: "other", HTML: "<p>parent</p>\n"}
+	replies := &domain.Messages{domain.Message{ID: "01J00000000000000000000002", RoomID: "1234", Seq: 2, UserID: "id1", UserName: "user", ParentID: parent.ID, HTML: "<p>reply</p>\n"}}
+	summaries := &domain.ThreadSummaries{domain.ThreadSummary{ParentID: parent.ID, ReplyCount: 1, LastReplyAt: time.Now(), Participants: []string{"user"}}}
+
+	allowPost(u, "1234")
+	u.message.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, message *domain.Message) error {
+		if message.ParentID != parent.ID || message.AlsoToRoom {
+			t.Errorf("Create() message = %+v, want reply to %s", message, parent.ID)
+		}
+		message.ID = "01J00000000000000000000002"
+		message.Seq = 2
+		message.HTML = "<p>reply</p>\n"
+		return nil
+	})
+	u.message.EXPECT().GetThreadSummaries(gomock.Any(), []string{parent.ID}).Return(summaries, nil).Times(2)
+	u.message.EXPECT().GetThread(gomock.Any(), "1234", parent.ID, "", threadLimit).Return(parent, replies, nil)
+	u.messageReaction.EXPECT().GetCounts(gomock.Any(), gomock.Any()).Return(&domain.ReactionCounts{}, nil).Times(2)
+
+	conn, done := joinTestRoom(t, h, u)
+
+	// 返信は返信先のメッセージIDを付けて配信され、返信先のスレッドの概要が更新される
+	conn.send(`{"v":1,"type":"chat.send","id":"c1","payload":{"message":"reply","parentid":"01J00000000000000000000001"}}`)
+	var chatPayload envelope.ChatMessagePayload
+	if err := waitEvent(t, conn, envelope.TypeChatMessage).DecodePayload(&chatPayload); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if chatPayload.ParentID != parent.ID || chatPayload.AlsoToRoom {
+		t.Errorf("chat.message = %+v, want reply to %s", chatPayload, parent.ID)
+	}
+	var update envelope.ThreadUpdatePayload
+	if err := waitEvent(t, conn, envelope.TypeThreadUpdate).DecodePayload(&update); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if update.ID != parent.ID || update.Thread.ReplyCount != 1 || !reflect.DeepEqual(update.Thread.Participants, []string{"user"}) {
+		t.Errorf("thread.update = %+v, want 1 reply to %s", update, parent.ID)
+	}
+
+	conn.send(`{"v":1,"type":"thread.request","id":"c2","payload":{"id":"01J00000000000000000000001"}}`)
+	page := waitEvent(t, conn, envelope.TypeThreadPage)
+	var pagePayload envelope.ThreadPagePayload
+	if err := page.DecodePayload(&pagePayload); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if page.ID != "c2" || pagePayload.Parent.ID != parent.ID || pagePayload.Parent.Thread == nil || len(pagePayload.Messages) != 1 || pagePayload.HasMore {
+		t.Errorf("thread.page = %s %+v, want parent %s with 1 reply", page.ID, pagePayload, parent.ID)
+	}
+
+	conn.hangup()
+	waitDone(t, done)
+}
+
+func TestWebsocketHandler_serveConn_Mention(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, time.Minute)
+	// 入室、メンションの確認、退出で参加者を取得する
+	u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1"}, nil)
+	u.participatingRoom.EXPECT().GetUsersByRoomID(gomock.Any(), "1234").Return(&domain.Users{domain.User{ID: "id1", Name: "user"}, domain.User{ID: "id2", Name: "other"}}, nil).Times(3)
+	u.messageMention.EXPECT().MarkReadByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(nil).Times(2)
+	u.message.EXPECT().GetHistory(gomock.Any(), "1234", "id1", "", historyLimit).Return(&domain.Messages{}, nil)
+	u.messagePin.EXPECT().GetByRoomID(gomock.Any(), "1234").Return(&domain.Messages{}, nil)
+	allowPost(u, "1234")
+	u.message.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, message *domain.Message) error {
+		if len(message.Mentions) != 1 || message.Mentions[0].UserID != "id2" {
+			t.Errorf("Create() Mentions = %+v, want id2", message.Mentions)
+		}
+		message.ID = "01J00000000000000000000001"
+		message.Seq = 1
+		return nil
+	})
+
+	// メンションされたユーザーは別のRoomにいても通知を受け取る
+	otherConn := &fakeConn{}
+	other := NewClient("id2", "other", false, otherConn)
+	h.hub.addClient(other)
+	defer h.hub.removeClient(other)
+
+	conn := &fakeConn{}
+	done := startServeConn(h, conn)
+	conn.send(`{"v":1,"type":"room.join","payload":{"roomid":"1234"}}`)
+	waitEvent(t, conn, envelope.TypePresenceUpdate)
+	conn.send(`{"v":1,"type":"chat.send","id":"c1","payload":{"message":"@other @nobody hi"}}`)
+	waitEvent(t, conn, envelope.TypeChatMessage)
+
+	var notify envelope.MentionNotifyPayload
+	if err := waitEvent(t, otherConn, envelope.TypeMentionNotify).DecodePayload(&notify); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if notify.RoomID != "1234" || notify.ID != "01J00000000000000000000001" || notify.Name != "user" {
+		t.Errorf("mention.notify = %+v, want message 01J00000000000000000000001 from user in room 1234", notify)
+	}
+
+	conn.hangup()
+	waitDone(t, done)
+}
+
+func TestWebsocketHandler_serveConn_DirectMessage(t *testing.T) {
+	t.Run("[異常系] 参加者以外はダイレクトメッセージのRoomに参加できない", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h, u := newTestWebsocketHandler(ctrl, time.Minute)
+		h.hub.CreateDirect("dm1")
+		u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "dm1").Return(&domain.ParticipatingRoom{}, gorm.ErrRecordNotFound)
+
+		conn := &fakeConn{}
+		done := startServeConn(h, conn)
+		conn.send(`{"v":1,"type":"room.join","payload":{"roomid":"dm1"}}`)
+		waitDone(t, done)
+		if code, _ := conn.closeStatus(); code != closeCodeNotMember {
+			t.Errorf("close code = %d, want %d", code, closeCodeNotMember)
+		}
+	})
+
+	t.Run("[正常系] 相手が他のRoomにいてもダイレクトメッセージが届く", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		h, u := newTestWebsocketHandler(ctrl, time.Minute)
+		h.hub.CreateDirect("dm1")
+		// 入室、ダイレクトメッセージの配信、退出で参加者を取得する
+		u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "dm1").Return(&domain.ParticipatingRoom{RoomID: "dm1", UserID: "id1"}, nil)
+		u.participatingRoom.EXPECT().GetUsersByRoomID(gomock.Any(), "dm1").Return(&domain.Users{domain.User{ID: "id1", Name: "user"}, domain.User{ID: "id2", Name: "other"}}, nil).Times(3)
+		u.messageMention.EXPECT().MarkReadByUserIDAndRoomID(gomock.Any(), "id1", "dm1").Return(nil).Times(2)
+		u.message.EXPECT().GetHistory(gomock.Any(), "dm1", "id1", "", historyLimit).Return(&domain.Messages{}, nil)
+		u.messagePin.EXPECT().GetByRoomID(gomock.Any(), "dm1").Return(&domain.Messages{}, nil)
+		allowPost(u, "dm1")
+		u.message.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, message *domain.Message) error {
+			message.ID = "01J00000000000000000000001"
+			message.Seq = 1
+			return nil
+		})
+
+		otherConn := &fakeConn{}
+		other := NewClient("id2", "other", false, otherConn)
+		h.hub.addClient(other)
+		defer h.hub.removeClient(other)
+
+		conn := &fakeConn{}
+		done := startServeConn(h, conn)
+		conn.send(`{"v":1,"type":"room.join","payload":{"roomid":"dm1"}}`)
+		waitEvent(t, conn, envelope.TypePresenceUpdate)
+		conn.send(`{"v":1,"type":"chat.send","id":"c1","payload":{"message":"hi"}}`)
+		waitEvent(t, conn, envelope.TypeChatMessage)
+
+		var dm envelope.ChatMessagePayload
+		if err := waitEvent(t, otherConn, envelope.TypeDirectMessage).DecodePayload(&dm); err != nil {
+			t.Fatalf("DecodePayload() error = %v", err)
+		}
+		if dm.RoomID != "dm1" || dm.ID != "01J00000000000000000000001" || dm.Name != "user" {
+			t.Errorf("dm.message = %+v, want message 01J00000000000000000000001 from user in room dm1", dm)
+		}
+
+		// 送信者には届かない
+		for _, msg := range conn.messages() {
+			if msg.Type == envelope.TypeDirectMessage {
+				t.Errorf("sender received %s", envelope.TypeDirectMessage)
+			}
+		}
+
+		conn.hangup()
+		waitDone(t, done)
+	})
+}
+
+func TestWebsocketHandler_serveConn_Visibility(t *testing.T) {
+	tests := []struct {
+		name       string
+		visibility string
+		wantJoin   bool
+	}{
+		{
+			name:       "[正常系] 公開されたRoomには参加者以外も参加できる",
+			visibility: domain.RoomVisibilityPublic,
+			wantJoin:   true,
+		},
+		{
+			name:       "[異常系] 招待制のRoomには参加者以外は参加できない",
+			visibility: domain.RoomVisibilityInvite,
+			wantJoin:   false,
+		},
+		{
+			name:       "[異常系] 参加承認制のRoomには参加者以外は参加できない",
+			visibility: domain.RoomVisibilityRequest,
+			wantJoin:   false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			h, u := newTestWebsocketHandler(ctrl, time.Minute)
+			u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{}, gorm.ErrRecordNotFound)
+			u.room.EXPECT().GetByID(gomock.Any(), "1234").Return(&domain.Room{ID: "1234", Kind: domain.RoomKindGroup, Visibility: tt.visibility}, nil)
+			if tt.wantJoin {
+				u.participatingRoom.EXPECT().Create(gomock.Any(), &domain.ParticipatingRoom{RoomID: "1234", UserID: "id1", Role: domain.RoleMember}).Return(nil)
+				u.participatingRoom.EXPECT().GetUsersByRoomID(gomock.Any(), "1234").Return(&domain.Users{domain.User{ID: "id1", Name: "user"}}, nil).Times(2)
+				u.messageMention.EXPECT().MarkReadByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(nil).Times(2)
+				u.message.EXPECT().GetHistory(gomock.Any(), "1234", "id1", "", historyLimit).Return(&domain.Messages{}, nil)
+				u.messagePin.EXPECT().GetByRoomID(gomock.Any(), "1234").Return(&domain.Messages{}, nil)
+			}
+
+			conn := &fakeConn{}
+			done := startServeConn(h, conn)
+			conn.send(`{"v":1,"type":"room.join","payload":{"roomid":"1234"}}`)
+			if tt.wantJoin {
+				waitEvent(t, conn, envelope.TypePresenceUpdate)
+				conn.hangup()
+				waitDone(t, done)
+				return
+			}
+			waitDone(t, done)
+			if code, _ := conn.closeStatus(); code != closeCodeNotMember {
+				t.Errorf("close code = %d, want %d", code, closeCodeNotMember)
+			}
+		})
+	}
+}
+
+func TestWebsocketHandler_serveConn_Whisper(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, time.Minute)
+	conn, done := joinTestRoom(t, h, u)
+
+	u.user.EXPECT().GetByName(gomock.Any(), "nobody").Return(&domain.User{}, gorm.ErrRecordNotFound)
+	u.user.EXPECT().GetByName(gomock.Any(), "stranger").Return(&domain.User{ID: "id3", Name: "stranger"}, nil)
+	u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id3", "1234").Return(&domain.ParticipatingRoom{}, gorm.ErrRecordNotFound)
+	u.user.EXPECT().GetByName(gomock.Any(), "other").Return(&domain.User{ID: "id2", Name: "other"}, nil).Times(2)
+	u.participatingRoom.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id2", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id2"}, nil).Times(2)
+	allowPost(u, "1234")
+	u.message.EXPECT().Create(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, message *domain.Message) error {
+		if message.ToName != "other" || message.ToUserID != "id2" {
+			t.Errorf("Create() message = %+v, want whisper to id2", message)
+		}
+		message.ID = "01J00000000000000000000001"
+		message.Seq = 1
+		return nil
+	})
+
+	tests := []struct {
+		name     string
+		id       string
+		toName   string
+		wantCode string
+	}{
+		{name: "[異常系] 存在しないユーザー", id: "c1", toName: "nobody", wantCode: envelope.ErrCodeNotFound},
+		{name: "[異常系] Roomに参加していないユーザー", id: "c2", toName: "stranger", wantCode: envelope.ErrCodeNotFound},
+		{name: "[異常系] 接続していないユーザー", id: "c3", toName: "other", wantCode: envelope.ErrCodeOffline},
+	}
+	for _, tt := range tests {
+		conn.send(fmt.Sprintf(`{"v":1,"type":"chat.send","id":"%s","payload":{"message":"secret","toname":"%s"}}`, tt.id, tt.toName))
+		var p envelope.ErrorPayload
+		if err := waitEventID(t, conn, envelope.TypeError, tt.id).DecodePayload(&p); err != nil {
+			t.Fatalf("%s: DecodePayload() error = %v", tt.name, err)
+		}
+		if p.Code != tt.wantCode {
+			t.Errorf("%s: error code = %s, want %s", tt.name, p.Code, tt.wantCode)
+		}
+	}
+
+	// 宛先が接続していれば、ユーザーIDで宛先を決めて配信する
+	room, _ := h.hub.Get("1234")
+	otherConn, sameNameConn := &fakeConn{}, &fakeConn{}
+	room.Register(NewClient("id2", "other", false, otherConn))
+	room.Register(NewClient("id4", "other", false, sameNameConn))
+	conn.send(`{"v":1,"type":"chat.send","id":"c4","payload":{"message":"secret","toname":"other"}}`)
+	waitEventID(t, conn, envelope.TypeChatAck, "c4")
+	waitEvent(t, otherConn, envelope.TypeChatMessage)
+	for _, msg := range sameNameConn.messages() {
+		if msg.Type == envelope.TypeChatMessage {
+			t.Errorf("client with the same name received the whisper")
+		}
+	}
+
+	conn.hangup()
+	waitDone(t, done)
+}
+
+func TestWebsocketHandler_serveConn_ReadMark(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, time.Minute)
+	u.participatingRoom.EXPECT().UpdateLastReadSeq(gomock.Any(), "id1", "1234", int64(5)).Return(nil)
+
+	conn, done := joinTestRoom(t, h, u)
+
+	conn.send(`{"v":1,"type":"read.mark","id":"c1","payload":{"seq":5}}`)
+	// seqが不正な場合はエラーを返す
+	conn.send(`{"v":1,"type":"read.mark","id":"c2","payload":{"seq":0}}`)
+	e := waitEvent(t, conn, envelope.TypeError)
+	var errPayload envelope.ErrorPayload
+	if err := e.DecodePayload(&errPayload); err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	if e.ID != "c2" || errPayload.Code != envelope.ErrCodeBadRequest {
+		t.Errorf("error = %s %+v, want bad_request for c2", e.ID, errPayload)
+	}
+
+	conn.hangup()
+	waitDone(t, done)
+}
+
+func TestWebsocketHandler_serveConn_Typing(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	h, u := newTestWebsocketHandler(ctrl, time.Minute)
+	conn, done := joinTestRoom(t, h, u)
+
+	other := &fakeConn{}
+	h.hub.rooms["1234"].Register(NewClient("id2", "other", false, other))
+
+	// 入力中の表示はRoomの他のクライアントに中継され、保存はされない
+	conn.send(`{"v":1,"type":"typing.start"}`)
+	conn.send(`{"v":1,"type":"typing.start"}`)
+	conn.send(`{"v":1,"type":"typing.stop"}`)
+	msgs := waitMessages(t, other, 2)
+	if len(msgs) != 2 || msgs[0].Type != envelope.TypeTypingStart || msgs[1].Type != envelope.TypeTypingStop {
+		t.Errorf("other client received %+v, want typing.start and typing.stop", msgs)
+	}
+
+	conn.hangup()
+	waitDone(t, done)
+}
+
+func TestWebsocketHandler_serveConn_PinMessage(t *testing.T) {
+	pinned := domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", Seq: 1, UserID: "id2", UserName: "other", HTML: "<p>pinned</p>\n"}
+	moderator := &domain.ParticipatingRoom{RoomID: "1234", UserID: "id1", Role: domain.RoleModerator}
+	tests := []struct {
+		name       string
+		frame      string
+		mockFn     func(pr *mock_usecase.MockParticipatingRoomUsecase, mp *mock_usecase.MockMessagePinUsecase, u *testUsecases)
+		wantNotice string
+		wantPins   int
+		wantCode   string
+	}{
+		{
+			name:  "[正常系] モデレーターによるピン留め",
+			frame: `{"v":1,"type":"pin.add","id":"c1","payload":{"id":"01J00000000000000000000001"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mp *mock_usecase.MockMessagePinUsecase, u *testUsecases) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(moderator, nil)
+				mp.EXPECT().Pin(gomock.Any(), "1234", "01J00000000000000000000001", "id1").Return(&pinned, nil)
+				mp.EXPECT().GetByRoomID(gomock.Any(), "1234").Return(&domain.Messages{pinned}, nil)
+				u.messageReaction.EXPECT().GetCounts(gomock.Any(), []string{"01J00000000000000000000001"}).Return(&domain.ReactionCounts{}, nil)
+				u.message.EXPECT().GetThreadSummaries(gomock.Any(), []string{"01J00000000000000000000001"}).Return(&domain.ThreadSummaries{}, nil)
+			},
+			wantNotice: "userがメッセージをピン留めしました",
+			wantPins:   1,
+		},
+		{
+			name:  "[正常系] モデレーターによるピン留めの解除",
+			frame: `{"v":1,"type":"pin.remove","id":"c1","payload":{"id":"01J00000000000000000000001"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mp *mock_usecase.MockMessagePinUsecase, u *testUsecases) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(moderator, nil)
+				mp.EXPECT().Unpin(gomock.Any(), "1234", "01J00000000000000000000001").Return(nil)
+				mp.EXPECT().GetByRoomID(gomock.Any(), "1234").Return(&domain.Messages{}, nil)
+			},
+			wantNotice: "userがメッセージのピン留めを外しました",
+			wantPins:   0,
+		},
+		{
+			name:  "[異常系] ピン留めの権限がない",
+			frame: `{"v":1,"type":"pin.add","id":"c1","payload":{"id":"01J00000000000000000000001"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mp *mock_usecase.MockMessagePinUsecase, u *testUsecases) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "id1", Role: domain.RoleMember}, nil)
+			},
+			wantCode: envelope.ErrCodeForbidden,
+		},
+		{
+			name:  "[異常系] ピン留めの上限",
+			frame: `{"v":1,"type":"pin.add","id":"c1","payload":{"id":"01J00000000000000000000001"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mp *mock_usecase.MockMessagePinUsecase, u *testUsecases) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(moderator, nil)
+				mp.EXPECT().Pin(gomock.Any(), "1234", "01J00000000000000000000001", "id1").Return(nil, domain.ErrTooManyPins)
+			},
+			wantCode: envelope.ErrCodeBadRequest,
+		},
+		{
+			name:  "[異常系] ピン留めされていない",
+			frame: `{"v":1,"type":"pin.remove","id":"c1","payload":{"id":"01J00000000000000000000001"}}`,
+			mockFn: func(pr *mock_usecase.MockParticipatingRoomUsecase, mp *mock_usecase.MockMessagePinUsecase, u *testUsecases) {
+				pr.EXPECT().GetByUserIDAndRoomID(gomock.Any(), "id1", "1234").Return(moderator, nil)
+				mp.EXPECT().Unpin(gomock.Any(), "1234", "01J00000000000000000000001").Return(gorm.ErrRecordNotFound)
+			},
+			wantCode: envelope.ErrCodeNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			h, u := newTestWebsocketHandler(ctrl, time.Minute)
+			conn, done := joinTestRoom(t, h, u)
+			waitEvent(t, conn, envelope.TypePinUpdate) // 入室時のピン留めの一覧
+			tt.mockFn(u.participatingRoom, u.messagePin, u)
+
+			conn.send(tt.frame)
+			if tt.wantCode != "" {
+				e := waitEvent(t, conn, envelope.TypeError)
+				var p envelope.ErrorPayload
+				if err := e.DecodePayload(&p); err != nil {
+					t.Fatalf("DecodePayload() error = %v", err)
+				}
+				if e.ID != "c1" || p.Code != tt.wantCode {
+					t.Errorf("error = %s %+v, want id c1 with code %s", e.ID, p, tt.wantCode)
+				}
+			} else {
+				// 変更後のピン留めの一覧は入室時に続く2件目のpin.update
+				var update *envelope.Envelope
+				deadline := time.Now().Add(5 * time.Second)
+				for update == nil && time.Now().Before(deadline) {
+					var updates []*envelope.Envelope
+					for _, msg := range conn.messages() {
+						if msg.Type == envelope.TypePinUpdate {
+							updates = append(updates, msg)
+						}
+					}
+					if len(updates) >= 2 {
+						update = updates[1]
+					}
+					time.Sleep(time.Millisecond)
+				}
+				if update == nil {
+					t.Fatalf("pin.update was not received: %v", conn.messages())
+				}
+				var pins envelope.PinUpdatePayload
+				if err := update.DecodePayload(&pins); err != nil {
+					t.Fatalf("DecodePayload() error = %v", err)
+				}
+				if pins.RoomID != "1234" || len(pins.Pins) != tt.wantPins {
+					t.Errorf("pin.update = %+v, want %d pins", pins, tt.wantPins)
+				}
+
+				var notice envelope.SystemNoticePayload
+				for _, msg := range conn.messages() {
+					if msg.Type == envelope.TypeSystemNotice {
+						if err := msg.DecodePayload(&notice); err != nil {
+							t.Fatalf("DecodePayload() error = %v", err)
+						}
+					}
+				}
+				if notice.Message != tt.wantNotice {
+					t.Errorf("system.notice = %q, want %q", notice.Message, tt.wantNotice)
+				}
+			}
+
+			conn.hangup()
+			waitDone(t, done)
+		})
+	}
+}
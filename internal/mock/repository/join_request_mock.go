@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: join_request_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=join_request_repository.go -destination=../mock/repository/join_request_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockJoinRequestRepo is a mock of JoinRequestRepo interface.
+type MockJoinRequestRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockJoinRequestRepoMockRecorder
+}
+
+// MockJoinRequestRepoMockRecorder is the mock recorder for MockJoinRequestRepo.
+type MockJoinRequestRepoMockRecorder struct {
+	mock *MockJoinRequestRepo
+}
+
+// NewMockJoinRequestRepo creates a new mock instance.
+func NewMockJoinRequestRepo(ctrl *gomock.Controller) *MockJoinRequestRepo {
+	mock := &MockJoinRequestRepo{ctrl: ctrl}
+	mock.recorder = &MockJoinRequestRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJoinRequestRepo) EXPECT() *MockJoinRequestRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockJoinRequestRepo) Create(ctx context.Context, request *domain.JoinRequest) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, request)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockJoinRequestRepoMockRecorder) Create(ctx, request any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockJoinRequestRepo)(nil).Create), ctx, request)
+}
+
+// GetByID mocks base method.
+func (m *MockJoinRequestRepo) GetByID(ctx context.Context, id string) (*domain.JoinRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.JoinRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockJoinRequestRepoMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockJoinRequestRepo)(nil).GetByID), ctx, id)
+}
+
+// GetPendingByRoomID mocks base method.
+func (m *MockJoinRequestRepo) GetPendingByRoomID(ctx context.Context, roomID string) (*domain.JoinRequests, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*domain.JoinRequests)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingByRoomID indicates an expected call of GetPendingByRoomID.
+func (mr *MockJoinRequestRepoMockRecorder) GetPendingByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingByRoomID", reflect.TypeOf((*MockJoinRequestRepo)(nil).GetPendingByRoomID), ctx, roomID)
+}
+
+// PendingExists mocks base method.
+func (m *MockJoinRequestRepo) PendingExists(ctx context.Context, roomID, userID string) (*bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PendingExists", ctx, roomID, userID)
+	ret0, _ := ret[0].(*bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PendingExists indicates an expected call of PendingExists.
+func (mr *MockJoinRequestRepoMockRecorder) PendingExists(ctx, roomID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PendingExists", reflect.TypeOf((*MockJoinRequestRepo)(nil).PendingExists), ctx, roomID, userID)
+}
+
+// UpdateStatus mocks base method.
+func (m *MockJoinRequestRepo) UpdateStatus(ctx context.Context, id, status string, now time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateStatus", ctx, id, status, now)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateStatus indicates an expected call of UpdateStatus.
+func (mr *MockJoinRequestRepoMockRecorder) UpdateStatus(ctx, id, status, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateStatus", reflect.TypeOf((*MockJoinRequestRepo)(nil).UpdateStatus), ctx, id, status, now)
+}
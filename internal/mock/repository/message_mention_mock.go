@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_mention_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message_mention_repository.go -destination=../mock/repository/message_mention_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageMentionRepo is a mock of MessageMentionRepo interface.
+type MockMessageMentionRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageMentionRepoMockRecorder
+}
+
+// MockMessageMentionRepoMockRecorder is the mock recorder for MockMessageMentionRepo.
+type MockMessageMentionRepoMockRecorder struct {
+	mock *MockMessageMentionRepo
+}
+
+// NewMockMessageMentionRepo creates a new mock instance.
+func NewMockMessageMentionRepo(ctrl *gomock.Controller) *MockMessageMentionRepo {
+	mock := &MockMessageMentionRepo{ctrl: ctrl}
+	mock.recorder = &MockMessageMentionRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageMentionRepo) EXPECT() *MockMessageMentionRepoMockRecorder {
+	return m.recorder
+}
+
+// CountUnreadByUserID mocks base method.
+func (m *MockMessageMentionRepo) CountUnreadByUserID(ctx context.Context, userID string) (*domain.MentionCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnreadByUserID", ctx, userID)
+	ret0, _ := ret[0].(*domain.MentionCounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnreadByUserID indicates an expected call of CountUnreadByUserID.
+func (mr *MockMessageMentionRepoMockRecorder) CountUnreadByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnreadByUserID", reflect.TypeOf((*MockMessageMentionRepo)(nil).CountUnreadByUserID), ctx, userID)
+}
+
+// MarkReadByUserIDAndRoomID mocks base method.
+func (m *MockMessageMentionRepo) MarkReadByUserIDAndRoomID(ctx context.Context, userID, roomID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkReadByUserIDAndRoomID", ctx, userID, roomID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkReadByUserIDAndRoomID indicates an expected call of MarkReadByUserIDAndRoomID.
+func (mr *MockMessageMentionRepoMockRecorder) MarkReadByUserIDAndRoomID(ctx, userID, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkReadByUserIDAndRoomID", reflect.TypeOf((*MockMessageMentionRepo)(nil).MarkReadByUserIDAndRoomID), ctx, userID, roomID)
+}
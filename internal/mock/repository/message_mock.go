@@ -0,0 +1,203 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message_repository.go -destination=../mock/repository/message_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageRepo is a mock of MessageRepo interface.
+type MockMessageRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageRepoMockRecorder
+}
+
+// MockMessageRepoMockRecorder is the mock recorder for MockMessageRepo.
+type MockMessageRepoMockRecorder struct {
+	mock *MockMessageRepo
+}
+
+// NewMockMessageRepo creates a new mock instance.
+func NewMockMessageRepo(ctrl *gomock.Controller) *MockMessageRepo {
+	mock := &MockMessageRepo{ctrl: ctrl}
+	mock.recorder = &MockMessageRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageRepo) EXPECT() *MockMessageRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockMessageRepo) Create(ctx context.Context, message *domain.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMessageRepoMockRecorder) Create(ctx, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMessageRepo)(nil).Create), ctx, message)
+}
+
+// DeleteByRoomID mocks base method.
+func (m *MockMessageRepo) DeleteByRoomID(ctx context.Context, roomID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByRoomID indicates an expected call of DeleteByRoomID.
+func (mr *MockMessageRepoMockRecorder) DeleteByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByRoomID", reflect.TypeOf((*MockMessageRepo)(nil).DeleteByRoomID), ctx, roomID)
+}
+
+// GetByClientID mocks base method.
+func (m *MockMessageRepo) GetByClientID(ctx context.Context, roomID, userID, clientID string) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByClientID", ctx, roomID, userID, clientID)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByClientID indicates an expected call of GetByClientID.
+func (mr *MockMessageRepoMockRecorder) GetByClientID(ctx, roomID, userID, clientID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByClientID", reflect.TypeOf((*MockMessageRepo)(nil).GetByClientID), ctx, roomID, userID, clientID)
+}
+
+// GetByID mocks base method.
+func (m *MockMessageRepo) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockMessageRepoMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockMessageRepo)(nil).GetByID), ctx, id)
+}
+
+// GetByRoomID mocks base method.
+func (m *MockMessageRepo) GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRoomID indicates an expected call of GetByRoomID.
+func (mr *MockMessageRepoMockRecorder) GetByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRoomID", reflect.TypeOf((*MockMessageRepo)(nil).GetByRoomID), ctx, roomID)
+}
+
+// GetHistory mocks base method.
+func (m *MockMessageRepo) GetHistory(ctx context.Context, roomID, userID, before string, limit int) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, roomID, userID, before, limit)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockMessageRepoMockRecorder) GetHistory(ctx, roomID, userID, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockMessageRepo)(nil).GetHistory), ctx, roomID, userID, before, limit)
+}
+
+// GetSinceSeq mocks base method.
+func (m *MockMessageRepo) GetSinceSeq(ctx context.Context, roomID, userID string, seq int64, limit int) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSinceSeq", ctx, roomID, userID, seq, limit)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSinceSeq indicates an expected call of GetSinceSeq.
+func (mr *MockMessageRepoMockRecorder) GetSinceSeq(ctx, roomID, userID, seq, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSinceSeq", reflect.TypeOf((*MockMessageRepo)(nil).GetSinceSeq), ctx, roomID, userID, seq, limit)
+}
+
+// GetThread mocks base method.
+func (m *MockMessageRepo) GetThread(ctx context.Context, roomID, parentID, before string, limit int) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThread", ctx, roomID, parentID, before, limit)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThread indicates an expected call of GetThread.
+func (mr *MockMessageRepoMockRecorder) GetThread(ctx, roomID, parentID, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThread", reflect.TypeOf((*MockMessageRepo)(nil).GetThread), ctx, roomID, parentID, before, limit)
+}
+
+// GetThreadSummaries mocks base method.
+func (m *MockMessageRepo) GetThreadSummaries(ctx context.Context, parentIDs []string) (*domain.ThreadSummaries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThreadSummaries", ctx, parentIDs)
+	ret0, _ := ret[0].(*domain.ThreadSummaries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThreadSummaries indicates an expected call of GetThreadSummaries.
+func (mr *MockMessageRepoMockRecorder) GetThreadSummaries(ctx, parentIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThreadSummaries", reflect.TypeOf((*MockMessageRepo)(nil).GetThreadSummaries), ctx, parentIDs)
+}
+
+// Search mocks base method.
+func (m *MockMessageRepo) Search(ctx context.Context, userID, query, before string, limit int) (*domain.MessageSearchResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, userID, query, before, limit)
+	ret0, _ := ret[0].(*domain.MessageSearchResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockMessageRepoMockRecorder) Search(ctx, userID, query, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockMessageRepo)(nil).Search), ctx, userID, query, before, limit)
+}
+
+// UpdateWithRevision mocks base method.
+func (m *MockMessageRepo) UpdateWithRevision(ctx context.Context, message *domain.Message, revision *domain.MessageRevision) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateWithRevision", ctx, message, revision)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateWithRevision indicates an expected call of UpdateWithRevision.
+func (mr *MockMessageRepoMockRecorder) UpdateWithRevision(ctx, message, revision any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateWithRevision", reflect.TypeOf((*MockMessageRepo)(nil).UpdateWithRevision), ctx, message, revision)
+}
@@ -0,0 +1,114 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_pin_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message_pin_repository.go -destination=../mock/repository/message_pin_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessagePinRepo is a mock of MessagePinRepo interface.
+type MockMessagePinRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessagePinRepoMockRecorder
+}
+
+// MockMessagePinRepoMockRecorder is the mock recorder for MockMessagePinRepo.
+type MockMessagePinRepoMockRecorder struct {
+	mock *MockMessagePinRepo
+}
+
+// NewMockMessagePinRepo creates a new mock instance.
+func NewMockMessagePinRepo(ctrl *gomock.Controller) *MockMessagePinRepo {
+	mock := &MockMessagePinRepo{ctrl: ctrl}
+	mock.recorder = &MockMessagePinRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessagePinRepo) EXPECT() *MockMessagePinRepoMockRecorder {
+	return m.recorder
+}
+
+// CountByRoomID mocks base method.
+func (m *MockMessagePinRepo) CountByRoomID(ctx context.Context, roomID string) (*int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByRoomID indicates an expected call of CountByRoomID.
+func (mr *MockMessagePinRepoMockRecorder) CountByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByRoomID", reflect.TypeOf((*MockMessagePinRepo)(nil).CountByRoomID), ctx, roomID)
+}
+
+// Create mocks base method.
+func (m *MockMessagePinRepo) Create(ctx context.Context, pin *domain.MessagePin) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, pin)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMessagePinRepoMockRecorder) Create(ctx, pin any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMessagePinRepo)(nil).Create), ctx, pin)
+}
+
+// Delete mocks base method.
+func (m *MockMessagePinRepo) Delete(ctx context.Context, roomID, messageID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, roomID, messageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMessagePinRepoMockRecorder) Delete(ctx, roomID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMessagePinRepo)(nil).Delete), ctx, roomID, messageID)
+}
+
+// Exists mocks base method.
+func (m *MockMessagePinRepo) Exists(ctx context.Context, messageID string) (*bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exists", ctx, messageID)
+	ret0, _ := ret[0].(*bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exists indicates an expected call of Exists.
+func (mr *MockMessagePinRepoMockRecorder) Exists(ctx, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockMessagePinRepo)(nil).Exists), ctx, messageID)
+}
+
+// GetByRoomID mocks base method.
+func (m *MockMessagePinRepo) GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRoomID indicates an expected call of GetByRoomID.
+func (mr *MockMessagePinRepoMockRecorder) GetByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRoomID", reflect.TypeOf((*MockMessagePinRepo)(nil).GetByRoomID), ctx, roomID)
+}
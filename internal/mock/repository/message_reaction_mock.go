@@ -0,0 +1,99 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_reaction_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message_reaction_repository.go -destination=../mock/repository/message_reaction_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageReactionRepo is a mock of MessageReactionRepo interface.
+type MockMessageReactionRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageReactionRepoMockRecorder
+}
+
+// MockMessageReactionRepoMockRecorder is the mock recorder for MockMessageReactionRepo.
+type MockMessageReactionRepoMockRecorder struct {
+	mock *MockMessageReactionRepo
+}
+
+// NewMockMessageReactionRepo creates a new mock instance.
+func NewMockMessageReactionRepo(ctrl *gomock.Controller) *MockMessageReactionRepo {
+	mock := &MockMessageReactionRepo{ctrl: ctrl}
+	mock.recorder = &MockMessageReactionRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageReactionRepo) EXPECT() *MockMessageReactionRepoMockRecorder {
+	return m.recorder
+}
+
+// CountByMessageIDs mocks base method.
+func (m *MockMessageReactionRepo) CountByMessageIDs(ctx context.Context, messageIDs []string) (*domain.ReactionCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountByMessageIDs", ctx, messageIDs)
+	ret0, _ := ret[0].(*domain.ReactionCounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountByMessageIDs indicates an expected call of CountByMessageIDs.
+func (mr *MockMessageReactionRepoMockRecorder) CountByMessageIDs(ctx, messageIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountByMessageIDs", reflect.TypeOf((*MockMessageReactionRepo)(nil).CountByMessageIDs), ctx, messageIDs)
+}
+
+// Create mocks base method.
+func (m *MockMessageReactionRepo) Create(ctx context.Context, reaction *domain.MessageReaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMessageReactionRepoMockRecorder) Create(ctx, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMessageReactionRepo)(nil).Create), ctx, reaction)
+}
+
+// Delete mocks base method.
+func (m *MockMessageReactionRepo) Delete(ctx context.Context, reaction *domain.MessageReaction) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, reaction)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMessageReactionRepoMockRecorder) Delete(ctx, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMessageReactionRepo)(nil).Delete), ctx, reaction)
+}
+
+// Exists mocks base method.
+func (m *MockMessageReactionRepo) Exists(ctx context.Context, reaction *domain.MessageReaction) (*bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Exists", ctx, reaction)
+	ret0, _ := ret[0].(*bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Exists indicates an expected call of Exists.
+func (mr *MockMessageReactionRepoMockRecorder) Exists(ctx, reaction any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Exists", reflect.TypeOf((*MockMessageReactionRepo)(nil).Exists), ctx, reaction)
+}
@@ -96,6 +96,21 @@ func (mr *MockParticipatingRoomRepoMockRecorder) DeleteByUserIDAndRoomID(ctx, us
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUserIDAndRoomID", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).DeleteByUserIDAndRoomID), ctx, userID, roomID)
 }
 
+// GetActivitiesByUserID mocks base method.
+func (m *MockParticipatingRoomRepo) GetActivitiesByUserID(ctx context.Context, userID string) (*domain.RoomActivities, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActivitiesByUserID", ctx, userID)
+	ret0, _ := ret[0].(*domain.RoomActivities)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActivitiesByUserID indicates an expected call of GetActivitiesByUserID.
+func (mr *MockParticipatingRoomRepoMockRecorder) GetActivitiesByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivitiesByUserID", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).GetActivitiesByUserID), ctx, userID)
+}
+
 // GetAll mocks base method.
 func (m *MockParticipatingRoomRepo) GetAll(ctx context.Context) (*domain.ParticipatingRooms, error) {
 	m.ctrl.T.Helper()
@@ -156,6 +171,36 @@ func (mr *MockParticipatingRoomRepoMockRecorder) GetByUserIDAndRoomID(ctx, userI
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserIDAndRoomID", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).GetByUserIDAndRoomID), ctx, userID, roomID)
 }
 
+// GetDirectPeersByUserID mocks base method.
+func (m *MockParticipatingRoomRepo) GetDirectPeersByUserID(ctx context.Context, userID string) (*domain.DirectPeers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDirectPeersByUserID", ctx, userID)
+	ret0, _ := ret[0].(*domain.DirectPeers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDirectPeersByUserID indicates an expected call of GetDirectPeersByUserID.
+func (mr *MockParticipatingRoomRepoMockRecorder) GetDirectPeersByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDirectPeersByUserID", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).GetDirectPeersByUserID), ctx, userID)
+}
+
+// GetMembersByRoomID mocks base method.
+func (m *MockParticipatingRoomRepo) GetMembersByRoomID(ctx context.Context, roomID string) (*domain.ParticipatingRooms, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembersByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*domain.ParticipatingRooms)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembersByRoomID indicates an expected call of GetMembersByRoomID.
+func (mr *MockParticipatingRoomRepoMockRecorder) GetMembersByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembersByRoomID", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).GetMembersByRoomID), ctx, roomID)
+}
+
 // GetUsersByRoomID mocks base method.
 func (m *MockParticipatingRoomRepo) GetUsersByRoomID(ctx context.Context, roomID string) (*domain.Users, error) {
 	m.ctrl.T.Helper()
@@ -170,3 +215,45 @@ func (mr *MockParticipatingRoomRepoMockRecorder) GetUsersByRoomID(ctx, roomID an
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByRoomID", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).GetUsersByRoomID), ctx, roomID)
 }
+
+// TransferOwnership mocks base method.
+func (m *MockParticipatingRoomRepo) TransferOwnership(ctx context.Context, roomID, ownerID, successorID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferOwnership", ctx, roomID, ownerID, successorID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// TransferOwnership indicates an expected call of TransferOwnership.
+func (mr *MockParticipatingRoomRepoMockRecorder) TransferOwnership(ctx, roomID, ownerID, successorID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferOwnership", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).TransferOwnership), ctx, roomID, ownerID, successorID)
+}
+
+// UpdateLastReadSeq mocks base method.
+func (m *MockParticipatingRoomRepo) UpdateLastReadSeq(ctx context.Context, userID, roomID string, seq int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastReadSeq", ctx, userID, roomID, seq)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastReadSeq indicates an expected call of UpdateLastReadSeq.
+func (mr *MockParticipatingRoomRepoMockRecorder) UpdateLastReadSeq(ctx, userID, roomID, seq any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastReadSeq", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).UpdateLastReadSeq), ctx, userID, roomID, seq)
+}
+
+// UpdateRole mocks base method.
+func (m *MockParticipatingRoomRepo) UpdateRole(ctx context.Context, userID, roomID, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateRole", ctx, userID, roomID, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateRole indicates an expected call of UpdateRole.
+func (mr *MockParticipatingRoomRepoMockRecorder) UpdateRole(ctx, userID, roomID, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateRole", reflect.TypeOf((*MockParticipatingRoomRepo)(nil).UpdateRole), ctx, userID, roomID, role)
+}
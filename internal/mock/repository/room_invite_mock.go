@@ -0,0 +1,115 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: room_invite_repository.go
+//
+// Generated by this command:
+//
+//	mockgen -source=room_invite_repository.go -destination=../mock/repository/room_invite_mock.go -package=mock_repository
+//
+
+// Package mock_repository is a generated GoMock package.
+package mock_repository
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoomInviteRepo is a mock of RoomInviteRepo interface.
+type MockRoomInviteRepo struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoomInviteRepoMockRecorder
+}
+
+// MockRoomInviteRepoMockRecorder is the mock recorder for MockRoomInviteRepo.
+type MockRoomInviteRepoMockRecorder struct {
+	mock *MockRoomInviteRepo
+}
+
+// NewMockRoomInviteRepo creates a new mock instance.
+func NewMockRoomInviteRepo(ctrl *gomock.Controller) *MockRoomInviteRepo {
+	mock := &MockRoomInviteRepo{ctrl: ctrl}
+	mock.recorder = &MockRoomInviteRepoMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoomInviteRepo) EXPECT() *MockRoomInviteRepoMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRoomInviteRepo) Create(ctx context.Context, invite *domain.RoomInvite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, invite)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRoomInviteRepoMockRecorder) Create(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRoomInviteRepo)(nil).Create), ctx, invite)
+}
+
+// Delete mocks base method.
+func (m *MockRoomInviteRepo) Delete(ctx context.Context, roomID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, roomID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRoomInviteRepoMockRecorder) Delete(ctx, roomID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRoomInviteRepo)(nil).Delete), ctx, roomID, id)
+}
+
+// GetActiveByRoomID mocks base method.
+func (m *MockRoomInviteRepo) GetActiveByRoomID(ctx context.Context, roomID string, now time.Time) (*domain.RoomInvites, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveByRoomID", ctx, roomID, now)
+	ret0, _ := ret[0].(*domain.RoomInvites)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveByRoomID indicates an expected call of GetActiveByRoomID.
+func (mr *MockRoomInviteRepoMockRecorder) GetActiveByRoomID(ctx, roomID, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveByRoomID", reflect.TypeOf((*MockRoomInviteRepo)(nil).GetActiveByRoomID), ctx, roomID, now)
+}
+
+// GetByID mocks base method.
+func (m *MockRoomInviteRepo) GetByID(ctx context.Context, id string) (*domain.RoomInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.RoomInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockRoomInviteRepoMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockRoomInviteRepo)(nil).GetByID), ctx, id)
+}
+
+// IncrementUses mocks base method.
+func (m *MockRoomInviteRepo) IncrementUses(ctx context.Context, id string, now time.Time) (*bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "IncrementUses", ctx, id, now)
+	ret0, _ := ret[0].(*bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// IncrementUses indicates an expected call of IncrementUses.
+func (mr *MockRoomInviteRepoMockRecorder) IncrementUses(ctx, id, now any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IncrementUses", reflect.TypeOf((*MockRoomInviteRepo)(nil).IncrementUses), ctx, id, now)
+}
@@ -55,6 +55,21 @@ func (mr *MockRoomRepoMockRecorder) Create(ctx, room any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRoomRepo)(nil).Create), ctx, room)
 }
 
+// CreateDirect mocks base method.
+func (m *MockRoomRepo) CreateDirect(ctx context.Context, room *domain.Room, userIDs []string) (*domain.Room, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateDirect", ctx, room, userIDs)
+	ret0, _ := ret[0].(*domain.Room)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateDirect indicates an expected call of CreateDirect.
+func (mr *MockRoomRepoMockRecorder) CreateDirect(ctx, room, userIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateDirect", reflect.TypeOf((*MockRoomRepo)(nil).CreateDirect), ctx, room, userIDs)
+}
+
 // Delete mocks base method.
 func (m *MockRoomRepo) Delete(ctx context.Context, id string) error {
 	m.ctrl.T.Helper()
@@ -84,6 +99,66 @@ func (mr *MockRoomRepoMockRecorder) GetAll(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRoomRepo)(nil).GetAll), ctx)
 }
 
+// GetByDirectKey mocks base method.
+func (m *MockRoomRepo) GetByDirectKey(ctx context.Context, directKey string) (*domain.Room, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByDirectKey", ctx, directKey)
+	ret0, _ := ret[0].(*domain.Room)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByDirectKey indicates an expected call of GetByDirectKey.
+func (mr *MockRoomRepoMockRecorder) GetByDirectKey(ctx, directKey any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByDirectKey", reflect.TypeOf((*MockRoomRepo)(nil).GetByDirectKey), ctx, directKey)
+}
+
+// GetByID mocks base method.
+func (m *MockRoomRepo) GetByID(ctx context.Context, id string) (*domain.Room, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Room)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockRoomRepoMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockRoomRepo)(nil).GetByID), ctx, id)
+}
+
+// GetByIDs mocks base method.
+func (m *MockRoomRepo) GetByIDs(ctx context.Context, ids []string) (*domain.Rooms, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].(*domain.Rooms)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockRoomRepoMockRecorder) GetByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockRoomRepo)(nil).GetByIDs), ctx, ids)
+}
+
+// GetBySlug mocks base method.
+func (m *MockRoomRepo) GetBySlug(ctx context.Context, slug string) (*domain.Room, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBySlug", ctx, slug)
+	ret0, _ := ret[0].(*domain.Room)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBySlug indicates an expected call of GetBySlug.
+func (mr *MockRoomRepoMockRecorder) GetBySlug(ctx, slug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBySlug", reflect.TypeOf((*MockRoomRepo)(nil).GetBySlug), ctx, slug)
+}
+
 // IDExists mocks base method.
 func (m *MockRoomRepo) IDExists(ctx context.Context, id string) (*bool, error) {
 	m.ctrl.T.Helper()
@@ -98,3 +173,17 @@ func (mr *MockRoomRepoMockRecorder) IDExists(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IDExists", reflect.TypeOf((*MockRoomRepo)(nil).IDExists), ctx, id)
 }
+
+// Update mocks base method.
+func (m *MockRoomRepo) Update(ctx context.Context, room *domain.Room) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, room)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRoomRepoMockRecorder) Update(ctx, room any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRoomRepo)(nil).Update), ctx, room)
+}
@@ -0,0 +1,86 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: join_request_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=join_request_usecase.go -destination=../mock/usecase/join_request_mock.go -package=mock_usecase
+//
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockJoinRequestUsecase is a mock of JoinRequestUsecase interface.
+type MockJoinRequestUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockJoinRequestUsecaseMockRecorder
+}
+
+// MockJoinRequestUsecaseMockRecorder is the mock recorder for MockJoinRequestUsecase.
+type MockJoinRequestUsecaseMockRecorder struct {
+	mock *MockJoinRequestUsecase
+}
+
+// NewMockJoinRequestUsecase creates a new mock instance.
+func NewMockJoinRequestUsecase(ctrl *gomock.Controller) *MockJoinRequestUsecase {
+	mock := &MockJoinRequestUsecase{ctrl: ctrl}
+	mock.recorder = &MockJoinRequestUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockJoinRequestUsecase) EXPECT() *MockJoinRequestUsecaseMockRecorder {
+	return m.recorder
+}
+
+// Decide mocks base method.
+func (m *MockJoinRequestUsecase) Decide(ctx context.Context, roomID, id string, approve bool) (*domain.JoinRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Decide", ctx, roomID, id, approve)
+	ret0, _ := ret[0].(*domain.JoinRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Decide indicates an expected call of Decide.
+func (mr *MockJoinRequestUsecaseMockRecorder) Decide(ctx, roomID, id, approve any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Decide", reflect.TypeOf((*MockJoinRequestUsecase)(nil).Decide), ctx, roomID, id, approve)
+}
+
+// GetPendingByRoomID mocks base method.
+func (m *MockJoinRequestUsecase) GetPendingByRoomID(ctx context.Context, roomID string) (*domain.JoinRequests, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPendingByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*domain.JoinRequests)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPendingByRoomID indicates an expected call of GetPendingByRoomID.
+func (mr *MockJoinRequestUsecaseMockRecorder) GetPendingByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPendingByRoomID", reflect.TypeOf((*MockJoinRequestUsecase)(nil).GetPendingByRoomID), ctx, roomID)
+}
+
+// Request mocks base method.
+func (m *MockJoinRequestUsecase) Request(ctx context.Context, room *domain.Room, userID, userName string) (*domain.JoinRequest, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Request", ctx, room, userID, userName)
+	ret0, _ := ret[0].(*domain.JoinRequest)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Request indicates an expected call of Request.
+func (mr *MockJoinRequestUsecaseMockRecorder) Request(ctx, room, userID, userName any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Request", reflect.TypeOf((*MockJoinRequestUsecase)(nil).Request), ctx, room, userID, userName)
+}
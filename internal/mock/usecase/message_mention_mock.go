@@ -0,0 +1,70 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_mention_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message_mention_usecase.go -destination=../mock/usecase/message_mention_mock.go -package=mock_usecase
+//
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageMentionUsecase is a mock of MessageMentionUsecase interface.
+type MockMessageMentionUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageMentionUsecaseMockRecorder
+}
+
+// MockMessageMentionUsecaseMockRecorder is the mock recorder for MockMessageMentionUsecase.
+type MockMessageMentionUsecaseMockRecorder struct {
+	mock *MockMessageMentionUsecase
+}
+
+// NewMockMessageMentionUsecase creates a new mock instance.
+func NewMockMessageMentionUsecase(ctrl *gomock.Controller) *MockMessageMentionUsecase {
+	mock := &MockMessageMentionUsecase{ctrl: ctrl}
+	mock.recorder = &MockMessageMentionUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageMentionUsecase) EXPECT() *MockMessageMentionUsecaseMockRecorder {
+	return m.recorder
+}
+
+// CountUnreadByUserID mocks base method.
+func (m *MockMessageMentionUsecase) CountUnreadByUserID(ctx context.Context, userID string) (*domain.MentionCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CountUnreadByUserID", ctx, userID)
+	ret0, _ := ret[0].(*domain.MentionCounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CountUnreadByUserID indicates an expected call of CountUnreadByUserID.
+func (mr *MockMessageMentionUsecaseMockRecorder) CountUnreadByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CountUnreadByUserID", reflect.TypeOf((*MockMessageMentionUsecase)(nil).CountUnreadByUserID), ctx, userID)
+}
+
+// MarkReadByUserIDAndRoomID mocks base method.
+func (m *MockMessageMentionUsecase) MarkReadByUserIDAndRoomID(ctx context.Context, userID, roomID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkReadByUserIDAndRoomID", ctx, userID, roomID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkReadByUserIDAndRoomID indicates an expected call of MarkReadByUserIDAndRoomID.
+func (mr *MockMessageMentionUsecaseMockRecorder) MarkReadByUserIDAndRoomID(ctx, userID, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkReadByUserIDAndRoomID", reflect.TypeOf((*MockMessageMentionUsecase)(nil).MarkReadByUserIDAndRoomID), ctx, userID, roomID)
+}
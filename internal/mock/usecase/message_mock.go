@@ -0,0 +1,205 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message_usecase.go -destination=../mock/usecase/message_mock.go -package=mock_usecase
+//
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageUsecase is a mock of MessageUsecase interface.
+type MockMessageUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageUsecaseMockRecorder
+}
+
+// MockMessageUsecaseMockRecorder is the mock recorder for MockMessageUsecase.
+type MockMessageUsecaseMockRecorder struct {
+	mock *MockMessageUsecase
+}
+
+// NewMockMessageUsecase creates a new mock instance.
+func NewMockMessageUsecase(ctrl *gomock.Controller) *MockMessageUsecase {
+	mock := &MockMessageUsecase{ctrl: ctrl}
+	mock.recorder = &MockMessageUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageUsecase) EXPECT() *MockMessageUsecaseMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockMessageUsecase) Create(ctx context.Context, message *domain.Message) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockMessageUsecaseMockRecorder) Create(ctx, message any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockMessageUsecase)(nil).Create), ctx, message)
+}
+
+// Delete mocks base method.
+func (m *MockMessageUsecase) Delete(ctx context.Context, roomID, id, editorID string, moderator bool) (*domain.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, roomID, id, editorID, moderator)
+	ret0, _ := ret[0].(*domain.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockMessageUsecaseMockRecorder) Delete(ctx, roomID, id, editorID, moderator any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockMessageUsecase)(nil).Delete), ctx, roomID, id, editorID, moderator)
+}
+
+// DeleteByRoomID mocks base method.
+func (m *MockMessageUsecase) DeleteByRoomID(ctx context.Context, roomID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteByRoomID indicates an expected call of DeleteByRoomID.
+func (mr *MockMessageUsecaseMockRecorder) DeleteByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByRoomID", reflect.TypeOf((*MockMessageUsecase)(nil).DeleteByRoomID), ctx, roomID)
+}
+
+// Edit mocks base method.
+func (m *MockMessageUsecase) Edit(ctx context.Context, roomID, id, editorID string, moderator bool, markdown string, participants domain.Users) (*domain.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Edit", ctx, roomID, id, editorID, moderator, markdown, participants)
+	ret0, _ := ret[0].(*domain.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Edit indicates an expected call of Edit.
+func (mr *MockMessageUsecaseMockRecorder) Edit(ctx, roomID, id, editorID, moderator, markdown, participants any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Edit", reflect.TypeOf((*MockMessageUsecase)(nil).Edit), ctx, roomID, id, editorID, moderator, markdown, participants)
+}
+
+// GetByID mocks base method.
+func (m *MockMessageUsecase) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockMessageUsecaseMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockMessageUsecase)(nil).GetByID), ctx, id)
+}
+
+// GetByRoomID mocks base method.
+func (m *MockMessageUsecase) GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRoomID indicates an expected call of GetByRoomID.
+func (mr *MockMessageUsecaseMockRecorder) GetByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRoomID", reflect.TypeOf((*MockMessageUsecase)(nil).GetByRoomID), ctx, roomID)
+}
+
+// GetHistory mocks base method.
+func (m *MockMessageUsecase) GetHistory(ctx context.Context, roomID, userID, before string, limit int) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHistory", ctx, roomID, userID, before, limit)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHistory indicates an expected call of GetHistory.
+func (mr *MockMessageUsecaseMockRecorder) GetHistory(ctx, roomID, userID, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHistory", reflect.TypeOf((*MockMessageUsecase)(nil).GetHistory), ctx, roomID, userID, before, limit)
+}
+
+// GetSinceSeq mocks base method.
+func (m *MockMessageUsecase) GetSinceSeq(ctx context.Context, roomID, userID string, seq int64, limit int) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSinceSeq", ctx, roomID, userID, seq, limit)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetSinceSeq indicates an expected call of GetSinceSeq.
+func (mr *MockMessageUsecaseMockRecorder) GetSinceSeq(ctx, roomID, userID, seq, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSinceSeq", reflect.TypeOf((*MockMessageUsecase)(nil).GetSinceSeq), ctx, roomID, userID, seq, limit)
+}
+
+// GetThread mocks base method.
+func (m *MockMessageUsecase) GetThread(ctx context.Context, roomID, parentID, before string, limit int) (*domain.Message, *domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThread", ctx, roomID, parentID, before, limit)
+	ret0, _ := ret[0].(*domain.Message)
+	ret1, _ := ret[1].(*domain.Messages)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetThread indicates an expected call of GetThread.
+func (mr *MockMessageUsecaseMockRecorder) GetThread(ctx, roomID, parentID, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThread", reflect.TypeOf((*MockMessageUsecase)(nil).GetThread), ctx, roomID, parentID, before, limit)
+}
+
+// GetThreadSummaries mocks base method.
+func (m *MockMessageUsecase) GetThreadSummaries(ctx context.Context, parentIDs []string) (*domain.ThreadSummaries, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetThreadSummaries", ctx, parentIDs)
+	ret0, _ := ret[0].(*domain.ThreadSummaries)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetThreadSummaries indicates an expected call of GetThreadSummaries.
+func (mr *MockMessageUsecaseMockRecorder) GetThreadSummaries(ctx, parentIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetThreadSummaries", reflect.TypeOf((*MockMessageUsecase)(nil).GetThreadSummaries), ctx, parentIDs)
+}
+
+// Search mocks base method.
+func (m *MockMessageUsecase) Search(ctx context.Context, userID, query, before string, limit int) (*domain.MessageSearchResults, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", ctx, userID, query, before, limit)
+	ret0, _ := ret[0].(*domain.MessageSearchResults)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockMessageUsecaseMockRecorder) Search(ctx, userID, query, before, limit any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockMessageUsecase)(nil).Search), ctx, userID, query, before, limit)
+}
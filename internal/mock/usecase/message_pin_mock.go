@@ -0,0 +1,85 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_pin_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message_pin_usecase.go -destination=../mock/usecase/message_pin_mock.go -package=mock_usecase
+//
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessagePinUsecase is a mock of MessagePinUsecase interface.
+type MockMessagePinUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessagePinUsecaseMockRecorder
+}
+
+// MockMessagePinUsecaseMockRecorder is the mock recorder for MockMessagePinUsecase.
+type MockMessagePinUsecaseMockRecorder struct {
+	mock *MockMessagePinUsecase
+}
+
+// NewMockMessagePinUsecase creates a new mock instance.
+func NewMockMessagePinUsecase(ctrl *gomock.Controller) *MockMessagePinUsecase {
+	mock := &MockMessagePinUsecase{ctrl: ctrl}
+	mock.recorder = &MockMessagePinUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessagePinUsecase) EXPECT() *MockMessagePinUsecaseMockRecorder {
+	return m.recorder
+}
+
+// GetByRoomID mocks base method.
+func (m *MockMessagePinUsecase) GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*domain.Messages)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByRoomID indicates an expected call of GetByRoomID.
+func (mr *MockMessagePinUsecaseMockRecorder) GetByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByRoomID", reflect.TypeOf((*MockMessagePinUsecase)(nil).GetByRoomID), ctx, roomID)
+}
+
+// Pin mocks base method.
+func (m *MockMessagePinUsecase) Pin(ctx context.Context, roomID, messageID, userID string) (*domain.Message, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Pin", ctx, roomID, messageID, userID)
+	ret0, _ := ret[0].(*domain.Message)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Pin indicates an expected call of Pin.
+func (mr *MockMessagePinUsecaseMockRecorder) Pin(ctx, roomID, messageID, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Pin", reflect.TypeOf((*MockMessagePinUsecase)(nil).Pin), ctx, roomID, messageID, userID)
+}
+
+// Unpin mocks base method.
+func (m *MockMessagePinUsecase) Unpin(ctx context.Context, roomID, messageID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Unpin", ctx, roomID, messageID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Unpin indicates an expected call of Unpin.
+func (mr *MockMessagePinUsecaseMockRecorder) Unpin(ctx, roomID, messageID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Unpin", reflect.TypeOf((*MockMessagePinUsecase)(nil).Unpin), ctx, roomID, messageID)
+}
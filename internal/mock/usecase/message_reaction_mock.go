@@ -0,0 +1,72 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: message_reaction_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=message_reaction_usecase.go -destination=../mock/usecase/message_reaction_mock.go -package=mock_usecase
+//
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockMessageReactionUsecase is a mock of MessageReactionUsecase interface.
+type MockMessageReactionUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockMessageReactionUsecaseMockRecorder
+}
+
+// MockMessageReactionUsecaseMockRecorder is the mock recorder for MockMessageReactionUsecase.
+type MockMessageReactionUsecaseMockRecorder struct {
+	mock *MockMessageReactionUsecase
+}
+
+// NewMockMessageReactionUsecase creates a new mock instance.
+func NewMockMessageReactionUsecase(ctrl *gomock.Controller) *MockMessageReactionUsecase {
+	mock := &MockMessageReactionUsecase{ctrl: ctrl}
+	mock.recorder = &MockMessageReactionUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockMessageReactionUsecase) EXPECT() *MockMessageReactionUsecaseMockRecorder {
+	return m.recorder
+}
+
+// GetCounts mocks base method.
+func (m *MockMessageReactionUsecase) GetCounts(ctx context.Context, messageIDs []string) (*domain.ReactionCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCounts", ctx, messageIDs)
+	ret0, _ := ret[0].(*domain.ReactionCounts)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCounts indicates an expected call of GetCounts.
+func (mr *MockMessageReactionUsecaseMockRecorder) GetCounts(ctx, messageIDs any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCounts", reflect.TypeOf((*MockMessageReactionUsecase)(nil).GetCounts), ctx, messageIDs)
+}
+
+// Toggle mocks base method.
+func (m *MockMessageReactionUsecase) Toggle(ctx context.Context, roomID, messageID, userID, emoji string) (*domain.Message, *domain.ReactionCounts, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Toggle", ctx, roomID, messageID, userID, emoji)
+	ret0, _ := ret[0].(*domain.Message)
+	ret1, _ := ret[1].(*domain.ReactionCounts)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Toggle indicates an expected call of Toggle.
+func (mr *MockMessageReactionUsecaseMockRecorder) Toggle(ctx, roomID, messageID, userID, emoji any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Toggle", reflect.TypeOf((*MockMessageReactionUsecase)(nil).Toggle), ctx, roomID, messageID, userID, emoji)
+}
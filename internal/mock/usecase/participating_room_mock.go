@@ -96,6 +96,21 @@ func (mr *MockParticipatingRoomUsecaseMockRecorder) DeleteByUserIDAndRoomID(ctx,
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteByUserIDAndRoomID", reflect.TypeOf((*MockParticipatingRoomUsecase)(nil).DeleteByUserIDAndRoomID), ctx, userID, roomID)
 }
 
+// GetActivitiesByUserID mocks base method.
+func (m *MockParticipatingRoomUsecase) GetActivitiesByUserID(ctx context.Context, userID string) (*domain.RoomActivities, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActivitiesByUserID", ctx, userID)
+	ret0, _ := ret[0].(*domain.RoomActivities)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActivitiesByUserID indicates an expected call of GetActivitiesByUserID.
+func (mr *MockParticipatingRoomUsecaseMockRecorder) GetActivitiesByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActivitiesByUserID", reflect.TypeOf((*MockParticipatingRoomUsecase)(nil).GetActivitiesByUserID), ctx, userID)
+}
+
 // GetAll mocks base method.
 func (m *MockParticipatingRoomUsecase) GetAll(ctx context.Context) (*domain.ParticipatingRooms, error) {
 	m.ctrl.T.Helper()
@@ -156,6 +171,21 @@ func (mr *MockParticipatingRoomUsecaseMockRecorder) GetByUserIDAndRoomID(ctx, us
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByUserIDAndRoomID", reflect.TypeOf((*MockParticipatingRoomUsecase)(nil).GetByUserIDAndRoomID), ctx, userID, roomID)
 }
 
+// GetDirectPeersByUserID mocks base method.
+func (m *MockParticipatingRoomUsecase) GetDirectPeersByUserID(ctx context.Context, userID string) (*domain.DirectPeers, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDirectPeersByUserID", ctx, userID)
+	ret0, _ := ret[0].(*domain.DirectPeers)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDirectPeersByUserID indicates an expected call of GetDirectPeersByUserID.
+func (mr *MockParticipatingRoomUsecaseMockRecorder) GetDirectPeersByUserID(ctx, userID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDirectPeersByUserID", reflect.TypeOf((*MockParticipatingRoomUsecase)(nil).GetDirectPeersByUserID), ctx, userID)
+}
+
 // GetUsersByRoomID mocks base method.
 func (m *MockParticipatingRoomUsecase) GetUsersByRoomID(ctx context.Context, roomID string) (*domain.Users, error) {
 	m.ctrl.T.Helper()
@@ -170,3 +200,17 @@ func (mr *MockParticipatingRoomUsecaseMockRecorder) GetUsersByRoomID(ctx, roomID
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetUsersByRoomID", reflect.TypeOf((*MockParticipatingRoomUsecase)(nil).GetUsersByRoomID), ctx, roomID)
 }
+
+// UpdateLastReadSeq mocks base method.
+func (m *MockParticipatingRoomUsecase) UpdateLastReadSeq(ctx context.Context, userID, roomID string, seq int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "UpdateLastReadSeq", ctx, userID, roomID, seq)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// UpdateLastReadSeq indicates an expected call of UpdateLastReadSeq.
+func (mr *MockParticipatingRoomUsecaseMockRecorder) UpdateLastReadSeq(ctx, userID, roomID, seq any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "UpdateLastReadSeq", reflect.TypeOf((*MockParticipatingRoomUsecase)(nil).UpdateLastReadSeq), ctx, userID, roomID, seq)
+}
@@ -0,0 +1,129 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: room_invite_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=room_invite_usecase.go -destination=../mock/usecase/room_invite_mock.go -package=mock_usecase
+//
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoomInviteUsecase is a mock of RoomInviteUsecase interface.
+type MockRoomInviteUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoomInviteUsecaseMockRecorder
+}
+
+// MockRoomInviteUsecaseMockRecorder is the mock recorder for MockRoomInviteUsecase.
+type MockRoomInviteUsecaseMockRecorder struct {
+	mock *MockRoomInviteUsecase
+}
+
+// NewMockRoomInviteUsecase creates a new mock instance.
+func NewMockRoomInviteUsecase(ctrl *gomock.Controller) *MockRoomInviteUsecase {
+	mock := &MockRoomInviteUsecase{ctrl: ctrl}
+	mock.recorder = &MockRoomInviteUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoomInviteUsecase) EXPECT() *MockRoomInviteUsecaseMockRecorder {
+	return m.recorder
+}
+
+// Create mocks base method.
+func (m *MockRoomInviteUsecase) Create(ctx context.Context, roomID, userID string, ttl time.Duration, maxUses int) (*domain.RoomInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Create", ctx, roomID, userID, ttl, maxUses)
+	ret0, _ := ret[0].(*domain.RoomInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Create indicates an expected call of Create.
+func (mr *MockRoomInviteUsecaseMockRecorder) Create(ctx, roomID, userID, ttl, maxUses any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Create", reflect.TypeOf((*MockRoomInviteUsecase)(nil).Create), ctx, roomID, userID, ttl, maxUses)
+}
+
+// Delete mocks base method.
+func (m *MockRoomInviteUsecase) Delete(ctx context.Context, roomID, id string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Delete", ctx, roomID, id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Delete indicates an expected call of Delete.
+func (mr *MockRoomInviteUsecaseMockRecorder) Delete(ctx, roomID, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRoomInviteUsecase)(nil).Delete), ctx, roomID, id)
+}
+
+// GetActiveByRoomID mocks base method.
+func (m *MockRoomInviteUsecase) GetActiveByRoomID(ctx context.Context, roomID string) (*domain.RoomInvites, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetActiveByRoomID", ctx, roomID)
+	ret0, _ := ret[0].(*domain.RoomInvites)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetActiveByRoomID indicates an expected call of GetActiveByRoomID.
+func (mr *MockRoomInviteUsecaseMockRecorder) GetActiveByRoomID(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetActiveByRoomID", reflect.TypeOf((*MockRoomInviteUsecase)(nil).GetActiveByRoomID), ctx, roomID)
+}
+
+// Token mocks base method.
+func (m *MockRoomInviteUsecase) Token(invite *domain.RoomInvite) string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Token", invite)
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// Token indicates an expected call of Token.
+func (mr *MockRoomInviteUsecaseMockRecorder) Token(invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockRoomInviteUsecase)(nil).Token), invite)
+}
+
+// Use mocks base method.
+func (m *MockRoomInviteUsecase) Use(ctx context.Context, invite *domain.RoomInvite) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Use", ctx, invite)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Use indicates an expected call of Use.
+func (mr *MockRoomInviteUsecaseMockRecorder) Use(ctx, invite any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Use", reflect.TypeOf((*MockRoomInviteUsecase)(nil).Use), ctx, invite)
+}
+
+// Verify mocks base method.
+func (m *MockRoomInviteUsecase) Verify(ctx context.Context, token string) (*domain.RoomInvite, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Verify", ctx, token)
+	ret0, _ := ret[0].(*domain.RoomInvite)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Verify indicates an expected call of Verify.
+func (mr *MockRoomInviteUsecaseMockRecorder) Verify(ctx, token any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Verify", reflect.TypeOf((*MockRoomInviteUsecase)(nil).Verify), ctx, token)
+}
@@ -84,6 +84,51 @@ func (mr *MockRoomUsecaseMockRecorder) GetAll(ctx any) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRoomUsecase)(nil).GetAll), ctx)
 }
 
+// GetByID mocks base method.
+func (m *MockRoomUsecase) GetByID(ctx context.Context, id string) (*domain.Room, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByID", ctx, id)
+	ret0, _ := ret[0].(*domain.Room)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByID indicates an expected call of GetByID.
+func (mr *MockRoomUsecaseMockRecorder) GetByID(ctx, id any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockRoomUsecase)(nil).GetByID), ctx, id)
+}
+
+// GetByIDs mocks base method.
+func (m *MockRoomUsecase) GetByIDs(ctx context.Context, ids []string) (*domain.Rooms, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByIDs", ctx, ids)
+	ret0, _ := ret[0].(*domain.Rooms)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByIDs indicates an expected call of GetByIDs.
+func (mr *MockRoomUsecaseMockRecorder) GetByIDs(ctx, ids any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDs", reflect.TypeOf((*MockRoomUsecase)(nil).GetByIDs), ctx, ids)
+}
+
+// GetOrCreateDirect mocks base method.
+func (m *MockRoomUsecase) GetOrCreateDirect(ctx context.Context, userID, peerID string) (*domain.Room, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetOrCreateDirect", ctx, userID, peerID)
+	ret0, _ := ret[0].(*domain.Room)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetOrCreateDirect indicates an expected call of GetOrCreateDirect.
+func (mr *MockRoomUsecaseMockRecorder) GetOrCreateDirect(ctx, userID, peerID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetOrCreateDirect", reflect.TypeOf((*MockRoomUsecase)(nil).GetOrCreateDirect), ctx, userID, peerID)
+}
+
 // IDExists mocks base method.
 func (m *MockRoomUsecase) IDExists(ctx context.Context, id string) (*bool, error) {
 	m.ctrl.T.Helper()
@@ -98,3 +143,32 @@ func (mr *MockRoomUsecaseMockRecorder) IDExists(ctx, id any) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "IDExists", reflect.TypeOf((*MockRoomUsecase)(nil).IDExists), ctx, id)
 }
+
+// Resolve mocks base method.
+func (m *MockRoomUsecase) Resolve(ctx context.Context, idOrSlug string) (*domain.Room, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Resolve", ctx, idOrSlug)
+	ret0, _ := ret[0].(*domain.Room)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Resolve indicates an expected call of Resolve.
+func (mr *MockRoomUsecaseMockRecorder) Resolve(ctx, idOrSlug any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Resolve", reflect.TypeOf((*MockRoomUsecase)(nil).Resolve), ctx, idOrSlug)
+}
+
+// Update mocks base method.
+func (m *MockRoomUsecase) Update(ctx context.Context, room *domain.Room) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Update", ctx, room)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Update indicates an expected call of Update.
+func (mr *MockRoomUsecaseMockRecorder) Update(ctx, room any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRoomUsecase)(nil).Update), ctx, room)
+}
@@ -0,0 +1,130 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: room_role_usecase.go
+//
+// Generated by this command:
+//
+//	mockgen -source=room_role_usecase.go -destination=../mock/usecase/room_role_mock.go -package=mock_usecase
+//
+
+// Package mock_usecase is a generated GoMock package.
+package mock_usecase
+
+import (
+	context "context"
+	reflect "reflect"
+
+	domain "github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	gomock "go.uber.org/mock/gomock"
+)
+
+// MockRoomRoleUsecase is a mock of RoomRoleUsecase interface.
+type MockRoomRoleUsecase struct {
+	ctrl     *gomock.Controller
+	recorder *MockRoomRoleUsecaseMockRecorder
+}
+
+// MockRoomRoleUsecaseMockRecorder is the mock recorder for MockRoomRoleUsecase.
+type MockRoomRoleUsecaseMockRecorder struct {
+	mock *MockRoomRoleUsecase
+}
+
+// NewMockRoomRoleUsecase creates a new mock instance.
+func NewMockRoomRoleUsecase(ctrl *gomock.Controller) *MockRoomRoleUsecase {
+	mock := &MockRoomRoleUsecase{ctrl: ctrl}
+	mock.recorder = &MockRoomRoleUsecaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockRoomRoleUsecase) EXPECT() *MockRoomRoleUsecaseMockRecorder {
+	return m.recorder
+}
+
+// Authorize mocks base method.
+func (m *MockRoomRoleUsecase) Authorize(ctx context.Context, userID, roomID string, perm domain.Permission) (*domain.ParticipatingRoom, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Authorize", ctx, userID, roomID, perm)
+	ret0, _ := ret[0].(*domain.ParticipatingRoom)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Authorize indicates an expected call of Authorize.
+func (mr *MockRoomRoleUsecaseMockRecorder) Authorize(ctx, userID, roomID, perm any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Authorize", reflect.TypeOf((*MockRoomRoleUsecase)(nil).Authorize), ctx, userID, roomID, perm)
+}
+
+// ChangeRole mocks base method.
+func (m *MockRoomRoleUsecase) ChangeRole(ctx context.Context, actorID, roomID, targetID, role string) (*domain.ParticipatingRoom, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ChangeRole", ctx, actorID, roomID, targetID, role)
+	ret0, _ := ret[0].(*domain.ParticipatingRoom)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ChangeRole indicates an expected call of ChangeRole.
+func (mr *MockRoomRoleUsecaseMockRecorder) ChangeRole(ctx, actorID, roomID, targetID, role any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ChangeRole", reflect.TypeOf((*MockRoomRoleUsecase)(nil).ChangeRole), ctx, actorID, roomID, targetID, role)
+}
+
+// GetMembers mocks base method.
+func (m *MockRoomRoleUsecase) GetMembers(ctx context.Context, roomID string) (*domain.ParticipatingRooms, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMembers", ctx, roomID)
+	ret0, _ := ret[0].(*domain.ParticipatingRooms)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMembers indicates an expected call of GetMembers.
+func (mr *MockRoomRoleUsecaseMockRecorder) GetMembers(ctx, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMembers", reflect.TypeOf((*MockRoomRoleUsecase)(nil).GetMembers), ctx, roomID)
+}
+
+// Kick mocks base method.
+func (m *MockRoomRoleUsecase) Kick(ctx context.Context, actorID, roomID, targetID string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Kick", ctx, actorID, roomID, targetID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Kick indicates an expected call of Kick.
+func (mr *MockRoomRoleUsecaseMockRecorder) Kick(ctx, actorID, roomID, targetID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Kick", reflect.TypeOf((*MockRoomRoleUsecase)(nil).Kick), ctx, actorID, roomID, targetID)
+}
+
+// Succeed mocks base method.
+func (m *MockRoomRoleUsecase) Succeed(ctx context.Context, ownerID, roomID string) (*domain.ParticipatingRoom, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Succeed", ctx, ownerID, roomID)
+	ret0, _ := ret[0].(*domain.ParticipatingRoom)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Succeed indicates an expected call of Succeed.
+func (mr *MockRoomRoleUsecaseMockRecorder) Succeed(ctx, ownerID, roomID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Succeed", reflect.TypeOf((*MockRoomRoleUsecase)(nil).Succeed), ctx, ownerID, roomID)
+}
+
+// TransferOwnership mocks base method.
+func (m *MockRoomRoleUsecase) TransferOwnership(ctx context.Context, ownerID, roomID, targetID string) (*domain.ParticipatingRoom, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TransferOwnership", ctx, ownerID, roomID, targetID)
+	ret0, _ := ret[0].(*domain.ParticipatingRoom)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TransferOwnership indicates an expected call of TransferOwnership.
+func (mr *MockRoomRoleUsecaseMockRecorder) TransferOwnership(ctx, ownerID, roomID, targetID any) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TransferOwnership", reflect.TypeOf((*MockRoomRoleUsecase)(nil).TransferOwnership), ctx, ownerID, roomID, targetID)
+}
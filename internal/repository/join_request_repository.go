@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/join_request_mock.go -package=mock_$GOPACKAGE
+
+type JoinRequestRepo interface {
+	GetByID(ctx context.Context, id string) (*domain.JoinRequest, error)
+	GetPendingByRoomID(ctx context.Context, roomID string) (*domain.JoinRequests, error)
+	PendingExists(ctx context.Context, roomID, userID string) (*bool, error)
+	Create(ctx context.Context, request *domain.JoinRequest) error
+	UpdateStatus(ctx context.Context, id, status string, now time.Time) error
+}
+
+type joinRequestRepo struct {
+	*postgres.Postgres
+}
+
+func NewJoinRequestRepo(pg *postgres.Postgres) JoinRequestRepo {
+	return &joinRequestRepo{pg}
+}
+
+func (r *joinRequestRepo) GetByID(ctx context.Context, id string) (*domain.JoinRequest, error) {
+	var request domain.JoinRequest
+	err := r.Db.WithContext(ctx).Where("id = ?", id).First(&request).Error
+	return &request, err
+}
+
+// Roomの承認待ちの参加リクエストを、送信された順に取得
+func (r *joinRequestRepo) GetPendingByRoomID(ctx context.Context, roomID string) (*domain.JoinRequests, error) {
+	var requests domain.JoinRequests
+	err := r.Db.WithContext(ctx).Where("room_id = ? AND status = ?", roomID, domain.JoinRequestPending).Order("created_at").Find(&requests).Error
+	return &requests, err
+}
+
+// ユーザーのRoomへの承認待ちの参加リクエストがあるかどうか
+func (r *joinRequestRepo) PendingExists(ctx context.Context, roomID, userID string) (*bool, error) {
+	var exists bool
+	err := r.Db.WithContext(ctx).Model(&domain.JoinRequest{}).Select("count(*) > 0").Where("room_id = ? AND user_id = ? AND status = ?", roomID, userID, domain.JoinRequestPending).Find(&exists).Error
+	return &exists, err
+}
+
+func (r *joinRequestRepo) Create(ctx context.Context, request *domain.JoinRequest) error {
+	return r.Db.WithContext(ctx).Create(request).Error
+}
+
+// 承認待ちの参加リクエストを承認または却下する。承認待ちでなければgorm.ErrRecordNotFoundを返す
+func (r *joinRequestRepo) UpdateStatus(ctx context.Context, id, status string, now time.Time) error {
+	result := r.Db.WithContext(ctx).Model(&domain.JoinRequest{}).
+		Where("id = ? AND status = ?", id, domain.JoinRequestPending).
+		Updates(map[string]interface{}{"status": status, "updated_at": now})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,35 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/message_mention_mock.go -package=mock_$GOPACKAGE
+
+type MessageMentionRepo interface {
+	CountUnreadByUserID(ctx context.Context, userID string) (*domain.MentionCounts, error)
+	MarkReadByUserIDAndRoomID(ctx context.Context, userID, roomID string) error
+}
+
+type messageMentionRepo struct {
+	*postgres.Postgres
+}
+
+func NewMessageMentionRepo(pg *postgres.Postgres) MessageMentionRepo {
+	return &messageMentionRepo{pg}
+}
+
+// ユーザーの未読のメンションの数をRoomごとに取得
+func (r *messageMentionRepo) CountUnreadByUserID(ctx context.Context, userID string) (*domain.MentionCounts, error) {
+	var counts domain.MentionCounts
+	err := r.Db.WithContext(ctx).Model(&domain.MessageMention{}).Select("room_id, count(*) AS count").Where("user_id = ? AND NOT read", userID).Group("room_id").Find(&counts).Error
+	return &counts, err
+}
+
+// ユーザーのRoomでのメンションをすべて既読にする
+func (r *messageMentionRepo) MarkReadByUserIDAndRoomID(ctx context.Context, userID, roomID string) error {
+	return r.Db.WithContext(ctx).Model(&domain.MessageMention{}).Where("user_id = ? AND room_id = ? AND NOT read", userID, roomID).Update("read", true).Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/message_pin_mock.go -package=mock_$GOPACKAGE
+
+type MessagePinRepo interface {
+	GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error)
+	CountByRoomID(ctx context.Context, roomID string) (*int64, error)
+	Exists(ctx context.Context, messageID string) (*bool, error)
+	Create(ctx context.Context, pin *domain.MessagePin) error
+	Delete(ctx context.Context, roomID, messageID string) error
+}
+
+type messagePinRepo struct {
+	*postgres.Postgres
+}
+
+func NewMessagePinRepo(pg *postgres.Postgres) MessagePinRepo {
+	return &messagePinRepo{pg}
+}
+
+// Roomにピン留めされている削除されていないメッセージを、ピン留めした順に取得
+func (r *messagePinRepo) GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error) {
+	var messages domain.Messages
+	err := r.Db.WithContext(ctx).Joins("JOIN message_pins ON message_pins.message_id = messages.id").Where("message_pins.room_id = ? AND NOT messages.deleted", roomID).Order("message_pins.created_at").Find(&messages).Error
+	return &messages, err
+}
+
+// Roomにピン留めされている削除されていないメッセージの数
+func (r *messagePinRepo) CountByRoomID(ctx context.Context, roomID string) (*int64, error) {
+	var count int64
+	err := r.Db.WithContext(ctx).Model(&domain.MessagePin{}).Joins("JOIN messages ON messages.id = message_pins.message_id").Where("message_pins.room_id = ? AND NOT messages.deleted", roomID).Count(&count).Error
+	return &count, err
+}
+
+// メッセージがピン留めされているかどうか
+func (r *messagePinRepo) Exists(ctx context.Context, messageID string) (*bool, error) {
+	var exists bool
+	err := r.Db.WithContext(ctx).Model(&domain.MessagePin{}).Select("count(*) > 0").Where("message_id = ?", messageID).Find(&exists).Error
+	return &exists, err
+}
+
+func (r *messagePinRepo) Create(ctx context.Context, pin *domain.MessagePin) error {
+	return r.Db.WithContext(ctx).Create(pin).Error
+}
+
+// ピン留めを外す。ピン留めされていなければgorm.ErrRecordNotFoundを返す
+func (r *messagePinRepo) Delete(ctx context.Context, roomID, messageID string) error {
+	result := r.Db.WithContext(ctx).Where("room_id = ? AND message_id = ?", roomID, messageID).Delete(&domain.MessagePin{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
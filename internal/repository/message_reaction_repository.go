@@ -0,0 +1,47 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/message_reaction_mock.go -package=mock_$GOPACKAGE
+
+type MessageReactionRepo interface {
+	Exists(ctx context.Context, reaction *domain.MessageReaction) (*bool, error)
+	CountByMessageIDs(ctx context.Context, messageIDs []string) (*domain.ReactionCounts, error)
+	Create(ctx context.Context, reaction *domain.MessageReaction) error
+	Delete(ctx context.Context, reaction *domain.MessageReaction) error
+}
+
+type messageReactionRepo struct {
+	*postgres.Postgres
+}
+
+func NewMessageReactionRepo(pg *postgres.Postgres) MessageReactionRepo {
+	return &messageReactionRepo{pg}
+}
+
+// ユーザーがメッセージに同じ絵文字を付けているかどうか
+func (r *messageReactionRepo) Exists(ctx context.Context, reaction *domain.MessageReaction) (*bool, error) {
+	var exists bool
+	err := r.Db.WithContext(ctx).Model(&domain.MessageReaction{}).Select("count(*) > 0").Where("message_id = ? AND user_id = ? AND emoji = ?", reaction.MessageID, reaction.UserID, reaction.Emoji).Find(&exists).Error
+	return &exists, err
+}
+
+// メッセージごと絵文字ごとのリアクションの数を、最初に付けられた順に取得
+func (r *messageReactionRepo) CountByMessageIDs(ctx context.Context, messageIDs []string) (*domain.ReactionCounts, error) {
+	var counts domain.ReactionCounts
+	err := r.Db.WithContext(ctx).Model(&domain.MessageReaction{}).Select("message_id, emoji, count(*) AS count").Where("message_id IN ?", messageIDs).Group("message_id, emoji").Order("min(created_at)").Find(&counts).Error
+	return &counts, err
+}
+
+func (r *messageReactionRepo) Create(ctx context.Context, reaction *domain.MessageReaction) error {
+	return r.Db.WithContext(ctx).Create(reaction).Error
+}
+
+func (r *messageReactionRepo) Delete(ctx context.Context, reaction *domain.MessageReaction) error {
+	return r.Db.WithContext(ctx).Where("message_id = ? AND user_id = ? AND emoji = ?", reaction.MessageID, reaction.UserID, reaction.Emoji).Delete(&domain.MessageReaction{}).Error
+}
@@ -0,0 +1,227 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/message_mock.go -package=mock_$GOPACKAGE
+
+type MessageRepo interface {
+	GetByID(ctx context.Context, id string) (*domain.Message, error)
+	GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error)
+	GetHistory(ctx context.Context, roomID, userID, before string, limit int) (*domain.Messages, error)
+	GetByClientID(ctx context.Context, roomID, userID, clientID string) (*domain.Messages, error)
+	GetSinceSeq(ctx context.Context, roomID, userID string, seq int64, limit int) (*domain.Messages, error)
+	GetThread(ctx context.Context, roomID, parentID, before string, limit int) (*domain.Messages, error)
+	GetThreadSummaries(ctx context.Context, parentIDs []string) (*domain.ThreadSummaries, error)
+	Search(ctx context.Context, userID, query, before string, limit int) (*domain.MessageSearchResults, error)
+	Create(ctx context.Context, message *domain.Message) error
+	UpdateWithRevision(ctx context.Context, message *domain.Message, revision *domain.MessageRevision) error
+	DeleteByRoomID(ctx context.Context, roomID string) error
+}
+
+type messageRepo struct {
+	*postgres.Postgres
+}
+
+func NewMessageRepo(pg *postgres.Postgres) MessageRepo {
+	return &messageRepo{pg}
+}
+
+func (r *messageRepo) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	var message domain.Message
+	err := r.Db.WithContext(ctx).Where("id = ?", id).First(&message).Error
+	return &message, err
+}
+
+func (r *messageRepo) GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error) {
+	var messages domain.Messages
+	err := r.Db.WithContext(ctx).Where("room_id = ?", roomID).Order("id").Find(&messages).Error
+	return &messages, err
+}
+
+// userが閲覧できるRoomのタイムラインのメッセージをbeforeより前から新しい順にlimit件取得
+// スレッドへの返信はRoomにも送信されたもののみ含める
+func (r *messageRepo) GetHistory(ctx context.Context, roomID, userID, before string, limit int) (*domain.Messages, error) {
+	var messages domain.Messages
+	db := r.Db.WithContext(ctx).Where("room_id = ?", roomID).Where("to_name = '' OR user_id = ? OR to_user_id = ?", userID, userID).Where("parent_id = '' OR also_to_room")
+	if before != "" {
+		db = db.Where("id < ?", before)
+	}
+	err := db.Order("id desc").Limit(limit).Find(&messages).Error
+	return &messages, err
+}
+
+// userがRoomへclientIDを付けて送信したメッセージを取得
+func (r *messageRepo) GetByClientID(ctx context.Context, roomID, userID, clientID string) (*domain.Messages, error) {
+	var messages domain.Messages
+	err := r.Db.WithContext(ctx).Where("room_id = ? AND user_id = ? AND client_id = ?", roomID, userID, clientID).Order("id").Limit(1).Find(&messages).Error
+	return &messages, err
+}
+
+// userが閲覧できるメッセージのうちseqより後のものを古い順にlimit件取得
+func (r *messageRepo) GetSinceSeq(ctx context.Context, roomID, userID string, seq int64, limit int) (*domain.Messages, error) {
+	var messages domain.Messages
+	err := r.Db.WithContext(ctx).Where("room_id = ? AND seq > ?", roomID, seq).Where("to_name = '' OR user_id = ? OR to_user_id = ?", userID, userID).Order("seq").Limit(limit).Find(&messages).Error
+	return &messages, err
+}
+
+// スレッドへの返信をbeforeより前から新しい順にlimit件取得
+func (r *messageRepo) GetThread(ctx context.Context, roomID, parentID, before string, limit int) (*domain.Messages, error) {
+	var messages domain.Messages
+	db := r.Db.WithContext(ctx).Where("room_id = ? AND parent_id = ?", roomID, parentID)
+	if before != "" {
+		db = db.Where("id < ?", before)
+	}
+	err := db.Order("id desc").Limit(limit).Find(&messages).Error
+	return &messages, err
+}
+
+// スレッドごとの返信数、最後の返信日時、返信したユーザーを取得
+func (r *messageRepo) GetThreadSummaries(ctx context.Context, parentIDs []string) (*domain.ThreadSummaries, error) {
+	var counts []struct {
+		ParentID    string
+		ReplyCount  int64
+		LastReplyAt time.Time
+	}
+	err := r.Db.WithContext(ctx).Model(&domain.Message{}).Select("parent_id, count(*) AS reply_count, max(created_at) AS last_reply_at").Where("parent_id IN ?", parentIDs).Group("parent_id").Order("parent_id").Find(&counts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []struct {
+		ParentID string
+		UserName string
+	}
+	err = r.Db.WithContext(ctx).Model(&domain.Message{}).Distinct("parent_id", "user_name").Where("parent_id IN ?", parentIDs).Order("parent_id, user_name").Find(&participants).Error
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string][]string)
+	for _, p := range participants {
+		names[p.ParentID] = append(names[p.ParentID], p.UserName)
+	}
+
+	summaries := make(domain.ThreadSummaries, 0, len(counts))
+	for _, c := range counts {
+		summaries = append(summaries, domain.ThreadSummary{ParentID: c.ParentID, ReplyCount: c.ReplyCount, LastReplyAt: c.LastReplyAt, Participants: names[c.ParentID]})
+	}
+	return &summaries, nil
+}
+
+// 検索結果のスニペットの作り方
+var searchHeadlineOptions = "StartSel=" + domain.SearchHighlightStart + ", StopSel=" + domain.SearchHighlightStop + ", MaxWords=20, MinWords=5, MaxFragments=2"
+
+// userが参加しているRoomの、userが閲覧できる削除されていないメッセージを全文検索し、新しい順にlimit件取得
+// beforeが指定された場合はそのIDより前のメッセージを検索する
+func (r *messageRepo) Search(ctx context.Context, userID, query, before string, limit int) (*domain.MessageSearchResults, error) {
+	var results domain.MessageSearchResults
+	db := r.Db.WithContext(ctx).Table("messages").
+		Select("messages.id, messages.room_id, messages.user_name, messages.to_name, messages.parent_id, messages.created_at, ts_headline('simple', messages.markdown, q.query, ?) AS snippet", searchHeadlineOptions).
+		Joins("CROSS JOIN websearch_to_tsquery('simple', ?) AS q(query)", query).
+		Where("messages.search_vector @@ q.query").
+		Where("messages.room_id IN (?)", r.Db.Model(&domain.ParticipatingRoom{}).Select("room_id").Where("user_id = ?", userID)).
+		Where("NOT messages.deleted").
+		Where("messages.to_name = '' OR messages.user_id = ? OR messages.to_user_id = ?", userID, userID)
+	if before != "" {
+		db = db.Where("messages.id < ?", before)
+	}
+	err := db.Order("messages.id desc").Limit(limit).Scan(&results).Error
+	return &results, err
+}
+
+// Roomのseqを進めてメッセージに割り当て、保存する
+func (r *messageRepo) Create(ctx context.Context, message *domain.Message) error {
+	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		message.Seq = 0
+		err := tx.Raw("UPDATE rooms SET last_seq = last_seq + 1 WHERE id = ? RETURNING last_seq", message.RoomID).Scan(&message.Seq).Error
+		if err != nil {
+			return err
+		}
+		if message.Seq == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		// 同じRoomに同じユーザーが同じclientidで送信したメッセージは一意インデックスで重複とする
+		err = tx.Create(message).Error
+		if errors.Is(err, gorm.ErrDuplicatedKey) {
+			return domain.ErrDuplicateMessage
+		}
+		if err != nil {
+			return err
+		}
+
+		return createMentions(tx, message)
+	})
+}
+
+// 変更前の内容を保存してからメッセージを更新する
+func (r *messageRepo) UpdateWithRevision(ctx context.Context, message *domain.Message, revision *domain.MessageRevision) error {
+	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(revision).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Model(&domain.Message{}).Where("id = ?", message.ID).Updates(map[string]any{
+			"markdown":   message.Markdown,
+			"html":       message.HTML,
+			"edited":     message.Edited,
+			"deleted":    message.Deleted,
+			"updated_at": message.UpdatedAt,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return createMentions(tx, message)
+	})
+}
+
+// メッセージのメンションを保存する。既に保存されているメンションはそのままにする
+func createMentions(tx *gorm.DB, message *domain.Message) error {
+	if len(message.Mentions) == 0 {
+		return nil
+	}
+
+	for i := range message.Mentions {
+		message.Mentions[i].MessageID = message.ID
+		message.Mentions[i].RoomID = message.RoomID
+		message.Mentions[i].CreatedAt = message.UpdatedAt
+	}
+	return tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&message.Mentions).Error
+}
+
+func (r *messageRepo) DeleteByRoomID(ctx context.Context, roomID string) error {
+	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("room_id = ?", roomID).Delete(&domain.MessageRevision{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("room_id = ?", roomID).Delete(&domain.MessageReaction{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("room_id = ?", roomID).Delete(&domain.MessageMention{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("room_id = ?", roomID).Delete(&domain.MessagePin{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("room_id = ?", roomID).Delete(&domain.Message{}).Error
+	})
+}
@@ -2,9 +2,11 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
+	"gorm.io/gorm"
 )
 
 //go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/participating_room_mock.go -package=mock_$GOPACKAGE
@@ -19,6 +21,12 @@ type ParticipatingRoomRepo interface {
 	DeleteByRoomID(ctx context.Context, roomID string) error
 	DeleteByUserIDAndRoomID(ctx context.Context, userID, roomID string) error
 	GetUsersByRoomID(ctx context.Context, roomID string) (*domain.Users, error)
+	GetActivitiesByUserID(ctx context.Context, userID string) (*domain.RoomActivities, error)
+	UpdateLastReadSeq(ctx context.Context, userID, roomID string, seq int64) error
+	GetDirectPeersByUserID(ctx context.Context, userID string) (*domain.DirectPeers, error)
+	GetMembersByRoomID(ctx context.Context, roomID string) (*domain.ParticipatingRooms, error)
+	UpdateRole(ctx context.Context, userID, roomID, role string) error
+	TransferOwnership(ctx context.Context, roomID, ownerID, successorID string) error
 }
 
 type participatingRoomRepo struct {
@@ -79,3 +87,85 @@ func (r *participatingRoomRepo) GetUsersByRoomID(ctx context.Context, roomID str
 	}
 	return &users, err
 }
+
+// 参加中のRoomごとに、userが閲覧できるRoomのタイムラインのメッセージから未読数と最後の発言日時を集計する
+func (r *participatingRoomRepo) GetActivitiesByUserID(ctx context.Context, userID string) (*domain.RoomActivities, error) {
+	var activities domain.RoomActivities
+	err := r.Db.WithContext(ctx).Raw(`SELECT participating_rooms.room_id,
+		COUNT(messages.id) FILTER (WHERE messages.seq > participating_rooms.last_read_seq AND messages.user_id <> ? AND NOT messages.deleted) AS unread_count,
+		MAX(messages.created_at) AS last_activity_at
+		FROM participating_rooms
+		LEFT JOIN messages ON messages.room_id = participating_rooms.room_id
+			AND (messages.parent_id = '' OR messages.also_to_room)
+			AND (messages.to_name = '' OR messages.user_id = ? OR messages.to_user_id = ?)
+		WHERE participating_rooms.user_id = ?
+		GROUP BY participating_rooms.room_id`, userID, userID, userID, userID).Scan(&activities).Error
+	return &activities, err
+}
+
+// 既読のseqを進める。Roomの最新のseqより先には進めず、戻すこともしない
+func (r *participatingRoomRepo) UpdateLastReadSeq(ctx context.Context, userID, roomID string, seq int64) error {
+	return r.Db.WithContext(ctx).Exec(`UPDATE participating_rooms SET last_read_seq = LEAST(?, rooms.last_seq), updated_at = ?
+		FROM rooms
+		WHERE rooms.id = participating_rooms.room_id AND participating_rooms.user_id = ? AND participating_rooms.room_id = ? AND participating_rooms.last_read_seq < LEAST(?, rooms.last_seq)`,
+		seq, time.Now(), userID, roomID, seq).Error
+}
+
+// userが参加しているダイレクトメッセージのRoomと、その相手のユーザーを取得
+func (r *participatingRoomRepo) GetDirectPeersByUserID(ctx context.Context, userID string) (*domain.DirectPeers, error) {
+	var peers domain.DirectPeers
+	err := r.Db.WithContext(ctx).Raw(`SELECT own.room_id, users.id AS user_id, users.name AS user_name
+		FROM participating_rooms AS own
+		JOIN rooms ON rooms.id = own.room_id AND rooms.kind = ?
+		JOIN participating_rooms AS peer ON peer.room_id = own.room_id AND peer.user_id <> own.user_id
+		JOIN users ON users.id = peer.user_id
+		WHERE own.user_id = ?
+		ORDER BY own.room_id`, domain.RoomKindDirect, userID).Scan(&peers).Error
+	return &peers, err
+}
+
+// Roomの参加者をユーザー情報と一緒に参加順で取得
+func (r *participatingRoomRepo) GetMembersByRoomID(ctx context.Context, roomID string) (*domain.ParticipatingRooms, error) {
+	var participatingRooms domain.ParticipatingRooms
+	err := r.Db.WithContext(ctx).Where("room_id = ?", roomID).Preload("User").Order("id").Find(&participatingRooms).Error
+	return &participatingRooms, err
+}
+
+// 参加者の役割を変更する。参加していなければErrRecordNotFound
+func (r *participatingRoomRepo) UpdateRole(ctx context.Context, userID, roomID, role string) error {
+	result := r.Db.WithContext(ctx).Model(&domain.ParticipatingRoom{}).Where("user_id = ?", userID).Where("room_id = ?", roomID).
+		Updates(map[string]interface{}{"role": role, "updated_at": time.Now()})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// 作成者の役割をsuccessorに譲り、元の作成者は管理者にする
+// ownerが作成者でないか、successorが参加していなければErrRecordNotFound
+func (r *participatingRoomRepo) TransferOwnership(ctx context.Context, roomID, ownerID, successorID string) error {
+	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		result := tx.Model(&domain.ParticipatingRoom{}).Where("user_id = ?", ownerID).Where("room_id = ?", roomID).Where("role = ?", domain.RoleOwner).
+			Updates(map[string]interface{}{"role": domain.RoleAdmin, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		result = tx.Model(&domain.ParticipatingRoom{}).Where("user_id = ?", successorID).Where("room_id = ?", roomID).
+			Updates(map[string]interface{}{"role": domain.RoleOwner, "updated_at": now})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
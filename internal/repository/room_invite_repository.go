@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/room_invite_mock.go -package=mock_$GOPACKAGE
+
+type RoomInviteRepo interface {
+	GetByID(ctx context.Context, id string) (*domain.RoomInvite, error)
+	GetActiveByRoomID(ctx context.Context, roomID string, now time.Time) (*domain.RoomInvites, error)
+	Create(ctx context.Context, invite *domain.RoomInvite) error
+	IncrementUses(ctx context.Context, id string, now time.Time) (*bool, error)
+	Delete(ctx context.Context, roomID, id string) error
+}
+
+type roomInviteRepo struct {
+	*postgres.Postgres
+}
+
+func NewRoomInviteRepo(pg *postgres.Postgres) RoomInviteRepo {
+	return &roomInviteRepo{pg}
+}
+
+func (r *roomInviteRepo) GetByID(ctx context.Context, id string) (*domain.RoomInvite, error) {
+	var invite domain.RoomInvite
+	err := r.Db.WithContext(ctx).Where("id = ?", id).First(&invite).Error
+	return &invite, err
+}
+
+// Roomの有効期限内で使用回数が残っている招待リンクを、作成した順に取得
+func (r *roomInviteRepo) GetActiveByRoomID(ctx context.Context, roomID string, now time.Time) (*domain.RoomInvites, error) {
+	var invites domain.RoomInvites
+	err := r.Db.WithContext(ctx).Where("room_id = ? AND expires_at > ? AND (max_uses = 0 OR uses < max_uses)", roomID, now).Order("created_at").Find(&invites).Error
+	return &invites, err
+}
+
+func (r *roomInviteRepo) Create(ctx context.Context, invite *domain.RoomInvite) error {
+	return r.Db.WithContext(ctx).Create(invite).Error
+}
+
+// 招待リンクが使える場合のみ使用回数を1増やす。同時に使われても上限を超えないよう1つのUPDATEで確認する
+func (r *roomInviteRepo) IncrementUses(ctx context.Context, id string, now time.Time) (*bool, error) {
+	result := r.Db.WithContext(ctx).Model(&domain.RoomInvite{}).
+		Where("id = ? AND expires_at > ? AND (max_uses = 0 OR uses < max_uses)", id, now).
+		Update("uses", gorm.Expr("uses + 1"))
+	used := result.RowsAffected > 0
+	return &used, result.Error
+}
+
+// 招待リンクを無効にする。存在しなければgorm.ErrRecordNotFoundを返す
+func (r *roomInviteRepo) Delete(ctx context.Context, roomID, id string) error {
+	result := r.Db.WithContext(ctx).Where("room_id = ? AND id = ?", roomID, id).Delete(&domain.RoomInvite{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
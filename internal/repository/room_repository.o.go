@@ -5,15 +5,22 @@ import (
 
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
 	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/postgres"
+	"gorm.io/gorm"
 )
 
 //go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/room_mock.go -package=mock_$GOPACKAGE
 
 type RoomRepo interface {
 	GetAll(ctx context.Context) (*domain.Rooms, error)
+	GetByID(ctx context.Context, id string) (*domain.Room, error)
+	GetBySlug(ctx context.Context, slug string) (*domain.Room, error)
+	GetByIDs(ctx context.Context, ids []string) (*domain.Rooms, error)
 	Create(ctx context.Context, room *domain.Room) (*domain.Room, error)
+	Update(ctx context.Context, room *domain.Room) error
 	Delete(ctx context.Context, id string) error
 	IDExists(ctx context.Context, id string) (*bool, error)
+	GetByDirectKey(ctx context.Context, directKey string) (*domain.Room, error)
+	CreateDirect(ctx context.Context, room *domain.Room, userIDs []string) (*domain.Room, error)
 }
 
 type roomRepo struct {
@@ -30,13 +37,51 @@ func (r *roomRepo) GetAll(ctx context.Context) (*domain.Rooms, error) {
 	return &rooms, err
 }
 
+func (r *roomRepo) GetByID(ctx context.Context, id string) (*domain.Room, error) {
+	var room domain.Room
+	err := r.Db.WithContext(ctx).Where("id = ?", id).First(&room).Error
+	return &room, err
+}
+
+func (r *roomRepo) GetBySlug(ctx context.Context, slug string) (*domain.Room, error) {
+	var room domain.Room
+	err := r.Db.WithContext(ctx).Where("slug = ?", slug).First(&room).Error
+	return &room, err
+}
+
+func (r *roomRepo) GetByIDs(ctx context.Context, ids []string) (*domain.Rooms, error) {
+	var rooms domain.Rooms
+	err := r.Db.WithContext(ctx).Where("id IN ?", ids).Find(&rooms).Error
+	return &rooms, err
+}
+
 func (r *roomRepo) Create(ctx context.Context, room *domain.Room) (*domain.Room, error) {
 	err := r.Db.WithContext(ctx).Create(room).Error
 	return room, err
 }
 
+// Roomの名前、トピック、説明、スラッグ、公開範囲を更新する
+func (r *roomRepo) Update(ctx context.Context, room *domain.Room) error {
+	return r.Db.WithContext(ctx).Model(&domain.Room{}).Where("id = ?", room.ID).
+		Select("slug", "name", "topic", "description", "visibility", "updated_at").
+		Updates(room).Error
+}
+
+// Roomと、Roomの招待リンク、参加リクエストを削除する
 func (r *roomRepo) Delete(ctx context.Context, id string) error {
-	return r.Db.WithContext(ctx).Where("id = ?", id).Delete(&domain.Room{}).Error
+	return r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Where("room_id = ?", id).Delete(&domain.RoomInvite{}).Error
+		if err != nil {
+			return err
+		}
+
+		err = tx.Where("room_id = ?", id).Delete(&domain.JoinRequest{}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("id = ?", id).Delete(&domain.Room{}).Error
+	})
 }
 
 func (r *roomRepo) IDExists(ctx context.Context, id string) (*bool, error) {
@@ -44,3 +89,28 @@ func (r *roomRepo) IDExists(ctx context.Context, id string) (*bool, error) {
 	err := r.Db.Model(&domain.Room{}).Select("count(*) > 0").Where("id = ?", id).Find(&exists).Error
 	return &exists, err
 }
+
+func (r *roomRepo) GetByDirectKey(ctx context.Context, directKey string) (*domain.Room, error) {
+	var room domain.Room
+	err := r.Db.WithContext(ctx).Where("direct_key = ?", directKey).First(&room).Error
+	return &room, err
+}
+
+// ダイレクトメッセージのRoomと、2人の参加情報をまとめて保存する
+func (r *roomRepo) CreateDirect(ctx context.Context, room *domain.Room, userIDs []string) (*domain.Room, error) {
+	err := r.Db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Create(room).Error
+		if err != nil {
+			return err
+		}
+
+		for _, userID := range userIDs {
+			err = tx.Create(&domain.ParticipatingRoom{RoomID: room.ID, UserID: userID, Role: domain.RoleMember}).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	return room, err
+}
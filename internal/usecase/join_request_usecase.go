@@ -0,0 +1,96 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/repository"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/ulid"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/join_request_mock.go -package=mock_$GOPACKAGE
+
+type JoinRequestUsecase interface {
+	GetPendingByRoomID(ctx context.Context, roomID string) (*domain.JoinRequests, error)
+	Request(ctx context.Context, room *domain.Room, userID, userName string) (*domain.JoinRequest, error)
+	Decide(ctx context.Context, roomID, id string, approve bool) (*domain.JoinRequest, error)
+}
+
+type joinRequestUsecase struct {
+	repo repository.JoinRequestRepo
+}
+
+func NewJoinRequestUsecase(repo repository.JoinRequestRepo) JoinRequestUsecase {
+	return &joinRequestUsecase{repo: repo}
+}
+
+func (u *joinRequestUsecase) GetPendingByRoomID(ctx context.Context, roomID string) (*domain.JoinRequests, error) {
+	return u.repo.GetPendingByRoomID(ctx, roomID)
+}
+
+// 参加承認制のRoomに参加リクエストを送信する。承認待ちのリクエストは1ユーザー1件まで
+func (u *joinRequestUsecase) Request(ctx context.Context, room *domain.Room, userID, userName string) (*domain.JoinRequest, error) {
+	if room.IsDirect() || room.Visibility != domain.RoomVisibilityRequest {
+		return nil, domain.ErrJoinRequestNotAllowed
+	}
+
+	exists, err := u.repo.PendingExists(ctx, room.ID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if *exists {
+		return nil, domain.ErrJoinRequestExists
+	}
+
+	now := time.Now()
+	request := &domain.JoinRequest{
+		ID:        ulid.NewULID(),
+		RoomID:    room.ID,
+		UserID:    userID,
+		UserName:  userName,
+		Status:    domain.JoinRequestPending,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err = u.repo.Create(ctx, request)
+	if err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// 参加リクエストを承認または却下し、処理した参加リクエストを返す
+func (u *joinRequestUsecase) Decide(ctx context.Context, roomID, id string, approve bool) (*domain.JoinRequest, error) {
+	request, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	// 他のRoomの参加リクエストは存在しないものとして扱う
+	if request.RoomID != roomID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if request.Status != domain.JoinRequestPending {
+		return nil, domain.ErrJoinRequestDecided
+	}
+
+	status := domain.JoinRequestDenied
+	if approve {
+		status = domain.JoinRequestApproved
+	}
+	now := time.Now()
+	err = u.repo.UpdateStatus(ctx, id, status, now)
+	if err != nil {
+		// 同時に処理された場合
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrJoinRequestDecided
+		}
+		return nil, err
+	}
+
+	request.Status = status
+	request.UpdatedAt = now
+	return request, nil
+}
@@ -0,0 +1,196 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	mock_repository "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/repository"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/ulid"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func Test_joinRequestUsecase_Request(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		room     *domain.Room
+		userID   string
+		userName string
+	}
+	requestRoom := &domain.Room{ID: "01J00000000000000000000001", Kind: domain.RoomKindGroup, Visibility: domain.RoomVisibilityRequest}
+	notExists, exists := false, true
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockJoinRequestRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] 参加リクエスト送信",
+			args: args{context.Background(), requestRoom, "abcd1234", "testName"},
+			mockFn: func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {
+				m.EXPECT().PendingExists(ctx, "01J00000000000000000000001", "abcd1234").Return(&notExists, nil)
+				m.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, request *domain.JoinRequest) error {
+					if !ulid.IsValid(request.ID) || request.RoomID != "01J00000000000000000000001" || request.UserID != "abcd1234" || request.UserName != "testName" || request.Status != domain.JoinRequestPending {
+						t.Errorf("Create() request = %+v", request)
+					}
+					return nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name:      "[異常系] 公開されたRoom",
+			args:      args{context.Background(), &domain.Room{ID: "01J00000000000000000000001", Kind: domain.RoomKindGroup, Visibility: domain.RoomVisibilityPublic}, "abcd1234", "testName"},
+			mockFn:    func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrJoinRequestNotAllowed,
+		},
+		{
+			name:      "[異常系] 招待制のRoom",
+			args:      args{context.Background(), &domain.Room{ID: "01J00000000000000000000001", Kind: domain.RoomKindGroup, Visibility: domain.RoomVisibilityInvite}, "abcd1234", "testName"},
+			mockFn:    func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrJoinRequestNotAllowed,
+		},
+		{
+			name: "[異常系] 承認待ちの参加リクエストがある",
+			args: args{context.Background(), requestRoom, "abcd1234", "testName"},
+			mockFn: func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {
+				m.EXPECT().PendingExists(ctx, "01J00000000000000000000001", "abcd1234").Return(&exists, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrJoinRequestExists,
+		},
+		{
+			name: "[異常系] DB処理失敗（Create）",
+			args: args{context.Background(), requestRoom, "abcd1234", "testName"},
+			mockFn: func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {
+				m.EXPECT().PendingExists(ctx, "01J00000000000000000000001", "abcd1234").Return(&notExists, nil)
+				m.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockJoinRequestRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &joinRequestUsecase{
+				repo: mock,
+			}
+			_, err := test.Request(tt.args.ctx, tt.args.room, tt.args.userID, tt.args.userName)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("joinRequestUsecase.Request() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("joinRequestUsecase.Request() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
+
+func Test_joinRequestUsecase_Decide(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		roomID  string
+		id      string
+		approve bool
+	}
+	pending := func() *domain.JoinRequest {
+		return &domain.JoinRequest{ID: "01J00000000000000000000009", RoomID: "01J00000000000000000000001", UserID: "efgh5678", Status: domain.JoinRequestPending}
+	}
+	tests := []struct {
+		name       string
+		args       args
+		mockFn     func(m *mock_repository.MockJoinRequestRepo, ctx context.Context)
+		wantStatus string
+		wantErr    bool
+		wantErrIs  error
+	}{
+		{
+			name: "[正常系] 承認",
+			args: args{context.Background(), "01J00000000000000000000001", "01J00000000000000000000009", true},
+			mockFn: func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(pending(), nil)
+				m.EXPECT().UpdateStatus(ctx, "01J00000000000000000000009", domain.JoinRequestApproved, gomock.Any()).Return(nil)
+			},
+			wantStatus: domain.JoinRequestApproved,
+			wantErr:    false,
+		},
+		{
+			name: "[正常系] 却下",
+			args: args{context.Background(), "01J00000000000000000000001", "01J00000000000000000000009", false},
+			mockFn: func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(pending(), nil)
+				m.EXPECT().UpdateStatus(ctx, "01J00000000000000000000009", domain.JoinRequestDenied, gomock.Any()).Return(nil)
+			},
+			wantStatus: domain.JoinRequestDenied,
+			wantErr:    false,
+		},
+		{
+			name: "[異常系] 他のRoomの参加リクエスト",
+			args: args{context.Background(), "01J00000000000000000000002", "01J00000000000000000000009", true},
+			mockFn: func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(pending(), nil)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] 処理済み",
+			args: args{context.Background(), "01J00000000000000000000001", "01J00000000000000000000009", true},
+			mockFn: func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {
+				request := pending()
+				request.Status = domain.JoinRequestDenied
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(request, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrJoinRequestDecided,
+		},
+		{
+			name: "[異常系] 同時に処理された",
+			args: args{context.Background(), "01J00000000000000000000001", "01J00000000000000000000009", true},
+			mockFn: func(m *mock_repository.MockJoinRequestRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(pending(), nil)
+				m.EXPECT().UpdateStatus(ctx, "01J00000000000000000000009", domain.JoinRequestApproved, gomock.Any()).Return(gorm.ErrRecordNotFound)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrJoinRequestDecided,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockJoinRequestRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &joinRequestUsecase{
+				repo: mock,
+			}
+			got, err := test.Decide(tt.args.ctx, tt.args.roomID, tt.args.id, tt.args.approve)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("joinRequestUsecase.Decide() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("joinRequestUsecase.Decide() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !tt.wantErr && got.Status != tt.wantStatus {
+				t.Errorf("joinRequestUsecase.Decide() status = %s, want %s", got.Status, tt.wantStatus)
+			}
+		})
+	}
+}
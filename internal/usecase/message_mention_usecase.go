@@ -0,0 +1,31 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/repository"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/message_mention_mock.go -package=mock_$GOPACKAGE
+
+type MessageMentionUsecase interface {
+	CountUnreadByUserID(ctx context.Context, userID string) (*domain.MentionCounts, error)
+	MarkReadByUserIDAndRoomID(ctx context.Context, userID, roomID string) error
+}
+
+type messageMentionUsecase struct {
+	repo repository.MessageMentionRepo
+}
+
+func NewMessageMentionUsecase(repo repository.MessageMentionRepo) MessageMentionUsecase {
+	return &messageMentionUsecase{repo: repo}
+}
+
+func (u *messageMentionUsecase) CountUnreadByUserID(ctx context.Context, userID string) (*domain.MentionCounts, error) {
+	return u.repo.CountUnreadByUserID(ctx, userID)
+}
+
+func (u *messageMentionUsecase) MarkReadByUserIDAndRoomID(ctx context.Context, userID, roomID string) error {
+	return u.repo.MarkReadByUserIDAndRoomID(ctx, userID, roomID)
+}
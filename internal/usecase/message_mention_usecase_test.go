@@ -0,0 +1,117 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	mock_repository "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/repository"
+	"go.uber.org/mock/gomock"
+)
+
+func Test_messageMentionUsecase_CountUnreadByUserID(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userID string
+	}
+	counts := &domain.MentionCounts{domain.MentionCount{RoomID: "1234", Count: 2}, domain.MentionCount{RoomID: "5678", Count: 1}}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockMessageMentionRepo, ctx context.Context)
+		want    *domain.MentionCounts
+		wantErr bool
+	}{
+		{
+			name: "[正常系] Roomごとの未読メンション数取得",
+			args: args{context.Background(), "abcd1234"},
+			mockFn: func(m *mock_repository.MockMessageMentionRepo, ctx context.Context) {
+				m.EXPECT().CountUnreadByUserID(ctx, "abcd1234").Return(counts, nil)
+			},
+			want:    counts,
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗",
+			args: args{context.Background(), "abcd1234"},
+			mockFn: func(m *mock_repository.MockMessageMentionRepo, ctx context.Context) {
+				m.EXPECT().CountUnreadByUserID(ctx, "abcd1234").Return(&domain.MentionCounts{}, errors.New("test error"))
+			},
+			want:    &domain.MentionCounts{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageMentionRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &messageMentionUsecase{
+				repo: mock,
+			}
+			got, err := test.CountUnreadByUserID(tt.args.ctx, tt.args.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageMentionUsecase.CountUnreadByUserID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageMentionUsecase.CountUnreadByUserID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messageMentionUsecase_MarkReadByUserIDAndRoomID(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userID string
+		roomID string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockMessageMentionRepo, ctx context.Context)
+		wantErr bool
+	}{
+		{
+			name: "[正常系] Roomのメンションを既読にする",
+			args: args{context.Background(), "abcd1234", "1234"},
+			mockFn: func(m *mock_repository.MockMessageMentionRepo, ctx context.Context) {
+				m.EXPECT().MarkReadByUserIDAndRoomID(ctx, "abcd1234", "1234").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗",
+			args: args{context.Background(), "abcd1234", "1234"},
+			mockFn: func(m *mock_repository.MockMessageMentionRepo, ctx context.Context) {
+				m.EXPECT().MarkReadByUserIDAndRoomID(ctx, "abcd1234", "1234").Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageMentionRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &messageMentionUsecase{
+				repo: mock,
+			}
+			err := test.MarkReadByUserIDAndRoomID(tt.args.ctx, tt.args.userID, tt.args.roomID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageMentionUsecase.MarkReadByUserIDAndRoomID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,85 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/repository"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/message_pin_mock.go -package=mock_$GOPACKAGE
+
+type MessagePinUsecase interface {
+	GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error)
+	Pin(ctx context.Context, roomID, messageID, userID string) (*domain.Message, error)
+	Unpin(ctx context.Context, roomID, messageID string) error
+}
+
+type messagePinUsecase struct {
+	repo        repository.MessagePinRepo
+	messageRepo repository.MessageRepo
+	limit       int // Roomごとにピン留めできるメッセージの数
+}
+
+func NewMessagePinUsecase(repo repository.MessagePinRepo, messageRepo repository.MessageRepo, limit int) MessagePinUsecase {
+	return &messagePinUsecase{repo: repo, messageRepo: messageRepo, limit: limit}
+}
+
+func (u *messagePinUsecase) GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error) {
+	return u.repo.GetByRoomID(ctx, roomID)
+}
+
+// Roomのメッセージをピン留めし、ピン留めしたメッセージを返す
+func (u *messagePinUsecase) Pin(ctx context.Context, roomID, messageID, userID string) (*domain.Message, error) {
+	message, err := u.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 他のRoomのメッセージや閲覧できないプライベートメッセージは存在しないものとして扱う
+	if message.RoomID != roomID || !message.VisibleTo(userID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+	if message.Deleted {
+		return nil, domain.ErrMessageDeleted
+	}
+	if message.ToName != "" {
+		return nil, domain.ErrPrivatePin
+	}
+
+	exists, err := u.repo.Exists(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+	if *exists {
+		return nil, domain.ErrAlreadyPinned
+	}
+
+	count, err := u.repo.CountByRoomID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+	if *count >= int64(u.limit) {
+		return nil, domain.ErrTooManyPins
+	}
+
+	pin := domain.MessagePin{
+		MessageID: messageID,
+		RoomID:    roomID,
+		UserID:    userID,
+		CreatedAt: time.Now(),
+	}
+	err = u.repo.Create(ctx, &pin)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// ピン留めを外す。ピン留めされていなければgorm.ErrRecordNotFoundを返す
+func (u *messagePinUsecase) Unpin(ctx context.Context, roomID, messageID string) error {
+	return u.repo.Delete(ctx, roomID, messageID)
+}
@@ -0,0 +1,191 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	mock_repository "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/repository"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func Test_messagePinUsecase_Pin(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		roomID    string
+		messageID string
+		userID    string
+	}
+	stored := func() *domain.Message {
+		return &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "efgh5678", UserName: "otherName", HTML: "<p>test</p>\n"}
+	}
+	notExists, exists := false, true
+	var belowLimit, atLimit int64 = 2, 3
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(p *mock_repository.MockMessagePinRepo, m *mock_repository.MockMessageRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] メッセージをピン留めする",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				p.EXPECT().Exists(ctx, "01J00000000000000000000001").Return(&notExists, nil)
+				p.EXPECT().CountByRoomID(ctx, "1234").Return(&belowLimit, nil)
+				p.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, pin *domain.MessagePin) error {
+					if pin.MessageID != "01J00000000000000000000001" || pin.RoomID != "1234" || pin.UserID != "abcd1234" || pin.CreatedAt.IsZero() {
+						t.Errorf("Create() pin = %+v", pin)
+					}
+					return nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] 他のRoomのメッセージ",
+			args: args{context.Background(), "5678", "01J00000000000000000000001", "abcd1234"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] プライベートメッセージ",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				message := stored()
+				message.ToName = "testName"
+				message.ToUserID = "abcd1234"
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(message, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrPrivatePin,
+		},
+		{
+			name: "[異常系] 削除済みのメッセージ",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				message := stored()
+				message.Deleted = true
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(message, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrMessageDeleted,
+		},
+		{
+			name: "[異常系] ピン留め済み",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				p.EXPECT().Exists(ctx, "01J00000000000000000000001").Return(&exists, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrAlreadyPinned,
+		},
+		{
+			name: "[異常系] ピン留めの上限",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				p.EXPECT().Exists(ctx, "01J00000000000000000000001").Return(&notExists, nil)
+				p.EXPECT().CountByRoomID(ctx, "1234").Return(&atLimit, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrTooManyPins,
+		},
+		{
+			name: "[異常系] DB処理失敗（Create）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				p.EXPECT().Exists(ctx, "01J00000000000000000000001").Return(&notExists, nil)
+				p.EXPECT().CountByRoomID(ctx, "1234").Return(&belowLimit, nil)
+				p.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPin := mock_repository.NewMockMessagePinRepo(ctrl)
+			mockMessage := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mockPin, mockMessage, tt.args.ctx)
+
+			test := &messagePinUsecase{
+				repo:        mockPin,
+				messageRepo: mockMessage,
+				limit:       3,
+			}
+			got, err := test.Pin(tt.args.ctx, tt.args.roomID, tt.args.messageID, tt.args.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messagePinUsecase.Pin() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("messagePinUsecase.Pin() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !tt.wantErr && got.ID != tt.args.messageID {
+				t.Errorf("messagePinUsecase.Pin() = %+v, want message %s", got, tt.args.messageID)
+			}
+		})
+	}
+}
+
+func Test_messagePinUsecase_Unpin(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		roomID    string
+		messageID string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(p *mock_repository.MockMessagePinRepo, ctx context.Context)
+		wantErr bool
+	}{
+		{
+			name: "[正常系] ピン留めを外す",
+			args: args{context.Background(), "1234", "01J00000000000000000000001"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, ctx context.Context) {
+				p.EXPECT().Delete(ctx, "1234", "01J00000000000000000000001").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] ピン留めされていない",
+			args: args{context.Background(), "1234", "01J00000000000000000000001"},
+			mockFn: func(p *mock_repository.MockMessagePinRepo, ctx context.Context) {
+				p.EXPECT().Delete(ctx, "1234", "01J00000000000000000000001").Return(gorm.ErrRecordNotFound)
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mockPin := mock_repository.NewMockMessagePinRepo(ctrl)
+
+			tt.mockFn(mockPin, tt.args.ctx)
+
+			test := &messagePinUsecase{
+				repo: mockPin,
+			}
+			err := test.Unpin(tt.args.ctx, tt.args.roomID, tt.args.messageID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messagePinUsecase.Unpin() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,108 @@
+package usecase
+
+import (
+	"context"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/repository"
+	"gorm.io/gorm"
+)
+
+// 1つのメッセージに付けられる絵文字の種類数
+const reactionKindsMax = 20
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/message_reaction_mock.go -package=mock_$GOPACKAGE
+
+type MessageReactionUsecase interface {
+	Toggle(ctx context.Context, roomID, messageID, userID, emoji string) (*domain.Message, *domain.ReactionCounts, error)
+	GetCounts(ctx context.Context, messageIDs []string) (*domain.ReactionCounts, error)
+}
+
+type messageReactionUsecase struct {
+	repo        repository.MessageReactionRepo
+	messageRepo repository.MessageRepo
+}
+
+func NewMessageReactionUsecase(repo repository.MessageReactionRepo, messageRepo repository.MessageRepo) MessageReactionUsecase {
+	return &messageReactionUsecase{repo: repo, messageRepo: messageRepo}
+}
+
+// リアクションを付けていなければ付け、付けていれば外す
+// リアクションの対象のメッセージと、変更後のそのメッセージのリアクションの数を返す
+func (u *messageReactionUsecase) Toggle(ctx context.Context, roomID, messageID, userID, emoji string) (*domain.Message, *domain.ReactionCounts, error) {
+	reaction := domain.MessageReaction{
+		MessageID: messageID,
+		UserID:    userID,
+		Emoji:     emoji,
+		RoomID:    roomID,
+	}
+	err := reaction.Validate()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	message, err := u.messageRepo.GetByID(ctx, messageID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 他のRoomのメッセージや閲覧できないプライベートメッセージは存在しないものとして扱う
+	if message.RoomID != roomID || !message.VisibleTo(userID) {
+		return nil, nil, gorm.ErrRecordNotFound
+	}
+	if message.Deleted {
+		return nil, nil, domain.ErrMessageDeleted
+	}
+
+	exists, err := u.repo.Exists(ctx, &reaction)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if *exists {
+		err = u.repo.Delete(ctx, &reaction)
+		if err != nil {
+			return nil, nil, err
+		}
+	} else {
+		// 新しい種類の絵文字であれば種類数の上限を確認する
+		counts, err := u.repo.CountByMessageIDs(ctx, []string{messageID})
+		if err != nil {
+			return nil, nil, err
+		}
+		if !hasEmoji(counts, emoji) && len(*counts) >= reactionKindsMax {
+			return nil, nil, domain.ErrTooManyReactions
+		}
+
+		reaction.CreatedAt = time.Now()
+		err = u.repo.Create(ctx, &reaction)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	counts, err := u.repo.CountByMessageIDs(ctx, []string{messageID})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return message, counts, nil
+}
+
+// メッセージごと絵文字ごとのリアクションの数を取得する
+func (u *messageReactionUsecase) GetCounts(ctx context.Context, messageIDs []string) (*domain.ReactionCounts, error) {
+	if len(messageIDs) == 0 {
+		return &domain.ReactionCounts{}, nil
+	}
+	return u.repo.CountByMessageIDs(ctx, messageIDs)
+}
+
+func hasEmoji(counts *domain.ReactionCounts, emoji string) bool {
+	for _, c := range *counts {
+		if c.Emoji == emoji {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,287 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	mock_repository "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/repository"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func Test_messageReactionUsecase_Toggle(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		roomID    string
+		messageID string
+		userID    string
+		emoji     string
+	}
+	stored := &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "abcd1234", UserName: "testName", HTML: "<p>test</p>\n"}
+	reaction := &domain.MessageReaction{MessageID: "01J00000000000000000000001", UserID: "efgh5678", Emoji: "👍", RoomID: "1234"}
+	counts := &domain.ReactionCounts{domain.ReactionCount{MessageID: "01J00000000000000000000001", Emoji: "👍", Count: 1}}
+	fullCounts := make(domain.ReactionCounts, 0, reactionKindsMax)
+	for i := 0; i < reactionKindsMax; i++ {
+		fullCounts = append(fullCounts, domain.ReactionCount{MessageID: "01J00000000000000000000001", Emoji: string(rune(0x1f600 + i)), Count: 1})
+	}
+	tests := []struct {
+		name       string
+		args       args
+		mockFn     func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context)
+		wantCounts *domain.ReactionCounts
+		wantErr    bool
+		wantErrIs  error
+	}{
+		{
+			name: "[正常系] リアクションを付ける",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				notExists := false
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored, nil)
+				r.EXPECT().Exists(ctx, reaction).Return(&notExists, nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(&domain.ReactionCounts{}, nil)
+				r.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(counts, nil)
+			},
+			wantCounts: counts,
+		},
+		{
+			name: "[正常系] 異体字セレクタ付きの記号の絵文字",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "©️"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				notExists := false
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored, nil)
+				r.EXPECT().Exists(ctx, gomock.Any()).Return(&notExists, nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(&domain.ReactionCounts{}, nil)
+				r.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(counts, nil)
+			},
+			wantCounts: counts,
+		},
+		{
+			name: "[正常系] 付けているリアクションを外す",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				exists := true
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored, nil)
+				r.EXPECT().Exists(ctx, reaction).Return(&exists, nil)
+				r.EXPECT().Delete(ctx, reaction).Return(nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(&domain.ReactionCounts{}, nil)
+			},
+			wantCounts: &domain.ReactionCounts{},
+		},
+		{
+			name: "[正常系] 種類数が上限でも付けられている絵文字は付けられる",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "😀"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				notExists := false
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored, nil)
+				r.EXPECT().Exists(ctx, gomock.Any()).Return(&notExists, nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(&fullCounts, nil)
+				r.EXPECT().Create(ctx, gomock.Any()).Return(nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(&fullCounts, nil)
+			},
+			wantCounts: &fullCounts,
+		},
+		{
+			name: "[異常系] 絵文字の種類数の上限",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				notExists := false
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored, nil)
+				r.EXPECT().Exists(ctx, reaction).Return(&notExists, nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(&fullCounts, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrTooManyReactions,
+		},
+		{
+			name: "[異常系] 閲覧できないプライベートメッセージ",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(&domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "abcd1234", UserName: "testName", ToName: "thirdName", ToUserID: "ijkl9012"}, nil)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] 送信先のユーザー名を後から使い始めた別のユーザー",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(&domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "abcd1234", UserName: "testName", ToName: "otherName", ToUserID: "ijkl9012"}, nil)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] 他のRoomのメッセージ",
+			args: args{context.Background(), "5678", "01J00000000000000000000001", "efgh5678", "👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored, nil)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] 削除済みのメッセージ",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(&domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "abcd1234", UserName: "testName", Deleted: true}, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrMessageDeleted,
+		},
+		{
+			name: "[異常系] バリデーション失敗（絵文字に空白を含む）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍 "},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+			},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] バリデーション失敗（絵文字ではない文字列）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "hello"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+			},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] バリデーション失敗（矢印）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "→"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+			},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] バリデーション失敗（異体字セレクタのない記号）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "©"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+			},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] バリデーション失敗（異体字セレクタのない再生記号）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "▶"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+			},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] バリデーション失敗（複数の絵文字）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+			},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] DB処理失敗（Create）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "efgh5678", "👍"},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, m *mock_repository.MockMessageRepo, ctx context.Context) {
+				notExists := false
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored, nil)
+				r.EXPECT().Exists(ctx, reaction).Return(&notExists, nil)
+				r.EXPECT().CountByMessageIDs(ctx, []string{"01J00000000000000000000001"}).Return(&domain.ReactionCounts{}, nil)
+				r.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageReactionRepo(ctrl)
+			messageMock := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mock, messageMock, tt.args.ctx)
+
+			test := &messageReactionUsecase{
+				repo:        mock,
+				messageRepo: messageMock,
+			}
+			message, got, err := test.Toggle(tt.args.ctx, tt.args.roomID, tt.args.messageID, tt.args.userID, tt.args.emoji)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageReactionUsecase.Toggle() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("messageReactionUsecase.Toggle() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if tt.wantErr {
+				return
+			}
+			if message.ID != tt.args.messageID {
+				t.Errorf("messageReactionUsecase.Toggle() message = %+v", message)
+			}
+			if !reflect.DeepEqual(got, tt.wantCounts) {
+				t.Errorf("messageReactionUsecase.Toggle() = %v, want %v", got, tt.wantCounts)
+			}
+		})
+	}
+}
+
+func Test_messageReactionUsecase_GetCounts(t *testing.T) {
+	type args struct {
+		ctx        context.Context
+		messageIDs []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(r *mock_repository.MockMessageReactionRepo, ctx context.Context, messageIDs []string)
+		want    *domain.ReactionCounts
+		wantErr bool
+	}{
+		{
+			name: "[正常系] リアクションの数を取得",
+			args: args{context.Background(), []string{"01J00000000000000000000001"}},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, ctx context.Context, messageIDs []string) {
+				r.EXPECT().CountByMessageIDs(ctx, messageIDs).Return(&domain.ReactionCounts{domain.ReactionCount{MessageID: "01J00000000000000000000001", Emoji: "👍", Count: 2}}, nil)
+			},
+			want:    &domain.ReactionCounts{domain.ReactionCount{MessageID: "01J00000000000000000000001", Emoji: "👍", Count: 2}},
+			wantErr: false,
+		},
+		{
+			name:    "[正常系] メッセージがない",
+			args:    args{context.Background(), []string{}},
+			mockFn:  func(r *mock_repository.MockMessageReactionRepo, ctx context.Context, messageIDs []string) {},
+			want:    &domain.ReactionCounts{},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗（CountByMessageIDs）",
+			args: args{context.Background(), []string{"01J00000000000000000000001"}},
+			mockFn: func(r *mock_repository.MockMessageReactionRepo, ctx context.Context, messageIDs []string) {
+				r.EXPECT().CountByMessageIDs(ctx, messageIDs).Return(nil, errors.New("test error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageReactionRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx, tt.args.messageIDs)
+
+			test := &messageReactionUsecase{
+				repo: mock,
+			}
+			got, err := test.GetCounts(tt.args.ctx, tt.args.messageIDs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageReactionUsecase.GetCounts() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageReactionUsecase.GetCounts() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
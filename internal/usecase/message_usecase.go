@@ -0,0 +1,388 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/repository"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/ulid"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/russross/blackfriday/v2"
+	"golang.org/x/net/html"
+	"gorm.io/gorm"
+)
+
+const (
+	historyLimitMax = 100
+	threadLimitMax  = 100
+	resumeLimitMax  = 500
+	searchLimitMax  = 50
+	// 検索語の最大の文字数
+	searchQueryMax = 200
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/message_mock.go -package=mock_$GOPACKAGE
+
+type MessageUsecase interface {
+	GetByID(ctx context.Context, id string) (*domain.Message, error)
+	GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error)
+	GetHistory(ctx context.Context, roomID, userID, before string, limit int) (*domain.Messages, error)
+	GetSinceSeq(ctx context.Context, roomID, userID string, seq int64, limit int) (*domain.Messages, error)
+	GetThread(ctx context.Context, roomID, parentID, before string, limit int) (*domain.Message, *domain.Messages, error)
+	GetThreadSummaries(ctx context.Context, parentIDs []string) (*domain.ThreadSummaries, error)
+	Search(ctx context.Context, userID, query, before string, limit int) (*domain.MessageSearchResults, error)
+	Create(ctx context.Context, message *domain.Message) error
+	Edit(ctx context.Context, roomID, id, editorID string, moderator bool, markdown string, participants domain.Users) (*domain.Message, error)
+	Delete(ctx context.Context, roomID, id, editorID string, moderator bool) (*domain.Message, error)
+	DeleteByRoomID(ctx context.Context, roomID string) error
+}
+
+type messageUsecase struct {
+	repo repository.MessageRepo
+}
+
+func NewMessageUsecase(repo repository.MessageRepo) MessageUsecase {
+	return &messageUsecase{repo: repo}
+}
+
+func (u *messageUsecase) GetByID(ctx context.Context, id string) (*domain.Message, error) {
+	return u.repo.GetByID(ctx, id)
+}
+
+func (u *messageUsecase) GetByRoomID(ctx context.Context, roomID string) (*domain.Messages, error) {
+	return u.repo.GetByRoomID(ctx, roomID)
+}
+
+// Roomの履歴を古い順に取得する。beforeが指定された場合はそのIDより前のメッセージを取得する
+func (u *messageUsecase) GetHistory(ctx context.Context, roomID, userID, before string, limit int) (*domain.Messages, error) {
+	if before != "" && !ulid.IsValid(before) {
+		return nil, domain.ErrInvalidCursor
+	}
+
+	if limit < 1 || limit > historyLimitMax {
+		limit = historyLimitMax
+	}
+
+	messages, err := u.repo.GetHistory(ctx, roomID, userID, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	// 新しい順で取得しているため古い順に並び替え
+	for i, j := 0, len(*messages)-1; i < j; i, j = i+1, j-1 {
+		(*messages)[i], (*messages)[j] = (*messages)[j], (*messages)[i]
+	}
+
+	return messages, nil
+}
+
+// 再接続したクライアントが受け取っていないseqより後のメッセージを古い順に取得する
+func (u *messageUsecase) GetSinceSeq(ctx context.Context, roomID, userID string, seq int64, limit int) (*domain.Messages, error) {
+	if seq < 0 {
+		return nil, errors.New("seq の値が不正です。")
+	}
+
+	if limit < 1 || limit > resumeLimitMax {
+		limit = resumeLimitMax
+	}
+
+	return u.repo.GetSinceSeq(ctx, roomID, userID, seq, limit)
+}
+
+// userが参加しているRoomのメッセージを全文検索し、新しい順に取得する
+// スニペットはHTMLエスケープし、一致した語を<mark>で囲む
+func (u *messageUsecase) Search(ctx context.Context, userID, query, before string, limit int) (*domain.MessageSearchResults, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > searchQueryMax {
+		return nil, fmt.Errorf("%w 検索語は1文字以上%d文字以内にしてください。", domain.ErrInvalidSearch, searchQueryMax)
+	}
+	if before != "" && !ulid.IsValid(before) {
+		return nil, fmt.Errorf("%w before の値が不正です。", domain.ErrInvalidSearch)
+	}
+
+	if limit < 1 || limit > searchLimitMax {
+		limit = searchLimitMax
+	}
+
+	results, err := u.repo.Search(ctx, userID, query, before, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range *results {
+		(*results)[i].Snippet = highlightSnippet((*results)[i].Snippet)
+	}
+	return results, nil
+}
+
+// 検索結果のスニペットをHTMLエスケープし、一致した語の区切り文字を<mark>に置き換える
+func highlightSnippet(snippet string) string {
+	return searchHighlighter.Replace(html.EscapeString(snippet))
+}
+
+var searchHighlighter = strings.NewReplacer(domain.SearchHighlightStart, "<mark>", domain.SearchHighlightStop, "</mark>")
+
+// メッセージを保存する。clientidが重複している場合は保存済みのメッセージをmessageに入れてErrDuplicateMessageを返す
+func (u *messageUsecase) Create(ctx context.Context, message *domain.Message) error {
+	err := message.Validate()
+	if err != nil {
+		return err
+	}
+
+	if message.IsReply() {
+		parent, err := u.getThreadParent(ctx, message.RoomID, message.ParentID)
+		if err != nil {
+			return err
+		}
+		// スレッドは1階層のみのため、返信への返信は元のメッセージへの返信とする
+		message.ParentID = parent.ID
+	} else {
+		message.AlsoToRoom = false
+	}
+
+	message.ID = ulid.NewULID()
+	message.HTML = renderMessage(message)
+
+	now := time.Now()
+	message.CreatedAt = now
+	message.UpdatedAt = now
+
+	err = u.repo.Create(ctx, message)
+	if !errors.Is(err, domain.ErrDuplicateMessage) {
+		return err
+	}
+
+	// 再送されたメッセージであれば保存済みのものを返す
+	messages, err := u.repo.GetByClientID(ctx, message.RoomID, message.UserID, message.ClientID)
+	if err != nil {
+		return err
+	}
+	if len(*messages) > 0 {
+		*message = (*messages)[0]
+	}
+	return domain.ErrDuplicateMessage
+}
+
+// スレッドへの返信を古い順に取得する。beforeが指定された場合はそのIDより前の返信を取得する
+// 返信先のメッセージも合わせて返す
+func (u *messageUsecase) GetThread(ctx context.Context, roomID, parentID, before string, limit int) (*domain.Message, *domain.Messages, error) {
+	if !ulid.IsValid(parentID) {
+		return nil, nil, errors.New("id の値が不正です。")
+	}
+	if before != "" && !ulid.IsValid(before) {
+		return nil, nil, domain.ErrInvalidCursor
+	}
+
+	if limit < 1 || limit > threadLimitMax {
+		limit = threadLimitMax
+	}
+
+	parent, err := u.getThreadParent(ctx, roomID, parentID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	messages, err := u.repo.GetThread(ctx, roomID, parent.ID, before, limit)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// 新しい順で取得しているため古い順に並び替え
+	for i, j := 0, len(*messages)-1; i < j; i, j = i+1, j-1 {
+		(*messages)[i], (*messages)[j] = (*messages)[j], (*messages)[i]
+	}
+
+	return parent, messages, nil
+}
+
+// スレッドごとの返信の概要を取得する
+func (u *messageUsecase) GetThreadSummaries(ctx context.Context, parentIDs []string) (*domain.ThreadSummaries, error) {
+	if len(parentIDs) == 0 {
+		return &domain.ThreadSummaries{}, nil
+	}
+	return u.repo.GetThreadSummaries(ctx, parentIDs)
+}
+
+// スレッドの返信先のメッセージを取得する。返信への返信であれば元のメッセージを取得する
+func (u *messageUsecase) getThreadParent(ctx context.Context, roomID, parentID string) (*domain.Message, error) {
+	parent, err := u.repo.GetByID(ctx, parentID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrParentNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if parent.RoomID != roomID {
+		return nil, domain.ErrParentNotFound
+	}
+
+	if parent.IsReply() {
+		parent, err = u.repo.GetByID(ctx, parent.ParentID)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if parent.ToName != "" {
+		return nil, domain.ErrPrivateThread
+	}
+
+	return parent, nil
+}
+
+// メッセージを編集する。編集前の内容は履歴として残す
+// 編集後のメッセージの@メンションのうち、participantsに含まれるユーザーをメンションとして追加する
+func (u *messageUsecase) Edit(ctx context.Context, roomID, id, editorID string, moderator bool, markdown string, participants domain.Users) (*domain.Message, error) {
+	message, err := u.getModifiable(ctx, roomID, id, editorID, moderator)
+	if err != nil {
+		return nil, err
+	}
+	revision := newRevision(message, editorID)
+
+	message.Markdown = markdown
+	err = message.Validate()
+	if err != nil {
+		return nil, err
+	}
+	message.SetMentions(participants)
+	message.HTML = renderMessage(message)
+	message.Edited = true
+	message.UpdatedAt = revision.CreatedAt
+
+	err = u.repo.UpdateWithRevision(ctx, message, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// メッセージを削除する。削除前の内容は履歴として残し、メッセージは削除済みとして内容を空にする
+func (u *messageUsecase) Delete(ctx context.Context, roomID, id, editorID string, moderator bool) (*domain.Message, error) {
+	message, err := u.getModifiable(ctx, roomID, id, editorID, moderator)
+	if err != nil {
+		return nil, err
+	}
+	revision := newRevision(message, editorID)
+
+	message.Markdown = ""
+	message.HTML = ""
+	message.Deleted = true
+	message.UpdatedAt = revision.CreatedAt
+
+	err = u.repo.UpdateWithRevision(ctx, message, revision)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// Room内のメッセージを取得し、editorが変更できるか確認する
+func (u *messageUsecase) getModifiable(ctx context.Context, roomID, id, editorID string, moderator bool) (*domain.Message, error) {
+	message, err := u.repo.GetByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+
+	// 他のRoomのメッセージや閲覧できないプライベートメッセージは存在しないものとして扱う
+	if message.RoomID != roomID || !message.VisibleTo(editorID) {
+		return nil, gorm.ErrRecordNotFound
+	}
+
+	err = message.CanModify(editorID, moderator)
+	if err != nil {
+		return nil, err
+	}
+
+	return message, nil
+}
+
+// 変更前のメッセージの内容から履歴を作成する
+func newRevision(message *domain.Message, editorID string) *domain.MessageRevision {
+	return &domain.MessageRevision{
+		ID:        ulid.NewULID(),
+		MessageID: message.ID,
+		RoomID:    message.RoomID,
+		EditorID:  editorID,
+		Markdown:  message.Markdown,
+		HTML:      message.HTML,
+		CreatedAt: time.Now(),
+	}
+}
+
+func (u *messageUsecase) DeleteByRoomID(ctx context.Context, roomID string) error {
+	return u.repo.DeleteByRoomID(ctx, roomID)
+}
+
+// マークダウンをHTMLに変換し、XSS対策としてサニタイズする
+func renderMarkdown(markdown string) string {
+	htmlmsg := blackfriday.Run([]byte(markdown))
+	policy := bluemonday.UGCPolicy()
+	return string(policy.SanitizeBytes(htmlmsg))
+}
+
+// メッセージをHTMLに変換し、メンションされたユーザーへの@メンションをリンクにする
+func renderMessage(message *domain.Message) string {
+	htmlmsg := renderMarkdown(message.Markdown)
+	if len(message.Mentions) == 0 {
+		return htmlmsg
+	}
+
+	names := make([]string, 0, len(message.Mentions))
+	for _, mention := range message.Mentions {
+		names = append(names, mention.UserName)
+	}
+	return linkMentions(htmlmsg, names)
+}
+
+// サニタイズ済みのHTMLのテキスト中の@メンションをリンクにする。リンクやコードの中は変換しない
+func linkMentions(htmlmsg string, names []string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(htmlmsg))
+	skip := 0
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			return b.String()
+		case html.StartTagToken, html.EndTagToken:
+			tag, _ := z.TagName()
+			if noMentionTags[string(tag)] {
+				if tt == html.StartTagToken {
+					skip++
+				} else if skip > 0 {
+					skip--
+				}
+			}
+			b.Write(z.Raw())
+		case html.TextToken:
+			if skip > 0 {
+				b.Write(z.Raw())
+				continue
+			}
+			for _, segment := range domain.SplitMentions(string(z.Text()), names) {
+				if segment.Name == "" {
+					b.WriteString(html.EscapeString(segment.Text))
+					continue
+				}
+				b.WriteString(`<a class="mention" href="#mention-` + html.EscapeString(url.PathEscape(segment.Name)) + `">` + html.EscapeString(segment.Text) + `</a>`)
+			}
+		default:
+			b.Write(z.Raw())
+		}
+	}
+}
+
+// 中のテキストを@メンションのリンクにしない要素
+var noMentionTags = map[string]bool{
+	"a":    true,
+	"code": true,
+	"pre":  true,
+}
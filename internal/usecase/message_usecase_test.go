@@ -0,0 +1,1065 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	mock_repository "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/repository"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func Test_messageUsecase_GetByID(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		id  string
+	}
+	testTime := time.Now()
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockMessageRepo, ctx context.Context, id string)
+		want    *domain.Message
+		wantErr bool
+	}{
+		{
+			name: "[正常系] ID指定でのMessage取得",
+			args: args{context.Background(), "abcd1234"},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, id string) {
+				m.EXPECT().GetByID(ctx, id).Return(&domain.Message{ID: "abcd1234", RoomID: "1234", UserID: "efgh5678", UserName: "testName", Markdown: "test", HTML: "<p>test</p>\n", CreatedAt: testTime, UpdatedAt: testTime}, nil)
+			},
+			want:    &domain.Message{ID: "abcd1234", RoomID: "1234", UserID: "efgh5678", UserName: "testName", Markdown: "test", HTML: "<p>test</p>\n", CreatedAt: testTime, UpdatedAt: testTime},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetByID）",
+			args: args{context.Background(), "abcd1234"},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, id string) {
+				m.EXPECT().GetByID(ctx, id).Return(&domain.Message{}, errors.New("test error"))
+			},
+			want:    &domain.Message{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx, tt.args.id)
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			got, err := test.GetByID(tt.args.ctx, tt.args.id)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.GetByID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageUsecase.GetByID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_GetByRoomID(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		roomID string
+	}
+	testTime := time.Now()
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID string)
+		want    *domain.Messages
+		wantErr bool
+	}{
+		{
+			name: "[正常系] RoomID指定でのMessage全取得",
+			args: args{context.Background(), "1234"},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID string) {
+				m.EXPECT().GetByRoomID(ctx, roomID).Return(&domain.Messages{domain.Message{ID: "abcd1234", RoomID: "1234", UserID: "efgh5678", UserName: "testName", Markdown: "test", HTML: "<p>test</p>\n", CreatedAt: testTime, UpdatedAt: testTime}}, nil)
+			},
+			want:    &domain.Messages{domain.Message{ID: "abcd1234", RoomID: "1234", UserID: "efgh5678", UserName: "testName", Markdown: "test", HTML: "<p>test</p>\n", CreatedAt: testTime, UpdatedAt: testTime}},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetByRoomID）",
+			args: args{context.Background(), "1234"},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID string) {
+				m.EXPECT().GetByRoomID(ctx, roomID).Return(&domain.Messages{}, errors.New("test error"))
+			},
+			want:    &domain.Messages{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx, tt.args.roomID)
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			got, err := test.GetByRoomID(tt.args.ctx, tt.args.roomID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.GetByRoomID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageUsecase.GetByRoomID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_GetHistory(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		roomID string
+		userID string
+		before string
+		limit  int
+	}
+	testTime := time.Now()
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID, before string)
+		want    *domain.Messages
+		wantErr bool
+	}{
+		{
+			name: "[正常系] 履歴が古い順に並び替えられる",
+			args: args{context.Background(), "1234", "abcd1234", "", 50},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID, before string) {
+				m.EXPECT().GetHistory(ctx, roomID, userID, before, 50).Return(&domain.Messages{
+					domain.Message{ID: "01J00000000000000000000002", RoomID: "1234", Markdown: "second", CreatedAt: testTime, UpdatedAt: testTime},
+					domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", Markdown: "first", CreatedAt: testTime, UpdatedAt: testTime},
+				}, nil)
+			},
+			want: &domain.Messages{
+				domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", Markdown: "first", CreatedAt: testTime, UpdatedAt: testTime},
+				domain.Message{ID: "01J00000000000000000000002", RoomID: "1234", Markdown: "second", CreatedAt: testTime, UpdatedAt: testTime},
+			},
+			wantErr: false,
+		},
+		{
+			name: "[正常系] beforeを指定して過去の履歴を取得",
+			args: args{context.Background(), "1234", "abcd1234", "01J00000000000000000000003", 50},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID, before string) {
+				m.EXPECT().GetHistory(ctx, roomID, userID, before, 50).Return(&domain.Messages{
+					domain.Message{ID: "01J00000000000000000000002", RoomID: "1234", Markdown: "second", CreatedAt: testTime, UpdatedAt: testTime},
+				}, nil)
+			},
+			want: &domain.Messages{
+				domain.Message{ID: "01J00000000000000000000002", RoomID: "1234", Markdown: "second", CreatedAt: testTime, UpdatedAt: testTime},
+			},
+			wantErr: false,
+		},
+		{
+			name: "[正常系] limitが上限を超える場合は上限に丸められる",
+			args: args{context.Background(), "1234", "abcd1234", "", 1000},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID, before string) {
+				m.EXPECT().GetHistory(ctx, roomID, userID, before, historyLimitMax).Return(&domain.Messages{}, nil)
+			},
+			want:    &domain.Messages{},
+			wantErr: false,
+		},
+		{
+			name:    "[異常系] beforeがULIDではない",
+			args:    args{context.Background(), "1234", "abcd1234", "invalid", 50},
+			mockFn:  nil,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetHistory）",
+			args: args{context.Background(), "1234", "abcd1234", "", 50},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID, before string) {
+				m.EXPECT().GetHistory(ctx, roomID, userID, before, 50).Return(&domain.Messages{}, errors.New("test error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			if tt.mockFn != nil {
+				tt.mockFn(mock, tt.args.ctx, tt.args.roomID, tt.args.userID, tt.args.before)
+			}
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			got, err := test.GetHistory(tt.args.ctx, tt.args.roomID, tt.args.userID, tt.args.before, tt.args.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.GetHistory() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageUsecase.GetHistory() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_GetSinceSeq(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		roomID string
+		userID string
+		seq    int64
+		limit  int
+	}
+	testTime := time.Now()
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID string, seq int64)
+		want    *domain.Messages
+		wantErr bool
+	}{
+		{
+			name: "[正常系] seqより後のMessage取得",
+			args: args{context.Background(), "1234", "abcd1234", 10, 50},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID string, seq int64) {
+				m.EXPECT().GetSinceSeq(ctx, roomID, userID, seq, 50).Return(&domain.Messages{
+					domain.Message{ID: "01J00000000000000000000011", RoomID: "1234", Seq: 11, Markdown: "first", CreatedAt: testTime, UpdatedAt: testTime},
+					domain.Message{ID: "01J00000000000000000000012", RoomID: "1234", Seq: 12, Markdown: "second", CreatedAt: testTime, UpdatedAt: testTime},
+				}, nil)
+			},
+			want: &domain.Messages{
+				domain.Message{ID: "01J00000000000000000000011", RoomID: "1234", Seq: 11, Markdown: "first", CreatedAt: testTime, UpdatedAt: testTime},
+				domain.Message{ID: "01J00000000000000000000012", RoomID: "1234", Seq: 12, Markdown: "second", CreatedAt: testTime, UpdatedAt: testTime},
+			},
+			wantErr: false,
+		},
+		{
+			name: "[正常系] limitが上限を超える場合は上限に丸める",
+			args: args{context.Background(), "1234", "abcd1234", 0, 10000},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID string, seq int64) {
+				m.EXPECT().GetSinceSeq(ctx, roomID, userID, seq, resumeLimitMax).Return(&domain.Messages{}, nil)
+			},
+			want:    &domain.Messages{},
+			wantErr: false,
+		},
+		{
+			name:    "[異常系] seqが負の値",
+			args:    args{context.Background(), "1234", "abcd1234", -1, 50},
+			mockFn:  nil,
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetSinceSeq）",
+			args: args{context.Background(), "1234", "abcd1234", 10, 50},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID, userID string, seq int64) {
+				m.EXPECT().GetSinceSeq(ctx, roomID, userID, seq, 50).Return(nil, errors.New("test error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			if tt.mockFn != nil {
+				tt.mockFn(mock, tt.args.ctx, tt.args.roomID, tt.args.userID, tt.args.seq)
+			}
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			got, err := test.GetSinceSeq(tt.args.ctx, tt.args.roomID, tt.args.userID, tt.args.seq, tt.args.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.GetSinceSeq() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageUsecase.GetSinceSeq() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_Search(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userID string
+		query  string
+		before string
+		limit  int
+	}
+	testTime := time.Now()
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockMessageRepo, ctx context.Context)
+		want      *domain.MessageSearchResults
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] スニペットがエスケープされ一致した語が強調される",
+			args: args{context.Background(), "abcd1234", " hello ", "", 20},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().Search(ctx, "abcd1234", "hello", "", 20).Return(&domain.MessageSearchResults{
+					domain.MessageSearchResult{ID: "01J00000000000000000000001", RoomID: "1234", UserName: "otherName", Snippet: "<b>" + domain.SearchHighlightStart + "hello" + domain.SearchHighlightStop + "</b>", CreatedAt: testTime},
+				}, nil)
+			},
+			want: &domain.MessageSearchResults{
+				domain.MessageSearchResult{ID: "01J00000000000000000000001", RoomID: "1234", UserName: "otherName", Snippet: "&lt;b&gt;<mark>hello</mark>&lt;/b&gt;", CreatedAt: testTime},
+			},
+			wantErr: false,
+		},
+		{
+			name: "[正常系] limitが上限を超える場合は上限に丸められる",
+			args: args{context.Background(), "abcd1234", "hello", "01J00000000000000000000003", 1000},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().Search(ctx, "abcd1234", "hello", "01J00000000000000000000003", searchLimitMax).Return(&domain.MessageSearchResults{}, nil)
+			},
+			want:    &domain.MessageSearchResults{},
+			wantErr: false,
+		},
+		{
+			name:      "[異常系] 検索語が空",
+			args:      args{context.Background(), "abcd1234", "  ", "", 20},
+			mockFn:    nil,
+			want:      nil,
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidSearch,
+		},
+		{
+			name:      "[異常系] 検索語が長すぎる",
+			args:      args{context.Background(), "abcd1234", strings.Repeat("あ", searchQueryMax+1), "", 20},
+			mockFn:    nil,
+			want:      nil,
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidSearch,
+		},
+		{
+			name:      "[異常系] beforeがULIDではない",
+			args:      args{context.Background(), "abcd1234", "hello", "invalid", 20},
+			mockFn:    nil,
+			want:      nil,
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidSearch,
+		},
+		{
+			name: "[異常系] DB処理失敗（Search）",
+			args: args{context.Background(), "abcd1234", "hello", "", 20},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().Search(ctx, "abcd1234", "hello", "", 20).Return(&domain.MessageSearchResults{}, errors.New("test error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			if tt.mockFn != nil {
+				tt.mockFn(mock, tt.args.ctx)
+			}
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			got, err := test.Search(tt.args.ctx, tt.args.userID, tt.args.query, tt.args.before, tt.args.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.Search() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("messageUsecase.Search() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageUsecase.Search() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_GetThread(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		roomID   string
+		parentID string
+		before   string
+		limit    int
+	}
+	parent := &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "abcd1234", UserName: "testName"}
+	tests := []struct {
+		name        string
+		args        args
+		mockFn      func(m *mock_repository.MockMessageRepo, ctx context.Context)
+		wantParent  string
+		wantReplies []string
+		wantErr     bool
+		wantErrIs   error
+	}{
+		{
+			name: "[正常系] スレッドの返信を古い順に取得",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "", 50},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(parent, nil)
+				m.EXPECT().GetThread(ctx, "1234", "01J00000000000000000000001", "", 50).Return(&domain.Messages{domain.Message{ID: "01J00000000000000000000003"}, domain.Message{ID: "01J00000000000000000000002"}}, nil)
+			},
+			wantParent:  "01J00000000000000000000001",
+			wantReplies: []string{"01J00000000000000000000002", "01J00000000000000000000003"},
+		},
+		{
+			name: "[正常系] 返信のIDを指定すると元のメッセージのスレッドを取得",
+			args: args{context.Background(), "1234", "01J00000000000000000000002", "", 500},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000002").Return(&domain.Message{ID: "01J00000000000000000000002", RoomID: "1234", ParentID: "01J00000000000000000000001"}, nil)
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(parent, nil)
+				m.EXPECT().GetThread(ctx, "1234", "01J00000000000000000000001", "", threadLimitMax).Return(&domain.Messages{domain.Message{ID: "01J00000000000000000000002"}}, nil)
+			},
+			wantParent:  "01J00000000000000000000001",
+			wantReplies: []string{"01J00000000000000000000002"},
+		},
+		{
+			name: "[異常系] メッセージが存在しない",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "", 50},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrParentNotFound,
+		},
+		{
+			name:    "[異常系] idの値が不正",
+			args:    args{context.Background(), "1234", "invalid", "", 50},
+			mockFn:  func(m *mock_repository.MockMessageRepo, ctx context.Context) {},
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] beforeの値が不正",
+			args:    args{context.Background(), "1234", "01J00000000000000000000001", "invalid", 50},
+			mockFn:  func(m *mock_repository.MockMessageRepo, ctx context.Context) {},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			gotParent, got, err := test.GetThread(tt.args.ctx, tt.args.roomID, tt.args.parentID, tt.args.before, tt.args.limit)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.GetThread() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("messageUsecase.GetThread() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if tt.wantErr {
+				return
+			}
+			if gotParent.ID != tt.wantParent {
+				t.Errorf("messageUsecase.GetThread() parent = %s, want %s", gotParent.ID, tt.wantParent)
+			}
+			var ids []string
+			for _, m := range *got {
+				ids = append(ids, m.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantReplies) {
+				t.Errorf("messageUsecase.GetThread() = %v, want %v", ids, tt.wantReplies)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_GetThreadSummaries(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		parentIDs []string
+	}
+	summaries := &domain.ThreadSummaries{domain.ThreadSummary{ParentID: "01J00000000000000000000001", ReplyCount: 2, LastReplyAt: time.Now(), Participants: []string{"testName"}}}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockMessageRepo, ctx context.Context, parentIDs []string)
+		want    *domain.ThreadSummaries
+		wantErr bool
+	}{
+		{
+			name: "[正常系] スレッドの概要を取得",
+			args: args{context.Background(), []string{"01J00000000000000000000001"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, parentIDs []string) {
+				m.EXPECT().GetThreadSummaries(ctx, parentIDs).Return(summaries, nil)
+			},
+			want:    summaries,
+			wantErr: false,
+		},
+		{
+			name:    "[正常系] メッセージがない",
+			args:    args{context.Background(), nil},
+			mockFn:  func(m *mock_repository.MockMessageRepo, ctx context.Context, parentIDs []string) {},
+			want:    &domain.ThreadSummaries{},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetThreadSummaries）",
+			args: args{context.Background(), []string{"01J00000000000000000000001"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, parentIDs []string) {
+				m.EXPECT().GetThreadSummaries(ctx, parentIDs).Return(nil, errors.New("test error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx, tt.args.parentIDs)
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			got, err := test.GetThreadSummaries(tt.args.ctx, tt.args.parentIDs)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.GetThreadSummaries() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("messageUsecase.GetThreadSummaries() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_Create(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		message *domain.Message
+	}
+	tests := []struct {
+		name          string
+		args          args
+		mockFn        func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message)
+		wantHTML      string
+		wantID        string
+		wantDuplicate bool
+		wantErr       bool
+	}{
+		{
+			name: "[正常系] Message作成",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", Markdown: "**test**"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().Create(ctx, message).Return(nil)
+			},
+			wantHTML: "<p><strong>test</strong></p>\n",
+			wantErr:  false,
+		},
+		{
+			name: "[正常系] scriptタグがサニタイズされる",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", Markdown: "<script>alert(1)</script>test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().Create(ctx, message).Return(nil)
+			},
+			wantHTML: "<p>test</p>\n",
+			wantErr:  false,
+		},
+		{
+			name: "[正常系] メンションがリンクになる",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", Markdown: "@other name <b>@other</b>, `@other name` @other nameX", Mentions: domain.MessageMentions{{UserID: "efgh5678", UserName: "other name"}, {UserID: "ijkl9012", UserName: "other"}}}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().Create(ctx, message).Return(nil)
+			},
+			wantHTML: "<p><a class=\"mention\" href=\"#mention-other%20name\">@other name</a> <b><a class=\"mention\" href=\"#mention-other\">@other</a></b>, <code>@other name</code> <a class=\"mention\" href=\"#mention-other\">@other</a> nameX</p>\n",
+			wantErr:  false,
+		},
+		{
+			name: "[正常系] clientid付きのMessage作成",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", ClientID: "c1", UserName: "testName", Markdown: "test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().Create(ctx, message).Return(nil)
+			},
+			wantHTML: "<p>test</p>\n",
+			wantErr:  false,
+		},
+		{
+			name: "[異常系] clientidが重複している場合は保存済みのMessageを返す",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", ClientID: "c1", UserName: "testName", Markdown: "test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().Create(ctx, message).Return(domain.ErrDuplicateMessage)
+				m.EXPECT().GetByClientID(ctx, "1234", "abcd1234", "c1").Return(&domain.Messages{domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "abcd1234", ClientID: "c1", UserName: "testName", Markdown: "test", HTML: "<p>test</p>\n"}}, nil)
+			},
+			wantID:        "01J00000000000000000000001",
+			wantDuplicate: true,
+			wantErr:       true,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetByClientID）",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", ClientID: "c1", UserName: "testName", Markdown: "test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().Create(ctx, message).Return(domain.ErrDuplicateMessage)
+				m.EXPECT().GetByClientID(ctx, "1234", "abcd1234", "c1").Return(&domain.Messages{}, errors.New("test error"))
+			},
+			wantErr: true,
+		},
+		{
+			name: "[正常系] スレッドへの返信",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", ParentID: "01J00000000000000000000001", AlsoToRoom: true, Markdown: "test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(&domain.Message{ID: "01J00000000000000000000001", RoomID: "1234"}, nil)
+				m.EXPECT().Create(ctx, message).Return(nil)
+			},
+			wantHTML: "<p>test</p>\n",
+			wantErr:  false,
+		},
+		{
+			name: "[正常系] スレッドへの返信への返信は元のメッセージへの返信になる",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", ParentID: "01J00000000000000000000002", Markdown: "test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000002").Return(&domain.Message{ID: "01J00000000000000000000002", RoomID: "1234", ParentID: "01J00000000000000000000001"}, nil)
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(&domain.Message{ID: "01J00000000000000000000001", RoomID: "1234"}, nil)
+				m.EXPECT().Create(ctx, message).DoAndReturn(func(_ context.Context, message *domain.Message) error {
+					if message.ParentID != "01J00000000000000000000001" {
+						t.Errorf("Create() ParentID = %s, want 01J00000000000000000000001", message.ParentID)
+					}
+					return nil
+				})
+			},
+			wantHTML: "<p>test</p>\n",
+			wantErr:  false,
+		},
+		{
+			name: "[異常系] 返信先のメッセージが他のRoomのもの",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", ParentID: "01J00000000000000000000001", Markdown: "test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(&domain.Message{ID: "01J00000000000000000000001", RoomID: "5678"}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] 返信先のメッセージがプライベートメッセージ",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", ParentID: "01J00000000000000000000001", Markdown: "test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(&domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", ToName: "testName"}, nil)
+			},
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] バリデーション失敗（スレッドへの返信をプライベートメッセージにする）",
+			args:    args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", ToName: "otherName", ParentID: "01J00000000000000000000001", Markdown: "test"}},
+			mockFn:  nil,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] バリデーション失敗（clientidが64文字より大きい）",
+			args:    args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", ClientID: strings.Repeat("a", 65), UserName: "testName", Markdown: "test"}},
+			mockFn:  nil,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] バリデーション失敗（RoomIDが空）",
+			args:    args{context.Background(), &domain.Message{RoomID: "", UserID: "abcd1234", UserName: "testName", Markdown: "test"}},
+			mockFn:  nil,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] バリデーション失敗（送信者が空）",
+			args:    args{context.Background(), &domain.Message{RoomID: "1234", UserID: "", UserName: "", Markdown: "test"}},
+			mockFn:  nil,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] バリデーション失敗（メッセージが空）",
+			args:    args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", Markdown: ""}},
+			mockFn:  nil,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] バリデーション失敗（メッセージが10000文字より大きい）",
+			args:    args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", Markdown: strings.Repeat("あ", 10001)}},
+			mockFn:  nil,
+			wantErr: true,
+		},
+		{
+			name: "[異常系] DB処理失敗（Create）",
+			args: args{context.Background(), &domain.Message{RoomID: "1234", UserID: "abcd1234", UserName: "testName", Markdown: "test"}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, message *domain.Message) {
+				m.EXPECT().Create(ctx, message).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			if tt.mockFn != nil {
+				tt.mockFn(mock, tt.args.ctx, tt.args.message)
+			}
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			err := test.Create(tt.args.ctx, tt.args.message)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if errors.Is(err, domain.ErrDuplicateMessage) != tt.wantDuplicate {
+				t.Errorf("messageUsecase.Create() error = %v, wantDuplicate %v", err, tt.wantDuplicate)
+			}
+			if tt.wantID != "" && tt.args.message.ID != tt.wantID {
+				t.Errorf("messageUsecase.Create() ID = %s, want %s", tt.args.message.ID, tt.wantID)
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.args.message.ID == "" {
+				t.Errorf("messageUsecase.Create() ID was not set")
+			}
+			if tt.args.message.HTML != tt.wantHTML {
+				t.Errorf("messageUsecase.Create() HTML = %q, want %q", tt.args.message.HTML, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_Edit(t *testing.T) {
+	type args struct {
+		ctx          context.Context
+		roomID       string
+		id           string
+		editorID     string
+		moderator    bool
+		markdown     string
+		participants domain.Users
+	}
+	stored := func() *domain.Message {
+		return &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "abcd1234", UserName: "testName", Markdown: "test", HTML: "<p>test</p>\n"}
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockMessageRepo, ctx context.Context)
+		wantHTML  string
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] 送信者本人による編集",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234", false, "**edited**", nil},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				m.EXPECT().UpdateWithRevision(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, message *domain.Message, revision *domain.MessageRevision) error {
+					if revision.MessageID != message.ID || revision.Markdown != "test" || revision.HTML != "<p>test</p>\n" || revision.EditorID != "abcd1234" {
+						t.Errorf("UpdateWithRevision() revision = %+v", revision)
+					}
+					return nil
+				})
+			},
+			wantHTML: "<p><strong>edited</strong></p>\n",
+		},
+		{
+			name: "[正常系] 編集でメンションが付け直される",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234", false, "@otherName hi", domain.Users{{ID: "abcd1234", Name: "testName"}, {ID: "efgh5678", Name: "otherName"}}},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				m.EXPECT().UpdateWithRevision(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, message *domain.Message, _ *domain.MessageRevision) error {
+					if len(message.Mentions) != 1 || message.Mentions[0].UserID != "efgh5678" {
+						t.Errorf("UpdateWithRevision() Mentions = %+v", message.Mentions)
+					}
+					return nil
+				})
+			},
+			wantHTML: "<p><a class=\"mention\" href=\"#mention-otherName\">@otherName</a> hi</p>\n",
+		},
+		{
+			name: "[正常系] メッセージを管理できる役割の参加者による編集",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "moderator", true, "<script>alert(1)</script>edited", nil},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				m.EXPECT().UpdateWithRevision(ctx, gomock.Any(), gomock.Any()).Return(nil)
+			},
+			wantHTML: "<p>edited</p>\n",
+		},
+		{
+			name: "[異常系] 送信者でもメッセージを管理できる役割でもない",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "other", false, "edited", nil},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrMessageForbidden,
+		},
+		{
+			name: "[異常系] メッセージを管理できる役割でも閲覧できないプライベートメッセージ",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "moderator", true, "edited", nil},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				message := stored()
+				message.ToName, message.ToUserID = "otherName", "efgh5678"
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(message, nil)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] 他のRoomのメッセージ",
+			args: args{context.Background(), "5678", "01J00000000000000000000001", "abcd1234", true, "edited", nil},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] 削除済みのメッセージ",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234", false, "edited", nil},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				message := stored()
+				message.Deleted = true
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(message, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrMessageDeleted,
+		},
+		{
+			name: "[異常系] バリデーション失敗（メッセージが10000文字より大きい）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234", false, strings.Repeat("あ", 10001), nil},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+			},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] DB処理失敗（UpdateWithRevision）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234", false, "edited", nil},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				m.EXPECT().UpdateWithRevision(ctx, gomock.Any(), gomock.Any()).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			got, err := test.Edit(tt.args.ctx, tt.args.roomID, tt.args.id, tt.args.editorID, tt.args.moderator, tt.args.markdown, tt.args.participants)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.Edit() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("messageUsecase.Edit() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if tt.wantErr {
+				return
+			}
+			if got.HTML != tt.wantHTML || got.Markdown != tt.args.markdown || !got.Edited {
+				t.Errorf("messageUsecase.Edit() = %+v, want HTML %q", got, tt.wantHTML)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_Delete(t *testing.T) {
+	type args struct {
+		ctx       context.Context
+		roomID    string
+		id        string
+		editorID  string
+		moderator bool
+	}
+	stored := func() *domain.Message {
+		return &domain.Message{ID: "01J00000000000000000000001", RoomID: "1234", UserID: "abcd1234", UserName: "testName", Markdown: "test", HTML: "<p>test</p>\n"}
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockMessageRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] 送信者本人による削除",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234", false},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				m.EXPECT().UpdateWithRevision(ctx, gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, message *domain.Message, revision *domain.MessageRevision) error {
+					if revision.Markdown != "test" || revision.EditorID != "abcd1234" {
+						t.Errorf("UpdateWithRevision() revision = %+v", revision)
+					}
+					return nil
+				})
+			},
+		},
+		{
+			name: "[正常系] メッセージを管理できる役割の参加者による削除",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "moderator", true},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				m.EXPECT().UpdateWithRevision(ctx, gomock.Any(), gomock.Any()).Return(nil)
+			},
+		},
+		{
+			name: "[異常系] 送信者でもメッセージを管理できる役割でもない",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "other", false},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrMessageForbidden,
+		},
+		{
+			name: "[異常系] メッセージを管理できる役割でも閲覧できないプライベートメッセージ",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "moderator", true},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				message := stored()
+				message.ToName, message.ToUserID = "otherName", "efgh5678"
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(message, nil)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] メッセージが存在しない",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234", false},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] DB処理失敗（UpdateWithRevision）",
+			args: args{context.Background(), "1234", "01J00000000000000000000001", "abcd1234", false},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(stored(), nil)
+				m.EXPECT().UpdateWithRevision(ctx, gomock.Any(), gomock.Any()).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			got, err := test.Delete(tt.args.ctx, tt.args.roomID, tt.args.id, tt.args.editorID, tt.args.moderator)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.Delete() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("messageUsecase.Delete() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !got.Deleted || got.Markdown != "" || got.HTML != "" {
+				t.Errorf("messageUsecase.Delete() = %+v", got)
+			}
+		})
+	}
+}
+
+func Test_messageUsecase_DeleteByRoomID(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		roomID string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID string)
+		wantErr bool
+	}{
+		{
+			name: "[正常系] RoomID指定でのMessage削除",
+			args: args{context.Background(), "1234"},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID string) {
+				m.EXPECT().DeleteByRoomID(ctx, roomID).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗（DeleteByRoomID）",
+			args: args{context.Background(), "1234"},
+			mockFn: func(m *mock_repository.MockMessageRepo, ctx context.Context, roomID string) {
+				m.EXPECT().DeleteByRoomID(ctx, roomID).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockMessageRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx, tt.args.roomID)
+
+			test := &messageUsecase{
+				repo: mock,
+			}
+			if err := test.DeleteByRoomID(tt.args.ctx, tt.args.roomID); (err != nil) != tt.wantErr {
+				t.Errorf("messageUsecase.DeleteByRoomID() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -2,6 +2,7 @@ package usecase
 
 import (
 	"context"
+	"errors"
 	"time"
 
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
@@ -20,6 +21,9 @@ type ParticipatingRoomUsecase interface {
 	DeleteByRoomID(ctx context.Context, roomID string) error
 	DeleteByUserIDAndRoomID(ctx context.Context, userID, roomID string) error
 	GetUsersByRoomID(ctx context.Context, roomID string) (*domain.Users, error)
+	GetActivitiesByUserID(ctx context.Context, userID string) (*domain.RoomActivities, error)
+	UpdateLastReadSeq(ctx context.Context, userID, roomID string, seq int64) error
+	GetDirectPeersByUserID(ctx context.Context, userID string) (*domain.DirectPeers, error)
 }
 
 type participatingRoomUsecase struct {
@@ -69,3 +73,19 @@ func (u *participatingRoomUsecase) DeleteByUserIDAndRoomID(ctx context.Context,
 func (u *participatingRoomUsecase) GetUsersByRoomID(ctx context.Context, roomID string) (*domain.Users, error) {
 	return u.repo.GetUsersByRoomID(ctx, roomID)
 }
+
+func (u *participatingRoomUsecase) GetActivitiesByUserID(ctx context.Context, userID string) (*domain.RoomActivities, error) {
+	return u.repo.GetActivitiesByUserID(ctx, userID)
+}
+
+func (u *participatingRoomUsecase) UpdateLastReadSeq(ctx context.Context, userID, roomID string, seq int64) error {
+	if seq <= 0 {
+		return errors.New("seq の値が不正です。")
+	}
+
+	return u.repo.UpdateLastReadSeq(ctx, userID, roomID, seq)
+}
+
+func (u *participatingRoomUsecase) GetDirectPeersByUserID(ctx context.Context, userID string) (*domain.DirectPeers, error) {
+	return u.repo.GetDirectPeersByUserID(ctx, userID)
+}
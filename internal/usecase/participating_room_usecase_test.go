@@ -31,7 +31,7 @@ func Test_participatingRoomUsecase_GetAll(t *testing.T) {
 				m.EXPECT().GetAll(ctx).Return(&domain.ParticipatingRooms{domain.ParticipatingRoom{
 					ID:        1,
 					RoomID:    "1234",
-					IsMaster:  true,
+					Role:      domain.RoleOwner,
 					UserID:    "abcd1234",
 					User:      domain.User{ID: "abcd1234", Name: "testName", Password: "testPass", CreatedAt: testTime, UpdatedAt: testTime},
 					CreatedAt: testTime,
@@ -41,7 +41,7 @@ func Test_participatingRoomUsecase_GetAll(t *testing.T) {
 			want: &domain.ParticipatingRooms{domain.ParticipatingRoom{
 				ID:        1,
 				RoomID:    "1234",
-				IsMaster:  true,
+				Role:      domain.RoleOwner,
 				UserID:    "abcd1234",
 				User:      domain.User{ID: "abcd1234", Name: "testName", Password: "testPass", CreatedAt: testTime, UpdatedAt: testTime},
 				CreatedAt: testTime,
@@ -103,7 +103,7 @@ func Test_participatingRoomUsecase_GetByUserID(t *testing.T) {
 				m.EXPECT().GetByUserID(ctx, userID).Return(&domain.ParticipatingRooms{domain.ParticipatingRoom{
 					ID:        1,
 					RoomID:    "1234",
-					IsMaster:  true,
+					Role:      domain.RoleOwner,
 					UserID:    "abcd1234",
 					User:      domain.User{ID: "abcd1234", Name: "testName", Password: "testPass", CreatedAt: testTime, UpdatedAt: testTime},
 					CreatedAt: testTime,
@@ -113,7 +113,7 @@ func Test_participatingRoomUsecase_GetByUserID(t *testing.T) {
 			want: &domain.ParticipatingRooms{domain.ParticipatingRoom{
 				ID:        1,
 				RoomID:    "1234",
-				IsMaster:  true,
+				Role:      domain.RoleOwner,
 				UserID:    "abcd1234",
 				User:      domain.User{ID: "abcd1234", Name: "testName", Password: "testPass", CreatedAt: testTime, UpdatedAt: testTime},
 				CreatedAt: testTime,
@@ -175,7 +175,7 @@ func Test_participatingRoomUsecase_GetByRoomID(t *testing.T) {
 				m.EXPECT().GetByRoomID(ctx, roomID).Return(&domain.ParticipatingRooms{domain.ParticipatingRoom{
 					ID:        1,
 					RoomID:    "1234",
-					IsMaster:  true,
+					Role:      domain.RoleOwner,
 					UserID:    "abcd1234",
 					User:      domain.User{ID: "abcd1234", Name: "testName", Password: "testPass", CreatedAt: testTime, UpdatedAt: testTime},
 					CreatedAt: testTime,
@@ -185,7 +185,7 @@ func Test_participatingRoomUsecase_GetByRoomID(t *testing.T) {
 			want: &domain.ParticipatingRooms{domain.ParticipatingRoom{
 				ID:        1,
 				RoomID:    "1234",
-				IsMaster:  true,
+				Role:      domain.RoleOwner,
 				UserID:    "abcd1234",
 				User:      domain.User{ID: "abcd1234", Name: "testName", Password: "testPass", CreatedAt: testTime, UpdatedAt: testTime},
 				CreatedAt: testTime,
@@ -248,7 +248,7 @@ func Test_participatingRoomUsecase_GetByUserIDAndRoomID(t *testing.T) {
 				m.EXPECT().GetByUserIDAndRoomID(ctx, userID, roomID).Return(&domain.ParticipatingRoom{
 					ID:        1,
 					RoomID:    "1234",
-					IsMaster:  true,
+					Role:      domain.RoleOwner,
 					UserID:    "abcd1234",
 					User:      domain.User{ID: "abcd1234", Name: "testName", Password: "testPass", CreatedAt: testTime, UpdatedAt: testTime},
 					CreatedAt: testTime,
@@ -258,7 +258,7 @@ func Test_participatingRoomUsecase_GetByUserIDAndRoomID(t *testing.T) {
 			want: &domain.ParticipatingRoom{
 				ID:        1,
 				RoomID:    "1234",
-				IsMaster:  true,
+				Role:      domain.RoleOwner,
 				UserID:    "abcd1234",
 				User:      domain.User{ID: "abcd1234", Name: "testName", Password: "testPass", CreatedAt: testTime, UpdatedAt: testTime},
 				CreatedAt: testTime,
@@ -313,7 +313,7 @@ func Test_participatingRoomUsecase_Create(t *testing.T) {
 	}{
 		{
 			name: "[正常系] ParticipatingRoom作成",
-			args: args{context.Background(), &domain.ParticipatingRoom{RoomID: "1234", IsMaster: true, UserID: "abcd1234"}},
+			args: args{context.Background(), &domain.ParticipatingRoom{RoomID: "1234", Role: domain.RoleOwner, UserID: "abcd1234"}},
 			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context, participatingRoom *domain.ParticipatingRoom) {
 				m.EXPECT().Create(ctx, participatingRoom).Return(nil)
 			},
@@ -321,7 +321,7 @@ func Test_participatingRoomUsecase_Create(t *testing.T) {
 		},
 		{
 			name: "[異常系] DB処理失敗（Create）",
-			args: args{context.Background(), &domain.ParticipatingRoom{RoomID: "1234", IsMaster: true, UserID: "abcd1234"}},
+			args: args{context.Background(), &domain.ParticipatingRoom{RoomID: "1234", Role: domain.RoleOwner, UserID: "abcd1234"}},
 			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context, participatingRoom *domain.ParticipatingRoom) {
 				m.EXPECT().Create(ctx, participatingRoom).Return(errors.New("test error"))
 			},
@@ -544,3 +544,170 @@ func Test_participatingRoomUsecase_GetUsersByRoomID(t *testing.T) {
 		})
 	}
 }
+
+func Test_participatingRoomUsecase_GetActivitiesByUserID(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userID string
+	}
+	testTime := time.Now()
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context)
+		want    *domain.RoomActivities
+		wantErr bool
+	}{
+		{
+			name: "[正常系] 参加中のRoomの未読数と最後の発言日時取得",
+			args: args{context.Background(), "abcd1234"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetActivitiesByUserID(ctx, "abcd1234").Return(&domain.RoomActivities{domain.RoomActivity{RoomID: "1234", UnreadCount: 3, LastActivityAt: &testTime}, domain.RoomActivity{RoomID: "5678"}}, nil)
+			},
+			want:    &domain.RoomActivities{domain.RoomActivity{RoomID: "1234", UnreadCount: 3, LastActivityAt: &testTime}, domain.RoomActivity{RoomID: "5678"}},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetActivitiesByUserID）",
+			args: args{context.Background(), "abcd1234"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetActivitiesByUserID(ctx, "abcd1234").Return(&domain.RoomActivities{}, errors.New("test error"))
+			},
+			want:    &domain.RoomActivities{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &participatingRoomUsecase{
+				repo: mock,
+			}
+			got, err := test.GetActivitiesByUserID(tt.args.ctx, tt.args.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("participatingRoomUsecase.GetActivitiesByUserID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("participatingRoomUsecase.GetActivitiesByUserID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_participatingRoomUsecase_UpdateLastReadSeq(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userID string
+		roomID string
+		seq    int64
+	}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context)
+		wantErr bool
+	}{
+		{
+			name: "[正常系] 既読のseqを進める",
+			args: args{context.Background(), "abcd1234", "1234", 10},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().UpdateLastReadSeq(ctx, "abcd1234", "1234", int64(10)).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:    "[異常系] seqが0以下",
+			args:    args{context.Background(), "abcd1234", "1234", 0},
+			mockFn:  func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] DB処理失敗（UpdateLastReadSeq）",
+			args: args{context.Background(), "abcd1234", "1234", 10},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().UpdateLastReadSeq(ctx, "abcd1234", "1234", int64(10)).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &participatingRoomUsecase{
+				repo: mock,
+			}
+			err := test.UpdateLastReadSeq(tt.args.ctx, tt.args.userID, tt.args.roomID, tt.args.seq)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("participatingRoomUsecase.UpdateLastReadSeq() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func Test_participatingRoomUsecase_GetDirectPeersByUserID(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userID string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context)
+		want    *domain.DirectPeers
+		wantErr bool
+	}{
+		{
+			name: "[正常系] ダイレクトメッセージの相手取得",
+			args: args{context.Background(), "abcd1234"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetDirectPeersByUserID(ctx, "abcd1234").Return(&domain.DirectPeers{domain.DirectPeer{RoomID: "01J00000000000000000000001", UserID: "efgh5678", UserName: "otherName"}}, nil)
+			},
+			want:    &domain.DirectPeers{domain.DirectPeer{RoomID: "01J00000000000000000000001", UserID: "efgh5678", UserName: "otherName"}},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetDirectPeersByUserID）",
+			args: args{context.Background(), "abcd1234"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetDirectPeersByUserID(ctx, "abcd1234").Return(&domain.DirectPeers{}, errors.New("test error"))
+			},
+			want:    &domain.DirectPeers{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &participatingRoomUsecase{
+				repo: mock,
+			}
+			got, err := test.GetDirectPeersByUserID(tt.args.ctx, tt.args.userID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("participatingRoomUsecase.GetDirectPeersByUserID() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("participatingRoomUsecase.GetDirectPeersByUserID() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
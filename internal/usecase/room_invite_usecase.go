@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/repository"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/signedtoken"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/ulid"
+	"gorm.io/gorm"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/room_invite_mock.go -package=mock_$GOPACKAGE
+
+type RoomInviteUsecase interface {
+	GetActiveByRoomID(ctx context.Context, roomID string) (*domain.RoomInvites, error)
+	Create(ctx context.Context, roomID, userID string, ttl time.Duration, maxUses int) (*domain.RoomInvite, error)
+	Token(invite *domain.RoomInvite) string
+	Verify(ctx context.Context, token string) (*domain.RoomInvite, error)
+	Use(ctx context.Context, invite *domain.RoomInvite) error
+	Delete(ctx context.Context, roomID, id string) error
+}
+
+type roomInviteUsecase struct {
+	repo   repository.RoomInviteRepo
+	signer *signedtoken.Signer // 招待リンクのトークンの署名
+}
+
+func NewRoomInviteUsecase(repo repository.RoomInviteRepo, signer *signedtoken.Signer) RoomInviteUsecase {
+	return &roomInviteUsecase{repo: repo, signer: signer}
+}
+
+func (u *roomInviteUsecase) GetActiveByRoomID(ctx context.Context, roomID string) (*domain.RoomInvites, error) {
+	return u.repo.GetActiveByRoomID(ctx, roomID, time.Now())
+}
+
+// 有効期間と使用回数の上限を指定して招待リンクを作成する。maxUsesが0なら無制限
+func (u *roomInviteUsecase) Create(ctx context.Context, roomID, userID string, ttl time.Duration, maxUses int) (*domain.RoomInvite, error) {
+	now := time.Now()
+	invite := &domain.RoomInvite{
+		ID:        ulid.NewULID(),
+		RoomID:    roomID,
+		UserID:    userID,
+		MaxUses:   maxUses,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	err := invite.Validate(ttl)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.repo.Create(ctx, invite)
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// 招待リンクのURLに含める署名付きのトークン
+func (u *roomInviteUsecase) Token(invite *domain.RoomInvite) string {
+	return u.signer.Sign(invite.ID)
+}
+
+// トークンの署名を確認し、今使える招待リンクを返す
+func (u *roomInviteUsecase) Verify(ctx context.Context, token string) (*domain.RoomInvite, error) {
+	id, err := u.signer.Verify(token)
+	if err != nil {
+		return nil, domain.ErrInvalidInvite
+	}
+
+	invite, err := u.repo.GetByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, domain.ErrInvalidInvite
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	err = invite.Usable(time.Now())
+	if err != nil {
+		return nil, err
+	}
+	return invite, nil
+}
+
+// 招待リンクの使用回数を1増やす。確認してから使うまでの間に使えなくなっていればその理由のエラーを返す
+func (u *roomInviteUsecase) Use(ctx context.Context, invite *domain.RoomInvite) error {
+	now := time.Now()
+	used, err := u.repo.IncrementUses(ctx, invite.ID, now)
+	if err != nil {
+		return err
+	}
+	if *used {
+		return nil
+	}
+
+	latest, err := u.repo.GetByID(ctx, invite.ID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return domain.ErrInvalidInvite
+	}
+	if err != nil {
+		return err
+	}
+	err = latest.Usable(now)
+	if err != nil {
+		return err
+	}
+	return domain.ErrInviteUsedUp
+}
+
+func (u *roomInviteUsecase) Delete(ctx context.Context, roomID, id string) error {
+	return u.repo.Delete(ctx, roomID, id)
+}
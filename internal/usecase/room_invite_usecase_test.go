@@ -0,0 +1,254 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	mock_repository "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/repository"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/signedtoken"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/ulid"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func Test_roomInviteUsecase_Create(t *testing.T) {
+	type args struct {
+		ctx     context.Context
+		roomID  string
+		userID  string
+		ttl     time.Duration
+		maxUses int
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockRoomInviteRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] 招待リンク作成",
+			args: args{context.Background(), "01J00000000000000000000001", "abcd1234", time.Hour, 5},
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, invite *domain.RoomInvite) error {
+					if !ulid.IsValid(invite.ID) || invite.RoomID != "01J00000000000000000000001" || invite.UserID != "abcd1234" || invite.MaxUses != 5 || invite.ExpiresAt.Sub(invite.CreatedAt) != time.Hour {
+						t.Errorf("Create() invite = %+v", invite)
+					}
+					return nil
+				})
+			},
+			wantErr: false,
+		},
+		{
+			name:      "[異常系] 有効期間が長すぎる",
+			args:      args{context.Background(), "01J00000000000000000000001", "abcd1234", domain.RoomInviteTTLMax + time.Hour, 0},
+			mockFn:    func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidInviteSetting,
+		},
+		{
+			name:      "[異常系] 使用回数の上限が負",
+			args:      args{context.Background(), "01J00000000000000000000001", "abcd1234", time.Hour, -1},
+			mockFn:    func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidInviteSetting,
+		},
+		{
+			name: "[異常系] DB処理失敗（Create）",
+			args: args{context.Background(), "01J00000000000000000000001", "abcd1234", time.Hour, 0},
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().Create(ctx, gomock.Any()).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockRoomInviteRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &roomInviteUsecase{
+				repo:   mock,
+				signer: signedtoken.New([]byte("test key")),
+			}
+			_, err := test.Create(tt.args.ctx, tt.args.roomID, tt.args.userID, tt.args.ttl, tt.args.maxUses)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomInviteUsecase.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomInviteUsecase.Create() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
+
+func Test_roomInviteUsecase_Verify(t *testing.T) {
+	signer := signedtoken.New([]byte("test key"))
+	usable := func() *domain.RoomInvite {
+		return &domain.RoomInvite{ID: "01J00000000000000000000009", RoomID: "01J00000000000000000000001", MaxUses: 2, Uses: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	}
+	tests := []struct {
+		name      string
+		token     string
+		mockFn    func(m *mock_repository.MockRoomInviteRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name:  "[正常系] 使える招待リンク",
+			token: signer.Sign("01J00000000000000000000009"),
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(usable(), nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "[異常系] 署名が一致しない",
+			token:     signedtoken.New([]byte("other key")).Sign("01J00000000000000000000009"),
+			mockFn:    func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidInvite,
+		},
+		{
+			name:  "[異常系] 無効にされた招待リンク",
+			token: signer.Sign("01J00000000000000000000009"),
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(&domain.RoomInvite{}, gorm.ErrRecordNotFound)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidInvite,
+		},
+		{
+			name:  "[異常系] 有効期限切れ",
+			token: signer.Sign("01J00000000000000000000009"),
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				invite := usable()
+				invite.ExpiresAt = time.Now().Add(-time.Minute)
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(invite, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrInviteExpired,
+		},
+		{
+			name:  "[異常系] 使用回数の上限",
+			token: signer.Sign("01J00000000000000000000009"),
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				invite := usable()
+				invite.Uses = 2
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(invite, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrInviteUsedUp,
+		},
+		{
+			name:  "[異常系] DB処理失敗（GetByID）",
+			token: signer.Sign("01J00000000000000000000009"),
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(&domain.RoomInvite{}, errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockRoomInviteRepo(ctrl)
+			ctx := context.Background()
+
+			tt.mockFn(mock, ctx)
+
+			test := &roomInviteUsecase{
+				repo:   mock,
+				signer: signer,
+			}
+			got, err := test.Verify(ctx, tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomInviteUsecase.Verify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomInviteUsecase.Verify() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !tt.wantErr && got.ID != "01J00000000000000000000009" {
+				t.Errorf("roomInviteUsecase.Verify() = %+v", got)
+			}
+		})
+	}
+}
+
+func Test_roomInviteUsecase_Use(t *testing.T) {
+	invite := &domain.RoomInvite{ID: "01J00000000000000000000009", RoomID: "01J00000000000000000000001", MaxUses: 1, ExpiresAt: time.Now().Add(time.Hour)}
+	used, notUsed := true, false
+	tests := []struct {
+		name      string
+		mockFn    func(m *mock_repository.MockRoomInviteRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] 使用回数を増やす",
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().IncrementUses(ctx, "01J00000000000000000000009", gomock.Any()).Return(&used, nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] 確認後に他のユーザーが使い切った",
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().IncrementUses(ctx, "01J00000000000000000000009", gomock.Any()).Return(&notUsed, nil)
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(&domain.RoomInvite{ID: "01J00000000000000000000009", MaxUses: 1, Uses: 1, ExpiresAt: time.Now().Add(time.Hour)}, nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrInviteUsedUp,
+		},
+		{
+			name: "[異常系] 確認後に無効にされた",
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().IncrementUses(ctx, "01J00000000000000000000009", gomock.Any()).Return(&notUsed, nil)
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000009").Return(&domain.RoomInvite{}, gorm.ErrRecordNotFound)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidInvite,
+		},
+		{
+			name: "[異常系] DB処理失敗（IncrementUses）",
+			mockFn: func(m *mock_repository.MockRoomInviteRepo, ctx context.Context) {
+				m.EXPECT().IncrementUses(ctx, "01J00000000000000000000009", gomock.Any()).Return(&notUsed, errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockRoomInviteRepo(ctrl)
+			ctx := context.Background()
+
+			tt.mockFn(mock, ctx)
+
+			test := &roomInviteUsecase{
+				repo: mock,
+			}
+			err := test.Use(ctx, invite)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomInviteUsecase.Use() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomInviteUsecase.Use() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/repository"
+)
+
+//go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/room_role_mock.go -package=mock_$GOPACKAGE
+
+type RoomRoleUsecase interface {
+	Authorize(ctx context.Context, userID, roomID string, perm domain.Permission) (*domain.ParticipatingRoom, error)
+	GetMembers(ctx context.Context, roomID string) (*domain.ParticipatingRooms, error)
+	ChangeRole(ctx context.Context, actorID, roomID, targetID, role string) (*domain.ParticipatingRoom, error)
+	Kick(ctx context.Context, actorID, roomID, targetID string) error
+	TransferOwnership(ctx context.Context, ownerID, roomID, targetID string) (*domain.ParticipatingRoom, error)
+	Succeed(ctx context.Context, ownerID, roomID string) (*domain.ParticipatingRoom, error)
+}
+
+type roomRoleUsecase struct {
+	repo repository.ParticipatingRoomRepo
+}
+
+func NewRoomRoleUsecase(repo repository.ParticipatingRoomRepo) RoomRoleUsecase {
+	return &roomRoleUsecase{repo: repo}
+}
+
+// userがRoomで操作を行えるか確認する。参加していなければErrRecordNotFound、役割で許可されていなければErrForbidden
+func (u *roomRoleUsecase) Authorize(ctx context.Context, userID, roomID string, perm domain.Permission) (*domain.ParticipatingRoom, error) {
+	proom, err := u.repo.GetByUserIDAndRoomID(ctx, userID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !proom.Can(perm) {
+		return nil, domain.ErrForbidden
+	}
+
+	return proom, nil
+}
+
+func (u *roomRoleUsecase) GetMembers(ctx context.Context, roomID string) (*domain.ParticipatingRooms, error) {
+	return u.repo.GetMembersByRoomID(ctx, roomID)
+}
+
+// actorがtargetの役割を変更する
+// 自分の役割は変更できず、自分より弱い役割の参加者を、自分より弱い役割にのみ変更できる。作成者への変更は所有権の移譲として扱うためできない
+func (u *roomRoleUsecase) ChangeRole(ctx context.Context, actorID, roomID, targetID, role string) (*domain.ParticipatingRoom, error) {
+	if !domain.ValidRole(role) || role == domain.RoleOwner {
+		return nil, domain.ErrInvalidRole
+	}
+
+	actor, target, err := u.getActorAndTarget(ctx, actorID, roomID, targetID, domain.PermManageRoles)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actor.Outranks(role) {
+		return nil, domain.ErrForbidden
+	}
+
+	err = u.repo.UpdateRole(ctx, targetID, roomID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	target.Role = role
+	return target, nil
+}
+
+// actorがtargetをRoomから退出させる。自分より弱い役割の参加者のみ退出させられる
+func (u *roomRoleUsecase) Kick(ctx context.Context, actorID, roomID, targetID string) error {
+	_, _, err := u.getActorAndTarget(ctx, actorID, roomID, targetID, domain.PermKick)
+	if err != nil {
+		return err
+	}
+
+	return u.repo.DeleteByUserIDAndRoomID(ctx, targetID, roomID)
+}
+
+// 作成者がRoomの他の参加者に作成者の役割を譲る。元の作成者は管理者になる
+func (u *roomRoleUsecase) TransferOwnership(ctx context.Context, ownerID, roomID, targetID string) (*domain.ParticipatingRoom, error) {
+	_, err := u.Authorize(ctx, ownerID, roomID, domain.PermTransferOwner)
+	if err != nil {
+		return nil, err
+	}
+
+	if ownerID == targetID {
+		return nil, domain.ErrInvalidRole
+	}
+
+	target, err := u.repo.GetByUserIDAndRoomID(ctx, targetID, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.repo.TransferOwnership(ctx, roomID, ownerID, targetID)
+	if err != nil {
+		return nil, err
+	}
+
+	target.Role = domain.RoleOwner
+	return target, nil
+}
+
+// 作成者がいなくなるRoomで、作成者を引き継ぐ参加者を選んで作成者にする
+// 引き継げる参加者がいなければnilを返す
+func (u *roomRoleUsecase) Succeed(ctx context.Context, ownerID, roomID string) (*domain.ParticipatingRoom, error) {
+	members, err := u.repo.GetMembersByRoomID(ctx, roomID)
+	if err != nil {
+		return nil, err
+	}
+
+	successor := members.Successor()
+	if successor == nil {
+		return nil, nil
+	}
+
+	err = u.repo.TransferOwnership(ctx, roomID, ownerID, successor.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	successor.Role = domain.RoleOwner
+	return successor, nil
+}
+
+// actorが操作を許可されていて、自分以外の自分より弱い役割のtargetに対して操作できるか確認する
+func (u *roomRoleUsecase) getActorAndTarget(ctx context.Context, actorID, roomID, targetID string, perm domain.Permission) (*domain.ParticipatingRoom, *domain.ParticipatingRoom, error) {
+	actor, err := u.Authorize(ctx, actorID, roomID, perm)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if actorID == targetID {
+		return nil, nil, domain.ErrForbidden
+	}
+
+	target, err := u.repo.GetByUserIDAndRoomID(ctx, targetID, roomID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !actor.Outranks(target.Role) {
+		return nil, nil, domain.ErrForbidden
+	}
+
+	return actor, target, nil
+}
@@ -0,0 +1,498 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
+	mock_repository "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/repository"
+	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
+)
+
+func Test_roomRoleUsecase_Authorize(t *testing.T) {
+	type args struct {
+		ctx  context.Context
+		role string
+		perm domain.Permission
+	}
+	allowed := map[string][]domain.Permission{
+		domain.RoleOwner:     {domain.PermPost, domain.PermDeleteMessages, domain.PermPin, domain.PermKick, domain.PermInvite, domain.PermEditSettings, domain.PermManageRoles, domain.PermDeleteRoom, domain.PermTransferOwner},
+		domain.RoleAdmin:     {domain.PermPost, domain.PermDeleteMessages, domain.PermPin, domain.PermKick, domain.PermInvite, domain.PermEditSettings, domain.PermManageRoles},
+		domain.RoleModerator: {domain.PermPost, domain.PermDeleteMessages, domain.PermPin, domain.PermKick},
+		domain.RoleMember:    {domain.PermPost},
+		domain.RoleReadOnly:  {},
+	}
+	roles := []string{domain.RoleOwner, domain.RoleAdmin, domain.RoleModerator, domain.RoleMember, domain.RoleReadOnly}
+	perms := []domain.Permission{domain.PermPost, domain.PermDeleteMessages, domain.PermPin, domain.PermKick, domain.PermInvite, domain.PermEditSettings, domain.PermManageRoles, domain.PermDeleteRoom, domain.PermTransferOwner}
+
+	type testCase struct {
+		name      string
+		args      args
+		wantErrIs error
+	}
+	// 役割と操作のすべての組み合わせ
+	var tests []testCase
+	for _, role := range roles {
+		for _, perm := range perms {
+			tc := testCase{name: "[異常系] " + role + "は" + string(perm) + "できない", args: args{context.Background(), role, perm}, wantErrIs: domain.ErrForbidden}
+			for _, p := range allowed[role] {
+				if p == perm {
+					tc = testCase{name: "[正常系] " + role + "は" + string(perm) + "できる", args: args{context.Background(), role, perm}}
+				}
+			}
+			tests = append(tests, tc)
+		}
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+			mock.EXPECT().GetByUserIDAndRoomID(tt.args.ctx, "abcd1234", "1234").Return(&domain.ParticipatingRoom{RoomID: "1234", UserID: "abcd1234", Role: tt.args.role}, nil)
+
+			test := &roomRoleUsecase{
+				repo: mock,
+			}
+			got, err := test.Authorize(tt.args.ctx, "abcd1234", "1234", tt.args.perm)
+			if !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomRoleUsecase.Authorize() error = %v, want %v", err, tt.wantErrIs)
+				return
+			}
+			if tt.wantErrIs == nil && got.Role != tt.args.role {
+				t.Errorf("roomRoleUsecase.Authorize() = %+v, want role %s", got, tt.args.role)
+			}
+		})
+	}
+
+	t.Run("[異常系] Roomに参加していない", func(t *testing.T) {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+
+		ctx := context.Background()
+		mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+		mock.EXPECT().GetByUserIDAndRoomID(ctx, "abcd1234", "1234").Return(nil, gorm.ErrRecordNotFound)
+
+		test := &roomRoleUsecase{
+			repo: mock,
+		}
+		_, err := test.Authorize(ctx, "abcd1234", "1234", domain.PermPost)
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			t.Errorf("roomRoleUsecase.Authorize() error = %v, want %v", err, gorm.ErrRecordNotFound)
+		}
+	})
+}
+
+func Test_roomRoleUsecase_ChangeRole(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		actorID  string
+		targetID string
+		role     string
+	}
+	member := func(userID, role string) *domain.ParticipatingRoom {
+		return &domain.ParticipatingRoom{RoomID: "1234", UserID: userID, Role: role}
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] 作成者がメンバーを管理者にする",
+			args: args{context.Background(), "owner", "target", domain.RoleAdmin},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleMember), nil)
+				m.EXPECT().UpdateRole(ctx, "target", "1234", domain.RoleAdmin).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "[正常系] 管理者がモデレーターを閲覧のみにする",
+			args: args{context.Background(), "admin", "target", domain.RoleReadOnly},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "admin", "1234").Return(member("admin", domain.RoleAdmin), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleModerator), nil)
+				m.EXPECT().UpdateRole(ctx, "target", "1234", domain.RoleReadOnly).Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name:      "[異常系] 存在しない役割",
+			args:      args{context.Background(), "owner", "target", "king"},
+			mockFn:    func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidRole,
+		},
+		{
+			name:      "[異常系] 作成者への変更",
+			args:      args{context.Background(), "owner", "target", domain.RoleOwner},
+			mockFn:    func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidRole,
+		},
+		{
+			name: "[異常系] モデレーターは役割を変更できない",
+			args: args{context.Background(), "moderator", "target", domain.RoleReadOnly},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "moderator", "1234").Return(member("moderator", domain.RoleModerator), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrForbidden,
+		},
+		{
+			name: "[異常系] 自分の役割の変更",
+			args: args{context.Background(), "admin", "admin", domain.RoleMember},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "admin", "1234").Return(member("admin", domain.RoleAdmin), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrForbidden,
+		},
+		{
+			name: "[異常系] 同じ役割の参加者の変更",
+			args: args{context.Background(), "admin", "target", domain.RoleMember},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "admin", "1234").Return(member("admin", domain.RoleAdmin), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleAdmin), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrForbidden,
+		},
+		{
+			name: "[異常系] 自分と同じ役割への変更",
+			args: args{context.Background(), "admin", "target", domain.RoleAdmin},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "admin", "1234").Return(member("admin", domain.RoleAdmin), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleMember), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrForbidden,
+		},
+		{
+			name: "[異常系] 変更対象がRoomに参加していない",
+			args: args{context.Background(), "owner", "target", domain.RoleAdmin},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] DB処理失敗（UpdateRole）",
+			args: args{context.Background(), "owner", "target", domain.RoleAdmin},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleMember), nil)
+				m.EXPECT().UpdateRole(ctx, "target", "1234", domain.RoleAdmin).Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &roomRoleUsecase{
+				repo: mock,
+			}
+			got, err := test.ChangeRole(tt.args.ctx, tt.args.actorID, "1234", tt.args.targetID, tt.args.role)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomRoleUsecase.ChangeRole() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomRoleUsecase.ChangeRole() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !tt.wantErr && got.Role != tt.args.role {
+				t.Errorf("roomRoleUsecase.ChangeRole() = %+v, want role %s", got, tt.args.role)
+			}
+		})
+	}
+}
+
+func Test_roomRoleUsecase_Kick(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		actorID  string
+		targetID string
+	}
+	member := func(userID, role string) *domain.ParticipatingRoom {
+		return &domain.ParticipatingRoom{RoomID: "1234", UserID: userID, Role: role}
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] モデレーターがメンバーを退出させる",
+			args: args{context.Background(), "moderator", "target"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "moderator", "1234").Return(member("moderator", domain.RoleModerator), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleMember), nil)
+				m.EXPECT().DeleteByUserIDAndRoomID(ctx, "target", "1234").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] メンバーは退出させられない",
+			args: args{context.Background(), "member", "target"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "member", "1234").Return(member("member", domain.RoleMember), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrForbidden,
+		},
+		{
+			name: "[異常系] 自分を退出させる",
+			args: args{context.Background(), "moderator", "moderator"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "moderator", "1234").Return(member("moderator", domain.RoleModerator), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrForbidden,
+		},
+		{
+			name: "[異常系] 自分より強い役割の参加者",
+			args: args{context.Background(), "moderator", "owner"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "moderator", "1234").Return(member("moderator", domain.RoleModerator), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrForbidden,
+		},
+		{
+			name: "[異常系] DB処理失敗（DeleteByUserIDAndRoomID）",
+			args: args{context.Background(), "owner", "target"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleReadOnly), nil)
+				m.EXPECT().DeleteByUserIDAndRoomID(ctx, "target", "1234").Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &roomRoleUsecase{
+				repo: mock,
+			}
+			err := test.Kick(tt.args.ctx, tt.args.actorID, "1234", tt.args.targetID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomRoleUsecase.Kick() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomRoleUsecase.Kick() error = %v, want %v", err, tt.wantErrIs)
+			}
+		})
+	}
+}
+
+func Test_roomRoleUsecase_TransferOwnership(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		ownerID  string
+		targetID string
+	}
+	member := func(userID, role string) *domain.ParticipatingRoom {
+		return &domain.ParticipatingRoom{RoomID: "1234", UserID: userID, Role: role}
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] 作成者がメンバーに譲る",
+			args: args{context.Background(), "owner", "target"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleMember), nil)
+				m.EXPECT().TransferOwnership(ctx, "1234", "owner", "target").Return(nil)
+			},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] 管理者は譲れない",
+			args: args{context.Background(), "admin", "target"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "admin", "1234").Return(member("admin", domain.RoleAdmin), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrForbidden,
+		},
+		{
+			name: "[異常系] 自分に譲る",
+			args: args{context.Background(), "owner", "owner"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+			},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidRole,
+		},
+		{
+			name: "[異常系] 譲る相手がRoomに参加していない",
+			args: args{context.Background(), "owner", "target"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(nil, gorm.ErrRecordNotFound)
+			},
+			wantErr:   true,
+			wantErrIs: gorm.ErrRecordNotFound,
+		},
+		{
+			name: "[異常系] DB処理失敗（TransferOwnership）",
+			args: args{context.Background(), "owner", "target"},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "owner", "1234").Return(member("owner", domain.RoleOwner), nil)
+				m.EXPECT().GetByUserIDAndRoomID(ctx, "target", "1234").Return(member("target", domain.RoleMember), nil)
+				m.EXPECT().TransferOwnership(ctx, "1234", "owner", "target").Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &roomRoleUsecase{
+				repo: mock,
+			}
+			got, err := test.TransferOwnership(tt.args.ctx, tt.args.ownerID, "1234", tt.args.targetID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomRoleUsecase.TransferOwnership() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomRoleUsecase.TransferOwnership() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !tt.wantErr && (got.UserID != tt.args.targetID || got.Role != domain.RoleOwner) {
+				t.Errorf("roomRoleUsecase.TransferOwnership() = %+v, want owner %s", got, tt.args.targetID)
+			}
+		})
+	}
+}
+
+func Test_roomRoleUsecase_Succeed(t *testing.T) {
+	joined := func(id int, userID, role string, minutes int) domain.ParticipatingRoom {
+		return domain.ParticipatingRoom{ID: id, RoomID: "1234", UserID: userID, Role: role, CreatedAt: time.Date(2024, 1, 1, 0, minutes, 0, 0, time.UTC)}
+	}
+	tests := []struct {
+		name    string
+		members domain.ParticipatingRooms
+		mockFn  func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context)
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "[正常系] 最も早く参加した管理者が引き継ぐ",
+			members: domain.ParticipatingRooms{
+				joined(1, "owner", domain.RoleOwner, 0),
+				joined(2, "member", domain.RoleMember, 1),
+				joined(3, "admin2", domain.RoleAdmin, 3),
+				joined(4, "admin1", domain.RoleAdmin, 2),
+			},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().TransferOwnership(ctx, "1234", "owner", "admin1").Return(nil)
+			},
+			want: "admin1",
+		},
+		{
+			name: "[正常系] 管理者がいなければ最も早く参加したメンバーが引き継ぐ",
+			members: domain.ParticipatingRooms{
+				joined(1, "owner", domain.RoleOwner, 0),
+				joined(2, "readonly", domain.RoleReadOnly, 1),
+				joined(3, "member1", domain.RoleMember, 2),
+				joined(4, "member2", domain.RoleMember, 3),
+			},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().TransferOwnership(ctx, "1234", "owner", "member1").Return(nil)
+			},
+			want: "member1",
+		},
+		{
+			name: "[正常系] 引き継げる参加者がいない",
+			members: domain.ParticipatingRooms{
+				joined(1, "owner", domain.RoleOwner, 0),
+				joined(2, "readonly", domain.RoleReadOnly, 1),
+			},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {},
+			want:   "",
+		},
+		{
+			name: "[異常系] DB処理失敗（TransferOwnership）",
+			members: domain.ParticipatingRooms{
+				joined(1, "owner", domain.RoleOwner, 0),
+				joined(2, "member", domain.RoleMember, 1),
+			},
+			mockFn: func(m *mock_repository.MockParticipatingRoomRepo, ctx context.Context) {
+				m.EXPECT().TransferOwnership(ctx, "1234", "owner", "member").Return(errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			ctx := context.Background()
+			mock := mock_repository.NewMockParticipatingRoomRepo(ctrl)
+			mock.EXPECT().GetMembersByRoomID(ctx, "1234").Return(&tt.members, nil)
+
+			tt.mockFn(mock, ctx)
+
+			test := &roomRoleUsecase{
+				repo: mock,
+			}
+			got, err := test.Succeed(ctx, "owner", "1234")
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomRoleUsecase.Succeed() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr {
+				return
+			}
+			if tt.want == "" {
+				if got != nil {
+					t.Errorf("roomRoleUsecase.Succeed() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil || got.UserID != tt.want || got.Role != domain.RoleOwner {
+				t.Errorf("roomRoleUsecase.Succeed() = %+v, want owner %s", got, tt.want)
+			}
+		})
+	}
+}
@@ -2,21 +2,28 @@ package usecase
 
 import (
 	"context"
-	"fmt"
-	"math/rand"
+	"errors"
+	"strings"
 	"time"
 
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/repository"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/ulid"
+	"gorm.io/gorm"
 )
 
 //go:generate mockgen -source=$GOFILE -destination=../mock/$GOPACKAGE/room_mock.go -package=mock_$GOPACKAGE
 
 type RoomUsecase interface {
 	GetAll(ctx context.Context) (*domain.Rooms, error)
+	GetByID(ctx context.Context, id string) (*domain.Room, error)
+	Resolve(ctx context.Context, idOrSlug string) (*domain.Room, error)
+	GetByIDs(ctx context.Context, ids []string) (*domain.Rooms, error)
 	Create(ctx context.Context, user *domain.Room) (*domain.Room, error)
+	Update(ctx context.Context, room *domain.Room) error
 	Delete(ctx context.Context, id string) error
 	IDExists(ctx context.Context, id string) (*bool, error)
+	GetOrCreateDirect(ctx context.Context, userID, peerID string) (*domain.Room, error)
 }
 
 type roomUsecase struct {
@@ -31,27 +38,38 @@ func (u *roomUsecase) GetAll(ctx context.Context) (*domain.Rooms, error) {
 	return u.repo.GetAll(ctx)
 }
 
-func (u *roomUsecase) Create(ctx context.Context, room *domain.Room) (*domain.Room, error) {
-	ran := rand.New(rand.NewSource(time.Now().UnixNano()))
+func (u *roomUsecase) GetByID(ctx context.Context, id string) (*domain.Room, error) {
+	return u.repo.GetByID(ctx, id)
+}
 
-	var roomID string
-	var exists *bool
-	var err error
+// ULIDならIDとして、それ以外はスラッグとしてRoomを取得する
+func (u *roomUsecase) Resolve(ctx context.Context, idOrSlug string) (*domain.Room, error) {
+	if ulid.IsValid(idOrSlug) {
+		return u.repo.GetByID(ctx, idOrSlug)
+	}
+	return u.repo.GetBySlug(ctx, strings.ToLower(idOrSlug))
+}
 
-	for {
-		roomID = fmt.Sprintf("%04d", ran.Intn(10000))
+func (u *roomUsecase) GetByIDs(ctx context.Context, ids []string) (*domain.Rooms, error) {
+	if len(ids) == 0 {
+		return &domain.Rooms{}, nil
+	}
+	return u.repo.GetByIDs(ctx, ids)
+}
 
-		exists, err = u.repo.IDExists(ctx, room.ID)
-		if err != nil {
-			return nil, err
-		}
+func (u *roomUsecase) Create(ctx context.Context, room *domain.Room) (*domain.Room, error) {
+	err := room.Validate()
+	if err != nil {
+		return nil, err
+	}
 
-		if !*exists {
-			break
-		}
+	err = u.checkSlug(ctx, room)
+	if err != nil {
+		return nil, err
 	}
 
-	room.ID = roomID
+	room.ID = ulid.NewULID()
+	room.Kind = domain.RoomKindGroup
 
 	now := time.Now()
 	room.CreatedAt = now
@@ -60,6 +78,41 @@ func (u *roomUsecase) Create(ctx context.Context, room *domain.Room) (*domain.Ro
 	return u.repo.Create(ctx, room)
 }
 
+// Roomの名前、トピック、説明、スラッグを更新する
+func (u *roomUsecase) Update(ctx context.Context, room *domain.Room) error {
+	err := room.Validate()
+	if err != nil {
+		return err
+	}
+
+	err = u.checkSlug(ctx, room)
+	if err != nil {
+		return err
+	}
+
+	room.UpdatedAt = time.Now()
+	return u.repo.Update(ctx, room)
+}
+
+// スラッグが他のRoomに使われていないか確認する
+func (u *roomUsecase) checkSlug(ctx context.Context, room *domain.Room) error {
+	if room.Slug == nil {
+		return nil
+	}
+
+	other, err := u.repo.GetBySlug(ctx, *room.Slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if other.ID != room.ID {
+		return domain.ErrSlugTaken
+	}
+	return nil
+}
+
 func (u *roomUsecase) Delete(ctx context.Context, id string) error {
 	return u.repo.Delete(ctx, id)
 }
@@ -67,3 +120,37 @@ func (u *roomUsecase) Delete(ctx context.Context, id string) error {
 func (u *roomUsecase) IDExists(ctx context.Context, id string) (*bool, error) {
 	return u.repo.IDExists(ctx, id)
 }
+
+// 2人のユーザーのダイレクトメッセージのRoomを取得する。まだなければ2人を参加者として作成する
+func (u *roomUsecase) GetOrCreateDirect(ctx context.Context, userID, peerID string) (*domain.Room, error) {
+	if userID == peerID {
+		return nil, domain.ErrDirectSelf
+	}
+
+	directKey := domain.DirectKey(userID, peerID)
+	room, err := u.repo.GetByDirectKey(ctx, directKey)
+	if err == nil {
+		return room, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	now := time.Now()
+	room, err = u.repo.CreateDirect(ctx, &domain.Room{
+		ID:        ulid.NewULID(),
+		Kind:      domain.RoomKindDirect,
+		DirectKey: &directKey,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}, []string{userID, peerID})
+	if err != nil {
+		// 同時に作成された場合は、先に作成されたRoomを使う
+		existing, getErr := u.repo.GetByDirectKey(ctx, directKey)
+		if getErr == nil {
+			return existing, nil
+		}
+		return nil, err
+	}
+	return room, nil
+}
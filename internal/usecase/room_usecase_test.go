@@ -4,12 +4,15 @@ import (
 	"context"
 	"errors"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	"github.com/Shakkuuu/websocket-chat-go-clean/internal/domain"
 	mock_repository "github.com/Shakkuuu/websocket-chat-go-clean/internal/mock/repository"
+	"github.com/Shakkuuu/websocket-chat-go-clean/pkg/ulid"
 	"go.uber.org/mock/gomock"
+	"gorm.io/gorm"
 )
 
 func Test_roomUsecase_GetAll(t *testing.T) {
@@ -72,75 +75,260 @@ func Test_roomUsecase_Create(t *testing.T) {
 		ctx  context.Context
 		room *domain.Room
 	}
-	testTime := time.Now()
+	slug := func(s string) *string { return &s }
 	tests := []struct {
-		name        string
-		args        args
-		mockFn1     func(m *mock_repository.MockRoomRepo, ctx context.Context, id string)
-		againMockFn func(m *mock_repository.MockRoomRepo, ctx context.Context, id string)
-		mockFn2     func(m *mock_repository.MockRoomRepo, ctx context.Context, room *domain.Room)
-		want        *domain.Room
-		wantErr     bool
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockRoomRepo, ctx context.Context)
+		wantSlug  *string
+		wantErr   bool
+		wantErrIs error
 	}{
 		{
 			name: "[正常系] Room作成",
-			args: args{context.Background(), &domain.Room{}},
-			mockFn1: func(m *mock_repository.MockRoomRepo, ctx context.Context, id string) {
-				exists := false
-				m.EXPECT().IDExists(ctx, id).Return(&exists, nil)
+			args: args{context.Background(), &domain.Room{Name: "雑談"}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, room *domain.Room) (*domain.Room, error) {
+					return room, nil
+				})
+			},
+			wantSlug: nil,
+			wantErr:  false,
+		},
+		{
+			name: "[正常系] スラッグ付きでRoom作成",
+			args: args{context.Background(), &domain.Room{Name: "雑談", Slug: slug(" Random-Talk ")}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetBySlug(ctx, "random-talk").Return(&domain.Room{}, gorm.ErrRecordNotFound)
+				m.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, room *domain.Room) (*domain.Room, error) {
+					return room, nil
+				})
+			},
+			wantSlug: slug("random-talk"),
+			wantErr:  false,
+		},
+		{
+			name: "[正常系] 空のスラッグは設定しない",
+			args: args{context.Background(), &domain.Room{Name: "雑談", Slug: slug("  ")}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().Create(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, room *domain.Room) (*domain.Room, error) {
+					return room, nil
+				})
+			},
+			wantSlug: nil,
+			wantErr:  false,
+		},
+		{
+			name:      "[異常系] 名前が空",
+			args:      args{context.Background(), &domain.Room{Name: "  "}},
+			mockFn:    func(m *mock_repository.MockRoomRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidRoom,
+		},
+		{
+			name:      "[異常系] スラッグに使えない文字",
+			args:      args{context.Background(), &domain.Room{Name: "雑談", Slug: slug("雑談")}},
+			mockFn:    func(m *mock_repository.MockRoomRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidRoom,
+		},
+		{
+			name:      "[異常系] スラッグが長すぎる",
+			args:      args{context.Background(), &domain.Room{Name: "雑談", Slug: slug(strings.Repeat("a", 25))}},
+			mockFn:    func(m *mock_repository.MockRoomRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidRoom,
+		},
+		{
+			name: "[異常系] スラッグが使用済み",
+			args: args{context.Background(), &domain.Room{Name: "雑談", Slug: slug("random")}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetBySlug(ctx, "random").Return(&domain.Room{ID: "01J00000000000000000000001"}, nil)
 			},
-			againMockFn: nil,
-			mockFn2: func(m *mock_repository.MockRoomRepo, ctx context.Context, room *domain.Room) {
-				m.EXPECT().Create(ctx, room).Return(&domain.Room{ID: "1234", CreatedAt: testTime, UpdatedAt: testTime}, nil)
+			wantErr:   true,
+			wantErrIs: domain.ErrSlugTaken,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetBySlug）",
+			args: args{context.Background(), &domain.Room{Name: "雑談", Slug: slug("random")}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetBySlug(ctx, "random").Return(&domain.Room{}, errors.New("test error"))
 			},
-			want:    &domain.Room{ID: "1234", CreatedAt: testTime, UpdatedAt: testTime},
+			wantErr: true,
+		},
+		{
+			name: "[異常系] DB処理失敗（Create）",
+			args: args{context.Background(), &domain.Room{Name: "雑談"}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().Create(ctx, gomock.Any()).Return(nil, errors.New("test error"))
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &roomUsecase{
+				repo: mock,
+			}
+			got, err := test.Create(tt.args.ctx, tt.args.room)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomUsecase.Create() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomUsecase.Create() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if tt.wantErr {
+				return
+			}
+			if !ulid.IsValid(got.ID) || got.Kind != domain.RoomKindGroup || got.CreatedAt.IsZero() {
+				t.Errorf("roomUsecase.Create() = %+v", got)
+			}
+			if !reflect.DeepEqual(got.Slug, tt.wantSlug) {
+				t.Errorf("roomUsecase.Create() slug = %v, want %v", got.Slug, tt.wantSlug)
+			}
+		})
+	}
+}
+
+func Test_roomUsecase_Resolve(t *testing.T) {
+	type args struct {
+		ctx      context.Context
+		idOrSlug string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockRoomRepo, ctx context.Context)
+		want    *domain.Room
+		wantErr bool
+	}{
+		{
+			name: "[正常系] IDで取得",
+			args: args{context.Background(), "01J00000000000000000000001"},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByID(ctx, "01J00000000000000000000001").Return(&domain.Room{ID: "01J00000000000000000000001"}, nil)
+			},
+			want:    &domain.Room{ID: "01J00000000000000000000001"},
 			wantErr: false,
 		},
 		{
-			name: "[正常系] すでにIDが存在しており再度ID生成処理が走る場合",
-			args: args{context.Background(), &domain.Room{}},
-			mockFn1: func(m *mock_repository.MockRoomRepo, ctx context.Context, id string) {
-				exists := true
-				m.EXPECT().IDExists(ctx, id).Return(&exists, nil)
+			name: "[正常系] スラッグで取得",
+			args: args{context.Background(), "Random"},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetBySlug(ctx, "random").Return(&domain.Room{ID: "01J00000000000000000000001"}, nil)
 			},
-			againMockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context, id string) {
-				exists := false
-				m.EXPECT().IDExists(ctx, id).Return(&exists, nil)
+			want:    &domain.Room{ID: "01J00000000000000000000001"},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] 存在しない",
+			args: args{context.Background(), "1234"},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetBySlug(ctx, "1234").Return(&domain.Room{}, gorm.ErrRecordNotFound)
 			},
-			mockFn2: func(m *mock_repository.MockRoomRepo, ctx context.Context, room *domain.Room) {
-				m.EXPECT().Create(ctx, room).Return(&domain.Room{ID: "5678", CreatedAt: testTime, UpdatedAt: testTime}, nil)
+			want:    &domain.Room{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &roomUsecase{
+				repo: mock,
+			}
+			got, err := test.Resolve(tt.args.ctx, tt.args.idOrSlug)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomUsecase.Resolve() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("roomUsecase.Resolve() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func Test_roomUsecase_Update(t *testing.T) {
+	type args struct {
+		ctx  context.Context
+		room *domain.Room
+	}
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockRoomRepo, ctx context.Context)
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] Roomの名前、トピック、説明を更新",
+			args: args{context.Background(), &domain.Room{ID: "1234", Name: " 雑談 ", Topic: "週末の予定", Description: "なんでも話せる部屋"}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().Update(ctx, gomock.Any()).DoAndReturn(func(_ context.Context, room *domain.Room) error {
+					if room.Name != "雑談" || room.Topic != "週末の予定" || room.UpdatedAt.IsZero() {
+						t.Errorf("Update() room = %+v", room)
+					}
+					return nil
+				})
 			},
-			want:    &domain.Room{ID: "5678", CreatedAt: testTime, UpdatedAt: testTime},
 			wantErr: false,
 		},
 		{
-			name: "[異常系] DB処理失敗（IDExists）",
-			args: args{context.Background(), &domain.Room{}},
-			mockFn1: func(m *mock_repository.MockRoomRepo, ctx context.Context, id string) {
-				var exists bool
-				m.EXPECT().IDExists(ctx, id).Return(&exists, errors.New("test error"))
+			name:      "[異常系] 名前が空",
+			args:      args{context.Background(), &domain.Room{ID: "1234"}},
+			mockFn:    func(m *mock_repository.MockRoomRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidRoom,
+		},
+		{
+			name:      "[異常系] トピックが長すぎる",
+			args:      args{context.Background(), &domain.Room{ID: "1234", Name: "雑談", Topic: strings.Repeat("あ", 101)}},
+			mockFn:    func(m *mock_repository.MockRoomRepo, ctx context.Context) {},
+			wantErr:   true,
+			wantErrIs: domain.ErrInvalidRoom,
+		},
+		{
+			name: "[正常系] 自分のスラッグはそのまま使える",
+			args: args{context.Background(), &domain.Room{ID: "01J00000000000000000000001", Name: "雑談", Slug: func() *string { s := "random"; return &s }()}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetBySlug(ctx, "random").Return(&domain.Room{ID: "01J00000000000000000000001"}, nil)
+				m.EXPECT().Update(ctx, gomock.Any()).Return(nil)
 			},
-			againMockFn: nil,
-			mockFn2:     nil,
-			want:        nil,
-			wantErr:     true,
+			wantErr: false,
 		},
 		{
-			name: "[異常系] DB処理失敗（Create）",
-			args: args{context.Background(), &domain.Room{}},
-			mockFn1: func(m *mock_repository.MockRoomRepo, ctx context.Context, id string) {
-				exists := false
-				m.EXPECT().IDExists(ctx, id).Return(&exists, nil)
+			name: "[異常系] スラッグが他のRoomで使用済み",
+			args: args{context.Background(), &domain.Room{ID: "01J00000000000000000000001", Name: "雑談", Slug: func() *string { s := "random"; return &s }()}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetBySlug(ctx, "random").Return(&domain.Room{ID: "01J00000000000000000000002"}, nil)
 			},
-			againMockFn: nil,
-			mockFn2: func(m *mock_repository.MockRoomRepo, ctx context.Context, room *domain.Room) {
-				m.EXPECT().Create(ctx, room).Return(nil, errors.New("test error"))
+			wantErr:   true,
+			wantErrIs: domain.ErrSlugTaken,
+		},
+		{
+			name: "[異常系] DB処理失敗（Update）",
+			args: args{context.Background(), &domain.Room{ID: "1234", Name: "雑談"}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().Update(ctx, gomock.Any()).Return(errors.New("test error"))
 			},
-			want:    nil,
 			wantErr: true,
 		},
 	}
-
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			ctrl := gomock.NewController(t)
@@ -148,26 +336,80 @@ func Test_roomUsecase_Create(t *testing.T) {
 
 			mock := mock_repository.NewMockRoomRepo(ctrl)
 
-			if tt.mockFn1 != nil {
-				tt.mockFn1(mock, tt.args.ctx, tt.args.room.ID)
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &roomUsecase{
+				repo: mock,
 			}
-			if tt.againMockFn != nil {
-				tt.againMockFn(mock, tt.args.ctx, tt.args.room.ID)
+			err := test.Update(tt.args.ctx, tt.args.room)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomUsecase.Update() error = %v, wantErr %v", err, tt.wantErr)
+				return
 			}
-			if tt.mockFn2 != nil {
-				tt.mockFn2(mock, tt.args.ctx, tt.args.room)
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomUsecase.Update() error = %v, want %v", err, tt.wantErrIs)
 			}
+		})
+	}
+}
+
+func Test_roomUsecase_GetByIDs(t *testing.T) {
+	type args struct {
+		ctx context.Context
+		ids []string
+	}
+	tests := []struct {
+		name    string
+		args    args
+		mockFn  func(m *mock_repository.MockRoomRepo, ctx context.Context)
+		want    *domain.Rooms
+		wantErr bool
+	}{
+		{
+			name: "[正常系] 指定したIDのRoom取得",
+			args: args{context.Background(), []string{"1234", "5678"}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByIDs(ctx, []string{"1234", "5678"}).Return(&domain.Rooms{domain.Room{ID: "1234", Name: "雑談"}, domain.Room{ID: "5678", Name: "技術"}}, nil)
+			},
+			want:    &domain.Rooms{domain.Room{ID: "1234", Name: "雑談"}, domain.Room{ID: "5678", Name: "技術"}},
+			wantErr: false,
+		},
+		{
+			name:    "[正常系] IDが空の場合はDBに問い合わせない",
+			args:    args{context.Background(), nil},
+			mockFn:  func(m *mock_repository.MockRoomRepo, ctx context.Context) {},
+			want:    &domain.Rooms{},
+			wantErr: false,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetByIDs）",
+			args: args{context.Background(), []string{"1234"}},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByIDs(ctx, []string{"1234"}).Return(&domain.Rooms{}, errors.New("test error"))
+			},
+			want:    &domain.Rooms{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
 
 			test := &roomUsecase{
 				repo: mock,
 			}
-			got, err := test.Create(tt.args.ctx, tt.args.room)
+			got, err := test.GetByIDs(tt.args.ctx, tt.args.ids)
 			if (err != nil) != tt.wantErr {
-				t.Errorf("roomUsecase.Create() error = %v, wantErr %v", err, tt.wantErr)
+				t.Errorf("roomUsecase.GetByIDs() error = %v, wantErr %v", err, tt.wantErr)
 				return
 			}
 			if !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("roomUsecase.Create() = %v, want %v", got, tt.want)
+				t.Errorf("roomUsecase.GetByIDs() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -288,3 +530,110 @@ func Test_roomUsecase_IDExists(t *testing.T) {
 		})
 	}
 }
+
+func Test_roomUsecase_GetOrCreateDirect(t *testing.T) {
+	type args struct {
+		ctx    context.Context
+		userID string
+		peerID string
+	}
+	directKey := "abcd1234:efgh5678"
+	existing := &domain.Room{ID: "01J00000000000000000000001", Kind: domain.RoomKindDirect, DirectKey: &directKey}
+	tests := []struct {
+		name      string
+		args      args
+		mockFn    func(m *mock_repository.MockRoomRepo, ctx context.Context)
+		want      *domain.Room
+		wantErr   bool
+		wantErrIs error
+	}{
+		{
+			name: "[正常系] 既存のダイレクトメッセージのRoomを返す",
+			args: args{context.Background(), "efgh5678", "abcd1234"},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByDirectKey(ctx, directKey).Return(existing, nil)
+			},
+			want:    existing,
+			wantErr: false,
+		},
+		{
+			name: "[正常系] ダイレクトメッセージのRoomを2人の参加者と一緒に作成",
+			args: args{context.Background(), "abcd1234", "efgh5678"},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByDirectKey(ctx, directKey).Return(&domain.Room{}, gorm.ErrRecordNotFound)
+				m.EXPECT().CreateDirect(ctx, gomock.Any(), []string{"abcd1234", "efgh5678"}).DoAndReturn(func(_ context.Context, room *domain.Room, _ []string) (*domain.Room, error) {
+					if !ulid.IsValid(room.ID) || room.Kind != domain.RoomKindDirect || room.DirectKey == nil || *room.DirectKey != directKey || room.CreatedAt.IsZero() {
+						t.Errorf("CreateDirect() room = %+v", room)
+					}
+					return existing, nil
+				})
+			},
+			want:    existing,
+			wantErr: false,
+		},
+		{
+			name: "[正常系] 同時に作成された場合は先に作成されたRoomを返す",
+			args: args{context.Background(), "abcd1234", "efgh5678"},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByDirectKey(ctx, directKey).Return(&domain.Room{}, gorm.ErrRecordNotFound)
+				m.EXPECT().CreateDirect(ctx, gomock.Any(), gomock.Any()).Return(nil, errors.New("duplicate key"))
+				m.EXPECT().GetByDirectKey(ctx, directKey).Return(existing, nil)
+			},
+			want:    existing,
+			wantErr: false,
+		},
+		{
+			name:      "[異常系] 自分自身を指定",
+			args:      args{context.Background(), "abcd1234", "abcd1234"},
+			mockFn:    func(m *mock_repository.MockRoomRepo, ctx context.Context) {},
+			want:      nil,
+			wantErr:   true,
+			wantErrIs: domain.ErrDirectSelf,
+		},
+		{
+			name: "[異常系] DB処理失敗（GetByDirectKey）",
+			args: args{context.Background(), "abcd1234", "efgh5678"},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByDirectKey(ctx, directKey).Return(&domain.Room{}, errors.New("test error"))
+			},
+			want:    nil,
+			wantErr: true,
+		},
+		{
+			name: "[異常系] DB処理失敗（CreateDirect）",
+			args: args{context.Background(), "abcd1234", "efgh5678"},
+			mockFn: func(m *mock_repository.MockRoomRepo, ctx context.Context) {
+				m.EXPECT().GetByDirectKey(ctx, directKey).Return(&domain.Room{}, gorm.ErrRecordNotFound)
+				m.EXPECT().CreateDirect(ctx, gomock.Any(), gomock.Any()).Return(nil, errors.New("test error"))
+				m.EXPECT().GetByDirectKey(ctx, directKey).Return(&domain.Room{}, gorm.ErrRecordNotFound)
+			},
+			want:    nil,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+
+			mock := mock_repository.NewMockRoomRepo(ctrl)
+
+			tt.mockFn(mock, tt.args.ctx)
+
+			test := &roomUsecase{
+				repo: mock,
+			}
+			got, err := test.GetOrCreateDirect(tt.args.ctx, tt.args.userID, tt.args.peerID)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("roomUsecase.GetOrCreateDirect() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErrIs != nil && !errors.Is(err, tt.wantErrIs) {
+				t.Errorf("roomUsecase.GetOrCreateDirect() error = %v, want %v", err, tt.wantErrIs)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("roomUsecase.GetOrCreateDirect() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,201 @@
+package envelope
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// 現在のエンベロープのバージョン
+const Version = 1
+
+// イベントの種類
+const (
+	// クライアントからサーバー
+	TypeRoomJoin       = "room.join"
+	TypeChatSend       = "chat.send"
+	TypeHistoryRequest = "history.request"
+	TypeChatEdit       = "chat.edit"
+	TypeChatDelete     = "chat.delete"
+	TypeReactionToggle = "reaction.toggle"
+	TypeThreadRequest  = "thread.request"
+	TypeReadMark       = "read.mark"
+	TypePinAdd         = "pin.add"
+	TypePinRemove      = "pin.remove"
+
+	// 双方向。クライアントから受け取るとRoomの他のクライアントに中継する
+	TypeTypingStart = "typing.start"
+	TypeTypingStop  = "typing.stop"
+
+	// サーバーからクライアント
+	TypeChatMessage    = "chat.message"
+	TypeChatUpdate     = "chat.update"
+	TypeChatAck        = "chat.ack"
+	TypeHistoryPage    = "history.page"
+	TypeHistoryResume  = "history.resume"
+	TypeReactionUpdate = "reaction.update"
+	TypeThreadPage     = "thread.page"
+	TypeThreadUpdate   = "thread.update"
+	TypeMentionNotify  = "mention.notify"
+	TypeDirectMessage  = "dm.message"
+	TypePinUpdate      = "pin.update"
+	TypeRoomUpdate     = "room.update"
+	TypeJoinRequest    = "join.request"
+	TypeJoinDecision   = "join.decision"
+	TypeRoleUpdate     = "role.update"
+	TypePresenceUpdate = "presence.update"
+	TypeSystemNotice   = "system.notice"
+	TypeError          = "error"
+)
+
+var knownTypes = map[string]bool{
+	TypeRoomJoin:       true,
+	TypeChatSend:       true,
+	TypeHistoryRequest: true,
+	TypeChatEdit:       true,
+	TypeChatDelete:     true,
+	TypeReactionToggle: true,
+	TypeThreadRequest:  true,
+	TypeReadMark:       true,
+	TypePinAdd:         true,
+	TypePinRemove:      true,
+	TypeTypingStart:    true,
+	TypeTypingStop:     true,
+	TypeChatMessage:    true,
+	TypeChatUpdate:     true,
+	TypeChatAck:        true,
+	TypeHistoryPage:    true,
+	TypeHistoryResume:  true,
+	TypeReactionUpdate: true,
+	TypeThreadPage:     true,
+	TypeThreadUpdate:   true,
+	TypeMentionNotify:  true,
+	TypeDirectMessage:  true,
+	TypePinUpdate:      true,
+	TypeRoomUpdate:     true,
+	TypeJoinRequest:    true,
+	TypeJoinDecision:   true,
+	TypeRoleUpdate:     true,
+	TypePresenceUpdate: true,
+	TypeSystemNotice:   true,
+	TypeError:          true,
+}
+
+// errorイベントのコード
+const (
+	ErrCodeBadRequest = "bad_request"
+	ErrCodeForbidden  = "forbidden"
+	ErrCodeNotFound   = "not_found"
+	ErrCodeInternal   = "internal_error"
+	ErrCodeOffline    = "offline" // 送信先のユーザーが接続していない
+)
+
+// クライアントからのイベントのidの最大長
+const IDLengthMax = 64
+
+// クライアントサーバ間でやりとりするイベントの共通形式
+type Envelope struct {
+	V       int             `json:"v"`
+	Type    string          `json:"type"`
+	ID      string          `json:"id,omitempty"` // クライアントが付与するID。応答のイベントにはそのまま返す
+	Payload json.RawMessage `json:"payload,omitempty"`
+}
+
+// ペイロードの値の検証
+type validator interface {
+	Validate() error
+}
+
+// エンベロープを作成する
+func New(typ, id string, payload any) (*Envelope, error) {
+	e := &Envelope{
+		V:    Version,
+		Type: typ,
+		ID:   id,
+	}
+
+	if payload != nil {
+		raw, err := json.Marshal(payload)
+		if err != nil {
+			return nil, fmt.Errorf("json.Marshal payload error: %w", err)
+		}
+		e.Payload = raw
+	}
+
+	err := e.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+// エンベロープを作成してJSONに変換する
+func Encode(typ, id string, payload any) ([]byte, error) {
+	e, err := New(typ, id, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(e)
+}
+
+// JSONからエンベロープに変換して検証する
+func Decode(data []byte) (*Envelope, error) {
+	var e Envelope
+	err := json.Unmarshal(data, &e)
+	if err != nil {
+		return nil, fmt.Errorf("json.Unmarshal error: %w", err)
+	}
+
+	err = e.Validate()
+	if err != nil {
+		return nil, err
+	}
+
+	return &e, nil
+}
+
+// バージョンを持つエンベロープ形式のJSONかどうか
+func IsEnvelope(data []byte) bool {
+	var probe struct {
+		V *int `json:"v"`
+	}
+	err := json.Unmarshal(data, &probe)
+	return err == nil && probe.V != nil
+}
+
+func (e *Envelope) Validate() error {
+	if e.V != Version {
+		return fmt.Errorf("v の値が不正です。(%d)", e.V)
+	}
+
+	if !knownTypes[e.Type] {
+		return fmt.Errorf("type の値が不正です。(%s)", e.Type)
+	}
+
+	if len(e.ID) > IDLengthMax {
+		return fmt.Errorf("id は%d文字以内にしてください", IDLengthMax)
+	}
+
+	return nil
+}
+
+// ペイロードを取り出して検証する
+func (e *Envelope) DecodePayload(v any) error {
+	if len(bytes.TrimSpace(e.Payload)) == 0 {
+		return errors.New("payload がありません。")
+	}
+
+	err := json.Unmarshal(e.Payload, v)
+	if err != nil {
+		return fmt.Errorf("json.Unmarshal payload error: %w", err)
+	}
+
+	if p, ok := v.(validator); ok {
+		return p.Validate()
+	}
+
+	return nil
+}
@@ -0,0 +1,179 @@
+package envelope
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecode(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *Envelope
+		wantErr bool
+	}{
+		{
+			name: "[正常系] chat.send",
+			data: `{"v":1,"type":"chat.send","id":"c1","payload":{"message":"hello"}}`,
+			want: &Envelope{V: 1, Type: TypeChatSend, ID: "c1", Payload: []byte(`{"message":"hello"}`)},
+		},
+		{
+			name: "[正常系] payloadなし",
+			data: `{"v":1,"type":"history.request"}`,
+			want: &Envelope{V: 1, Type: TypeHistoryRequest},
+		},
+		{
+			name:    "[異常系] バージョンが不正",
+			data:    `{"v":2,"type":"chat.send","payload":{"message":"hello"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] typeが不明",
+			data:    `{"v":1,"type":"chat.unknown","payload":{}}`,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] idが64文字より大きい",
+			data:    `{"v":1,"type":"chat.send","id":"` + strings.Repeat("a", 65) + `","payload":{"message":"hello"}}`,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] JSONが不正",
+			data:    `{"v":1,"type":`,
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Decode([]byte(tt.data))
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Decode() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Decode() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEncode(t *testing.T) {
+	data, err := Encode(TypeChatMessage, "", &ChatMessagePayload{ID: "01J00000000000000000000001", RoomID: "1234", Name: "testName", Message: "<p>hello</p>"})
+	if err != nil {
+		t.Fatalf("Encode() error = %v", err)
+	}
+
+	e, err := Decode(data)
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	var got ChatMessagePayload
+	err = e.DecodePayload(&got)
+	if err != nil {
+		t.Fatalf("DecodePayload() error = %v", err)
+	}
+	want := ChatMessagePayload{ID: "01J00000000000000000000001", RoomID: "1234", Name: "testName", Message: "<p>hello</p>"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("DecodePayload() = %+v, want %+v", got, want)
+	}
+
+	_, err = Encode("unknown", "", nil)
+	if err == nil {
+		t.Errorf("Encode() error = nil, want error for unknown type")
+	}
+}
+
+func TestIsEnvelope(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want bool
+	}{
+		{"[正常系] エンベロープ形式", `{"v":1,"type":"chat.send","payload":{}}`, true},
+		{"[正常系] 旧形式", `{"roomid":"1234","message":"hello","name":"testName","toname":""}`, false},
+		{"[異常系] JSONではない", `hello`, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsEnvelope([]byte(tt.data)); got != tt.want {
+				t.Errorf("IsEnvelope() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEnvelope_DecodePayload(t *testing.T) {
+	tests := []struct {
+		name    string
+		e       *Envelope
+		payload validator
+		wantErr bool
+	}{
+		{
+			name:    "[正常系] room.join",
+			e:       &Envelope{V: 1, Type: TypeRoomJoin, Payload: []byte(`{"roomid":"1234"}`)},
+			payload: &RoomJoinPayload{},
+		},
+		{
+			name:    "[異常系] roomidが空",
+			e:       &Envelope{V: 1, Type: TypeRoomJoin, Payload: []byte(`{"roomid":""}`)},
+			payload: &RoomJoinPayload{},
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] メッセージが空",
+			e:       &Envelope{V: 1, Type: TypeChatSend, Payload: []byte(`{"message":""}`)},
+			payload: &ChatSendPayload{},
+			wantErr: true,
+		},
+		{
+			name:    "[正常系] chat.edit",
+			e:       &Envelope{V: 1, Type: TypeChatEdit, Payload: []byte(`{"id":"01J00000000000000000000001","message":"hello"}`)},
+			payload: &ChatEditPayload{},
+		},
+		{
+			name:    "[異常系] 編集するメッセージのidが空",
+			e:       &Envelope{V: 1, Type: TypeChatEdit, Payload: []byte(`{"id":"","message":"hello"}`)},
+			payload: &ChatEditPayload{},
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 削除するメッセージのidが空",
+			e:       &Envelope{V: 1, Type: TypeChatDelete, Payload: []byte(`{}`)},
+			payload: &ChatDeletePayload{},
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] リアクションの絵文字が空",
+			e:       &Envelope{V: 1, Type: TypeReactionToggle, Payload: []byte(`{"id":"01J00000000000000000000001","emoji":""}`)},
+			payload: &ReactionTogglePayload{},
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 既読にするseqが負",
+			e:       &Envelope{V: 1, Type: TypeReadMark, Payload: []byte(`{"seq":-1}`)},
+			payload: &ReadMarkPayload{},
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] payloadがない",
+			e:       &Envelope{V: 1, Type: TypeChatSend},
+			payload: &ChatSendPayload{},
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] payloadの型が不正",
+			e:       &Envelope{V: 1, Type: TypeChatSend, Payload: []byte(`{"message":1}`)},
+			payload: &ChatSendPayload{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := tt.e.DecodePayload(tt.payload); (err != nil) != tt.wantErr {
+				t.Errorf("Envelope.DecodePayload() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,273 @@
+package envelope
+
+import "errors"
+
+// room.join: 参加するRoomの指定
+// 再接続時はLastSeqに最後に受け取ったメッセージのseqを指定すると、それより後のメッセージを受け取れる
+type RoomJoinPayload struct {
+	RoomID  string `json:"roomid"`
+	LastSeq int64  `json:"lastseq,omitempty"`
+}
+
+func (p *RoomJoinPayload) Validate() error {
+	if p.RoomID == "" {
+		return errors.New("roomid の値が不正です。")
+	}
+	if p.LastSeq < 0 {
+		return errors.New("lastseq の値が不正です。")
+	}
+	return nil
+}
+
+// chat.send: メッセージの送信
+// ParentIDを指定するとスレッドへの返信になり、AlsoToRoomを指定した場合のみRoomのタイムラインにも表示される
+type ChatSendPayload struct {
+	Message    string `json:"message"`
+	ToName     string `json:"toname,omitempty"`
+	ParentID   string `json:"parentid,omitempty"`
+	AlsoToRoom bool   `json:"alsotoroom,omitempty"`
+}
+
+func (p *ChatSendPayload) Validate() error {
+	if p.Message == "" {
+		return errors.New("メッセージが空です。")
+	}
+	return nil
+}
+
+// chat.edit: 送信済みのメッセージの編集
+type ChatEditPayload struct {
+	ID      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func (p *ChatEditPayload) Validate() error {
+	if p.ID == "" {
+		return errors.New("id の値が不正です。")
+	}
+	if p.Message == "" {
+		return errors.New("メッセージが空です。")
+	}
+	return nil
+}
+
+// chat.delete: 送信済みのメッセージの削除
+type ChatDeletePayload struct {
+	ID string `json:"id"`
+}
+
+func (p *ChatDeletePayload) Validate() error {
+	if p.ID == "" {
+		return errors.New("id の値が不正です。")
+	}
+	return nil
+}
+
+// reaction.toggle: メッセージへのリアクションの追加。既に付けている絵文字であれば外す
+type ReactionTogglePayload struct {
+	ID    string `json:"id"`
+	Emoji string `json:"emoji"`
+}
+
+func (p *ReactionTogglePayload) Validate() error {
+	if p.ID == "" {
+		return errors.New("id の値が不正です。")
+	}
+	if p.Emoji == "" {
+		return errors.New("emoji の値が不正です。")
+	}
+	return nil
+}
+
+// thread.request: スレッドの返信の要求
+type ThreadRequestPayload struct {
+	ID     string `json:"id"`
+	Before string `json:"before,omitempty"`
+}
+
+func (p *ThreadRequestPayload) Validate() error {
+	if p.ID == "" {
+		return errors.New("id の値が不正です。")
+	}
+	return nil
+}
+
+// read.mark: 参加中のRoomのseqまでのメッセージを既読にする
+type ReadMarkPayload struct {
+	Seq int64 `json:"seq"`
+}
+
+func (p *ReadMarkPayload) Validate() error {
+	if p.Seq <= 0 {
+		return errors.New("seq の値が不正です。")
+	}
+	return nil
+}
+
+// pin.add, pin.remove: メッセージのピン留めとその解除。Roomの作成者のみ
+type PinPayload struct {
+	ID string `json:"id"`
+}
+
+func (p *PinPayload) Validate() error {
+	if p.ID == "" {
+		return errors.New("id の値が不正です。")
+	}
+	return nil
+}
+
+// history.request: 過去のメッセージの要求
+type HistoryRequestPayload struct {
+	Before string `json:"before,omitempty"`
+}
+
+// chat.message: 配信されるメッセージ
+// chat.update: 編集、削除されたメッセージ。クライアントは同じIDのメッセージを置き換える
+// dm.message: 自分宛てのダイレクトメッセージ。参加中のRoomに関わらず送られる
+type ChatMessagePayload struct {
+	ID         string                `json:"id"`
+	RoomID     string                `json:"roomid"`
+	Seq        int64                 `json:"seq"`
+	Name       string                `json:"name"`
+	ToName     string                `json:"toname,omitempty"`
+	ParentID   string                `json:"parentid,omitempty"`
+	AlsoToRoom bool                  `json:"alsotoroom,omitempty"`
+	Message    string                `json:"message"`
+	Markdown   string                `json:"markdown,omitempty"` // 編集時に使う送信されたままのマークダウン
+	Edited     bool                  `json:"edited,omitempty"`
+	Deleted    bool                  `json:"deleted,omitempty"`
+	Reactions  []ReactionPayload     `json:"reactions,omitempty"`
+	Thread     *ThreadSummaryPayload `json:"thread,omitempty"` // 返信があるメッセージのみ
+	CreatedAt  string                `json:"createdat"`
+}
+
+// スレッドの返信の概要
+type ThreadSummaryPayload struct {
+	ReplyCount   int64    `json:"replycount"`
+	LastReplyAt  string   `json:"lastreplyat"`
+	Participants []string `json:"participants"`
+}
+
+// メッセージに付けられた絵文字とその数
+type ReactionPayload struct {
+	Emoji string `json:"emoji"`
+	Count int64  `json:"count"`
+}
+
+// chat.ack: chat.sendのメッセージが保存された。エンベロープのidは送信時のもの
+type ChatAckPayload struct {
+	ID        string `json:"id"`
+	RoomID    string `json:"roomid"`
+	Seq       int64  `json:"seq"`
+	CreatedAt string `json:"createdat"`
+	Duplicate bool   `json:"duplicate,omitempty"` // 再送されたため保存済みのメッセージを返した
+}
+
+// reaction.update: メッセージのリアクションの更新。Reactionsはそのメッセージのリアクションすべて
+type ReactionUpdatePayload struct {
+	RoomID    string            `json:"roomid"`
+	ID        string            `json:"id"`
+	Reactions []ReactionPayload `json:"reactions"`
+}
+
+// thread.page: スレッドの返信。Parentは返信先のメッセージ
+type ThreadPagePayload struct {
+	RoomID   string               `json:"roomid"`
+	Parent   ChatMessagePayload   `json:"parent"`
+	Before   string               `json:"before,omitempty"`
+	Messages []ChatMessagePayload `json:"messages"`
+	HasMore  bool                 `json:"hasmore"`
+}
+
+// thread.update: スレッドに返信された。IDは返信先のメッセージのID
+type ThreadUpdatePayload struct {
+	RoomID string               `json:"roomid"`
+	ID     string               `json:"id"`
+	Thread ThreadSummaryPayload `json:"thread"`
+}
+
+// history.page: 過去のメッセージ
+type HistoryPagePayload struct {
+	RoomID   string               `json:"roomid"`
+	Before   string               `json:"before,omitempty"`
+	Messages []ChatMessagePayload `json:"messages"`
+	HasMore  bool                 `json:"hasmore"`
+}
+
+// history.resume: 再接続したクライアントが受け取っていないメッセージ
+type HistoryResumePayload struct {
+	RoomID   string               `json:"roomid"`
+	LastSeq  int64                `json:"lastseq"`
+	Messages []ChatMessagePayload `json:"messages"`
+}
+
+// typing.start, typing.stop: 入力中の表示の開始と終了。クライアントからはpayloadなしで送る
+type TypingPayload struct {
+	RoomID string `json:"roomid"`
+	Name   string `json:"name"`
+}
+
+// pin.update: Roomにピン留めされているメッセージの一覧。入室時とピン留めの変更時に送られる
+type PinUpdatePayload struct {
+	RoomID string               `json:"roomid"`
+	Pins   []ChatMessagePayload `json:"pins"`
+}
+
+// room.update: Roomの名前、トピック、説明、スラッグ、公開範囲の変更
+type RoomUpdatePayload struct {
+	RoomID      string `json:"roomid"`
+	Slug        string `json:"slug,omitempty"`
+	Name        string `json:"name"`
+	Topic       string `json:"topic"`
+	Description string `json:"description"`
+	Visibility  string `json:"visibility,omitempty"`
+}
+
+// mention.notify: 自分宛ての@メンション。参加中のRoomに関わらず送られる
+type MentionNotifyPayload struct {
+	RoomID string `json:"roomid"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+}
+
+// join.request: 作成したRoomへの参加リクエスト。参加中のRoomに関わらず送られる
+type JoinRequestPayload struct {
+	RoomID string `json:"roomid"`
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+}
+
+// join.decision: 自分の参加リクエストの承認または却下。参加中のRoomに関わらず送られる
+type JoinDecisionPayload struct {
+	RoomID   string `json:"roomid"`
+	Approved bool   `json:"approved"`
+}
+
+// role.update: Roomの参加者の役割の変更
+type RoleUpdatePayload struct {
+	RoomID string `json:"roomid"`
+	UserID string `json:"userid"`
+	Name   string `json:"name"`
+	Role   string `json:"role"`
+}
+
+// presence.update: 参加ユーザーとオンラインユーザーの更新
+type PresenceUpdatePayload struct {
+	RoomID      string   `json:"roomid"`
+	Message     string   `json:"message,omitempty"`
+	AllUsers    []string `json:"allusers"`
+	OnlineUsers []string `json:"onlineusers"`
+}
+
+// system.notice: サーバーからのお知らせ。切断時はCodeに理由のコードが入る
+type SystemNoticePayload struct {
+	RoomID  string `json:"roomid,omitempty"`
+	Message string `json:"message"`
+	Code    int    `json:"code,omitempty"`
+}
+
+// error: 要求の処理に失敗した
+type ErrorPayload struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
@@ -21,8 +21,9 @@ func New(host, user, password, database, dbport string) (*Postgres, error) {
 	CONNECT := fmt.Sprintf("host=%s user=%s password=%s dbname=%s port=%s sslmode=disable TimeZone=Asia/Shanghai", host, user, password, database, dbport)
 
 	// 接続できるまで一定回数リトライ
+	// TranslateErrorで一意制約違反をgorm.ErrDuplicatedKeyに変換する
 	count := 0
-	pg.Db, err = gorm.Open(postgres.Open(CONNECT), &gorm.Config{})
+	pg.Db, err = gorm.Open(postgres.Open(CONNECT), &gorm.Config{TranslateError: true})
 	if err != nil {
 		for {
 			if err == nil {
@@ -37,7 +38,7 @@ func New(host, user, password, database, dbport string) (*Postgres, error) {
 				log.Printf("db Init error: %v\n", err)
 				panic(err)
 			}
-			pg.Db, err = gorm.Open(postgres.Open(CONNECT), &gorm.Config{})
+			pg.Db, err = gorm.Open(postgres.Open(CONNECT), &gorm.Config{TranslateError: true})
 		}
 	}
 
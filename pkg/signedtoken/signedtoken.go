@@ -0,0 +1,49 @@
+package signedtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"strings"
+)
+
+// 署名が一致しない、または形式が正しくないトークン
+var ErrInvalid = errors.New("トークンが不正です。")
+
+type Signer struct {
+	key []byte
+}
+
+// keyが変わると以前に作成したトークンは使えなくなるため、再起動やサーバー間で同じ鍵を使う
+func New(key []byte) *Signer {
+	return &Signer{key: key}
+}
+
+// 値にHMAC-SHA256の署名を付けた「値.署名」の形式のトークンを作成する
+func (s *Signer) Sign(value string) string {
+	return value + "." + base64.RawURLEncoding.EncodeToString(s.mac(value))
+}
+
+// トークンの署名を確認し、署名された値を返す
+func (s *Signer) Verify(token string) (string, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return "", ErrInvalid
+	}
+	value := token[:i]
+	sig, err := base64.RawURLEncoding.DecodeString(token[i+1:])
+	if err != nil {
+		return "", ErrInvalid
+	}
+	if !hmac.Equal(sig, s.mac(value)) {
+		return "", ErrInvalid
+	}
+	return value, nil
+}
+
+func (s *Signer) mac(value string) []byte {
+	m := hmac.New(sha256.New, s.key)
+	m.Write([]byte(value))
+	return m.Sum(nil)
+}
@@ -0,0 +1,64 @@
+package signedtoken
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSigner_Verify(t *testing.T) {
+	signer := New([]byte("test key"))
+	token := signer.Sign("01J00000000000000000000001")
+	tests := []struct {
+		name    string
+		signer  *Signer
+		token   string
+		want    string
+		wantErr bool
+	}{
+		{
+			name:   "[正常系] 署名したトークン",
+			signer: signer,
+			token:  token,
+			want:   "01J00000000000000000000001",
+		},
+		{
+			name:    "[異常系] 値を書き換えたトークン",
+			signer:  signer,
+			token:   "01J00000000000000000000002" + token[len("01J00000000000000000000001"):],
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 別の鍵で署名したトークン",
+			signer:  New([]byte("other key")),
+			token:   token,
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 署名がない",
+			signer:  signer,
+			token:   "01J00000000000000000000001",
+			wantErr: true,
+		},
+		{
+			name:    "[異常系] 署名の形式が不正",
+			signer:  signer,
+			token:   "01J00000000000000000000001.!!",
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.signer.Verify(tt.token)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("Verify() error = %v, wantErr %v", err, tt.wantErr)
+				return
+			}
+			if tt.wantErr && !errors.Is(err, ErrInvalid) {
+				t.Errorf("Verify() error = %v, want %v", err, ErrInvalid)
+			}
+			if got != tt.want {
+				t.Errorf("Verify() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,34 @@ const port = location.port;
 
 let room_id = "";
 let Name = "";
+let oldestMessageID = ""; // 表示中の最も古いメッセージのID
+let threadParentID = ""; // 表示中のスレッドの返信先のメッセージのID
+let oldestThreadMessageID = ""; // 表示中のスレッドの最も古い返信のID
+let lastSeq = 0; // 最後に受け取ったメッセージのseq。再接続時にこれより後のメッセージを受け取る
+let readSeq = 0; // サーバーに既読として送ったseq
+let eventSeq = 0; // 送信するイベントのID用の連番
+let pending = {}; // サーバーから応答がないメッセージ (clientid → payload)
+const reconnectDelay = 3000; // 切断されてから再接続するまでの時間
+const quickReactions = ["👍", "❤️", "😂", "🎉"]; // リアクションボタンで選べる絵文字
+const roleLabels = { owner: "作成者", admin: "管理者", moderator: "モデレーター", member: "メンバー", readonly: "閲覧のみ" }; // 役割の表示名
+
+// イベントをエンベロープ形式でサーバーに送信する
+function sendEvent(type, payload, id) {
+    if (id === undefined) {
+        eventSeq++;
+        id = String(eventSeq);
+    }
+    const e = { v: 1, type: type, id: id, payload: payload };
+    socket.send(JSON.stringify(e));
+}
+
+// 再送時の重複排除に使うメッセージのIDを生成する
+function newClientID() {
+    if (window.crypto && crypto.randomUUID) {
+        return crypto.randomUUID();
+    }
+    return Date.now().toString(36) + Math.random().toString(36).slice(2);
+}
 
 // サーバーに接続
 window.onload = function () {
@@ -21,15 +49,7 @@ window.onload = function () {
             window.location.href = protocol + "//" + domain + ":" + port + '/login';
             return
         }
-        socket = new WebSocket(wsprotocol + "//" + domain + ":" + port + "/ws");
-        socket.onopen = function () {
-            joinRoom();
-        };
-        socket.onmessage = function (event) {
-            // サーバーからメッセージを受け取る
-            const msg = JSON.parse(event.data);
-            updateMessage(msg.roomid, msg.message, msg.name, msg.toname, msg.allusers, msg.onlineusers);
-        };
+        connect();
     })
     .catch(error => {
         console.error('Error fetching user data:', error);
@@ -38,15 +58,164 @@ window.onload = function () {
     });
 }
 
+function connect() {
+    socket = new WebSocket(wsprotocol + "//" + domain + ":" + port + "/ws");
+    socket.onopen = function () {
+        // 切断中に届かなかった入力中の表示は消す
+        typingUsers = {};
+        updateTypingUsers("", false);
+        typingSentAt = 0;
+        joinRoom();
+
+        // 応答がないまま切断されたメッセージを再送する
+        for (const id in pending) {
+            sendEvent("chat.send", pending[id], id);
+        }
+    };
+    socket.onmessage = function (event) {
+        // サーバーからメッセージを受け取る
+        const e = JSON.parse(event.data);
+        const p = e.payload || {};
+        switch (e.type) {
+        case "chat.message":
+            if (p.seq <= lastSeq) { // 受け取り済み
+                break;
+            }
+            lastSeq = p.seq;
+            receiveChatMessage(p);
+            markRead();
+            break;
+        case "chat.update":
+            replaceChatMessage(p);
+            break;
+        case "reaction.update":
+            updateReactions(p.id, p.reactions);
+            break;
+        case "thread.update":
+            updateThreadSummary(p.id, p.thread);
+            break;
+        case "thread.page":
+            if (!p.before) { // 最初のページであればスレッドの表示をリセット
+                showThread(p.parent);
+            } else if (p.parent.id != threadParentID) { // 閉じたスレッド
+                break;
+            }
+            prependThread(p.messages, p.hasmore);
+            break;
+        case "pin.update":
+            showPins(p.pins);
+            break;
+        case "room.update":
+            showRoomInfo(p);
+            break;
+        case "typing.start":
+            updateTypingUsers(p.name, true);
+            break;
+        case "typing.stop":
+            updateTypingUsers(p.name, false);
+            break;
+        case "mention.notify":
+            if (p.roomid != room_id) { // 表示中のRoomのメンションはメッセージで確認できる
+                updateMessage(p.roomid, p.name + "からメンションされました", "Server", "", null, null);
+            }
+            break;
+        case "join.request":
+            if (p.roomid == room_id) {
+                getJoinRequests();
+            } else {
+                updateMessage(p.roomid, p.name + "から参加リクエストが届きました", "Server", "", null, null);
+            }
+            break;
+        case "join.decision":
+            updateMessage(p.roomid, p.approved ? "参加リクエストが承認されました" : "参加リクエストが却下されました", "Server", "", null, null);
+            break;
+        case "dm.message":
+            if (p.roomid != room_id) { // 表示中のRoomのメッセージはchat.messageで届く
+                updateMessage(p.roomid, p.name + "からダイレクトメッセージが届きました", "Server", "", null, null);
+            }
+            break;
+        case "chat.ack":
+            delete pending[e.id];
+            break;
+        case "history.page":
+            if (!p.before) { // 直近の履歴であれば表示をリセット
+                document.getElementById("messages").textContent = "";
+                oldestMessageID = "";
+                if (p.messages.length > 0) {
+                    lastSeq = p.messages[p.messages.length - 1].seq;
+                }
+            }
+            prependHistory(p.messages, p.hasmore);
+            markRead();
+            break;
+        case "history.resume":
+            p.messages.forEach(m => {
+                if (m.seq > lastSeq) {
+                    lastSeq = m.seq;
+                    receiveChatMessage(m);
+                }
+            });
+            markRead();
+            break;
+        case "role.update":
+            updateMessage(p.roomid, p.name + "の役割が" + roleLabels[p.role] + "に変更されました", "Server", "", null, null);
+            getMembers();
+            break;
+        case "presence.update":
+            updateMessage(p.roomid, p.message, "Server", "", p.allusers, p.onlineusers);
+            getMembers();
+            break;
+        case "system.notice":
+            updateMessage(p.roomid, p.message, "Server", "", null, null);
+            break;
+        case "error":
+            delete pending[e.id];
+            updateMessage(room_id, p.message, "Server", "", null, null);
+            break;
+        default:
+            console.log("unknown event type:", e.type);
+        }
+    };
+    socket.onclose = function (event) {
+        // サーバーから理由のコード付きで切断された場合は理由を表示する
+        if (event.code >= 4000 && event.reason != "") {
+            updateMessage(room_id, event.reason, "Server", "", null, null);
+        }
+        if (event.code == 4000 || event.code == 4004 || event.code == 4005 || event.code == 4007) { // 再接続しても参加できない
+            return;
+        }
+        setTimeout(connect, reconnectDelay);
+    };
+}
+
+// 表示しているメッセージまでを既読にする
+function markRead() {
+    if (document.hidden || lastSeq <= readSeq) {
+        return;
+    }
+    readSeq = lastSeq;
+    sendEvent("read.mark", { seq: lastSeq });
+}
+
+// タブが表示されたら既読にする
+document.addEventListener("visibilitychange", function () {
+    if (typeof socket != "undefined" && socket.readyState == WebSocket.OPEN) {
+        markRead();
+    }
+});
+
 function joinRoom() {
     let url_string = location.href;
     let url = new URL(url_string);
     room_id = url.searchParams.get("roomid");
     document.getElementById("current_server").textContent = room_id
+    getRoomInfo();
+    getInvites();
+    getJoinRequests();
+    getMembers();
 
     document.getElementById("username").textContent = Name
-    const message = { roomid: room_id, name: Name};
-    socket.send(JSON.stringify(message));
+    sendEvent("room.join", { roomid: room_id, lastseq: lastSeq });
 }
 
 // メッセージ欄を更新する
@@ -96,7 +265,7 @@ function updateMessage(roomid, message, name, toname, aus, ous) {
     messageContainer.className = "message";
 
     let messageText = document.createElement("span");
-    messageText.innerHTML = message;
+    messageText.textContent = message;
 
     messageContainer.appendChild(messageText);
 
@@ -104,6 +273,540 @@ function updateMessage(roomid, message, name, toname, aus, ous) {
     messageList.appendChild(messageContainer);
 }
 
+// 受け取ったメッセージを表示する
+// スレッドへの返信は開いているスレッドに追加し、Roomにも送信されたものはメッセージ欄にも追加する
+function receiveChatMessage(m) {
+    if (m.parentid && m.parentid == threadParentID) {
+        let listName = document.createElement("li");
+        listName.appendChild(document.createTextNode(m.createdat + " : " + m.name));
+        let threadList = document.getElementById("threadMessages");
+        threadList.appendChild(listName);
+        threadList.appendChild(newChatMessage(m));
+    }
+    if (!m.parentid || m.alsotoroom) {
+        appendChatMessage(m);
+    }
+}
+
+// 保存されたメッセージをメッセージ欄に追加する
+function appendChatMessage(m) {
+    let messageList = document.getElementById("messages");
+    let listName = document.createElement("li");
+    listName.appendChild(document.createTextNode(m.roomid + " : " + m.name + "→" + (m.toname || "")));
+    messageList.appendChild(listName);
+    messageList.appendChild(newChatMessage(m));
+}
+
+// 保存されたメッセージの表示を作成する。編集、削除時に置き換えられるようメッセージのIDを付ける
+// スレッドへの返信はメッセージ欄とスレッドの両方に表示されることがあるため、IDはdata属性に持つ
+function newChatMessage(m) {
+    let messageContainer = document.createElement("div");
+    messageContainer.className = "message";
+    messageContainer.dataset.id = m.id;
+    fillChatMessage(messageContainer, m);
+    return messageContainer;
+}
+
+// 表示中の指定したIDのメッセージ
+function findChatMessages(id) {
+    return document.querySelectorAll('.message[data-id="' + CSS.escape(id) + '"]');
+}
+
+// メッセージの本文と編集、削除ボタンを表示する
+// 変更できるのは送信者本人とモデレーター以上の役割の参加者のみで、権限がなければサーバーからerrorが返る
+function fillChatMessage(messageContainer, m) {
+    messageContainer.textContent = "";
+
+    let messageText = document.createElement("span");
+    if (m.deleted) {
+        messageText.textContent = "このメッセージは削除されました";
+        messageContainer.appendChild(messageText);
+        return;
+    }
+    messageText.innerHTML = m.message;
+    messageContainer.appendChild(messageText);
+
+    if (m.edited) {
+        let editedText = document.createElement("small");
+        editedText.textContent = "(編集済み)";
+        messageContainer.appendChild(editedText);
+    }
+
+    let editButton = document.createElement("button");
+    editButton.textContent = "編集";
+    editButton.onclick = () => editMessage(m.id, m.markdown);
+    messageContainer.appendChild(editButton);
+
+    let deleteButton = document.createElement("button");
+    deleteButton.textContent = "削除";
+    deleteButton.onclick = () => deleteMessage(m.id);
+    messageContainer.appendChild(deleteButton);
+
+    if (!m.toname) {
+        let pinButton = document.createElement("button");
+        pinButton.textContent = "ピン留め";
+        pinButton.onclick = () => sendEvent("pin.add", { id: m.id });
+        messageContainer.appendChild(pinButton);
+    }
+
+    if (m.parentid) {
+        if (m.alsotoroom) {
+            let threadLink = document.createElement("button");
+            threadLink.textContent = "スレッドへの返信";
+            threadLink.onclick = () => openThread(m.parentid);
+            messageContainer.appendChild(threadLink);
+        }
+    } else {
+        let threadButton = document.createElement("button");
+        threadButton.textContent = "スレッド";
+        threadButton.onclick = () => openThread(m.id);
+        messageContainer.appendChild(threadButton);
+
+        let threadSummary = document.createElement("small");
+        threadSummary.className = "thread-summary";
+        messageContainer.appendChild(threadSummary);
+        fillThreadSummary(threadSummary, m.thread);
+    }
+
+    let reactions = document.createElement("div");
+    reactions.className = "reactions";
+    messageContainer.appendChild(reactions);
+    fillReactions(reactions, m.id, m.reactions || []);
+}
+
+// スレッドの返信数、最後の返信日時、返信したユーザーを表示する
+function fillThreadSummary(threadSummary, thread) {
+    if (!thread) {
+        threadSummary.textContent = "";
+        return;
+    }
+    threadSummary.textContent = "返信" + thread.replycount + "件 (最終返信: " + thread.lastreplyat + ") " + thread.participants.join(", ");
+}
+
+// スレッドの概要の表示を更新する
+function updateThreadSummary(id, thread) {
+    findChatMessages(id).forEach(messageContainer => {
+        let threadSummary = messageContainer.querySelector(".thread-summary");
+        if (threadSummary != null) { // 削除されたメッセージでなければ
+            fillThreadSummary(threadSummary, thread);
+        }
+    });
+}
+
+// メッセージに付けられたリアクションと、リアクションを付けるボタンを表示する
+function fillReactions(reactionsContainer, id, reactions) {
+    reactionsContainer.textContent = "";
+
+    reactions.forEach(r => {
+        let button = document.createElement("button");
+        button.textContent = r.emoji + " " + r.count;
+        button.onclick = () => toggleReaction(id, r.emoji);
+        reactionsContainer.appendChild(button);
+    });
+
+    quickReactions.forEach(emoji => {
+        if (reactions.some(r => r.emoji == emoji)) {
+            return;
+        }
+        let button = document.createElement("button");
+        button.className = "add-reaction";
+        button.textContent = emoji;
+        button.onclick = () => toggleReaction(id, emoji);
+        reactionsContainer.appendChild(button);
+    });
+}
+
+// リアクションの表示を更新する
+function updateReactions(id, reactions) {
+    findChatMessages(id).forEach(messageContainer => {
+        let reactionsContainer = messageContainer.querySelector(".reactions");
+        if (reactionsContainer != null) { // 削除されたメッセージでなければ
+            fillReactions(reactionsContainer, id, reactions);
+        }
+    });
+}
+
+// リアクションを付ける。既に付けている絵文字であれば外す
+function toggleReaction(id, emoji) {
+    sendEvent("reaction.toggle", { id: id, emoji: emoji });
+}
+
+// 編集、削除されたメッセージの表示を置き換える
+function replaceChatMessage(m) {
+    findChatMessages(m.id).forEach(messageContainer => fillChatMessage(messageContainer, m));
+}
+
+// Roomの名前、トピック、説明を取得する
+function getRoomInfo() {
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id))
+        .then(response => response.json())
+        .then(data => showRoomInfo(data))
+        .catch(error => console.error('Error fetching room data:', error));
+}
+
+// Roomの名前、トピック、説明を表示する
+function showRoomInfo(info) {
+    const label = info.slug || room_id;
+    document.getElementById("current_server").textContent = info.name ? info.name + " (" + label + ")" : label;
+    document.getElementById("room_topic").textContent = info.topic;
+    document.getElementById("room_description").textContent = info.description;
+    document.getElementById("edit_room_name").value = info.name;
+    document.getElementById("edit_room_slug").value = info.slug || "";
+    document.getElementById("edit_room_topic").value = info.topic;
+    document.getElementById("edit_room_description").value = info.description;
+    if (info.visibility) {
+        document.getElementById("edit_room_visibility").value = info.visibility;
+    }
+}
+
+// Roomの名前、トピック、説明、スラッグを変更する。管理者以上の役割の参加者のみ変更できる
+function updateRoomInfo() {
+    const errorElement = document.getElementById("room_settings_error");
+    errorElement.textContent = "";
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id), {
+        method: "POST",
+        body: new URLSearchParams({
+            name: document.getElementById("edit_room_name").value,
+            slug: document.getElementById("edit_room_slug").value,
+            topic: document.getElementById("edit_room_topic").value,
+            description: document.getElementById("edit_room_description").value,
+            visibility: document.getElementById("edit_room_visibility").value,
+        }),
+    })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            return response.json();
+        })
+        .then(data => showRoomInfo(data))
+        .catch(error => {
+            errorElement.textContent = error.message;
+        });
+}
+
+// 招待リンクの一覧を取得する。招待の権限がない参加者には表示しない
+function getInvites() {
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/invites")
+        .then(response => response.ok ? response.json() : { invites: [] })
+        .then(data => showInvites(data.invites))
+        .catch(error => console.error('Error fetching invites data:', error));
+}
+
+// 招待リンクの一覧を表示する
+function showInvites(invites) {
+    const invitesElement = document.getElementById("invites");
+    invitesElement.textContent = "";
+    invites.forEach(invite => {
+        let listItem = document.createElement("li");
+        let link = document.createElement("input");
+        link.type = "text";
+        link.readOnly = true;
+        link.value = protocol + "//" + domain + ":" + port + invite.url;
+        listItem.appendChild(link);
+
+        let uses = invite.maxuses ? invite.uses + "/" + invite.maxuses + "回" : invite.uses + "回";
+        listItem.appendChild(document.createTextNode(" " + uses + " " + invite.expiresat + "まで "));
+
+        let revokeButton = document.createElement("button");
+        revokeButton.textContent = "無効にする";
+        revokeButton.onclick = () => revokeInvite(invite.id);
+        listItem.appendChild(revokeButton);
+        invitesElement.appendChild(listItem);
+    });
+}
+
+// 招待リンクを作成する
+function createInvite() {
+    const errorElement = document.getElementById("invite_error");
+    errorElement.textContent = "";
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/invites", {
+        method: "POST",
+        body: new URLSearchParams({
+            expires: document.getElementById("invite_expires").value,
+            maxuses: document.getElementById("invite_maxuses").value,
+        }),
+    })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            getInvites();
+        })
+        .catch(error => {
+            errorElement.textContent = error.message;
+        });
+}
+
+// 招待リンクを無効にする
+function revokeInvite(id) {
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/invites/"+encodeURIComponent(id), { method: "DELETE" })
+        .then(() => getInvites())
+        .catch(error => console.error('Error revoking invite:', error));
+}
+
+// 承認待ちの参加リクエストの一覧を取得する。招待の権限がない参加者には表示しない
+function getJoinRequests() {
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/requests")
+        .then(response => response.ok ? response.json() : { requests: [] })
+        .then(data => showJoinRequests(data.requests))
+        .catch(error => console.error('Error fetching join requests data:', error));
+}
+
+// 承認待ちの参加リクエストの一覧を表示する
+function showJoinRequests(requests) {
+    const requestsElement = document.getElementById("joinrequests");
+    requestsElement.textContent = "";
+    requests.forEach(request => {
+        let listItem = document.createElement("li");
+        listItem.appendChild(document.createTextNode(request.name + " (" + request.createdat + ") "));
+
+        let approveButton = document.createElement("button");
+        approveButton.textContent = "承認";
+        approveButton.onclick = () => decideJoinRequest(request.id, "approve");
+        listItem.appendChild(approveButton);
+
+        let denyButton = document.createElement("button");
+        denyButton.textContent = "却下";
+        denyButton.onclick = () => decideJoinRequest(request.id, "deny");
+        listItem.appendChild(denyButton);
+        requestsElement.appendChild(listItem);
+    });
+}
+
+// 参加リクエストを承認または却下する
+function decideJoinRequest(id, action) {
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/requests/"+encodeURIComponent(id), {
+        method: "POST",
+        body: new URLSearchParams({ action: action }),
+    })
+        .then(() => getJoinRequests())
+        .catch(error => console.error('Error deciding join request:', error));
+}
+
+// Roomの参加者と役割の一覧を取得する
+function getMembers() {
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/members")
+        .then(response => response.ok ? response.json() : { members: [] })
+        .then(data => showMembers(data.members))
+        .catch(error => console.error('Error fetching members data:', error));
+}
+
+// Roomの参加者と役割の一覧を表示する。役割の変更と退出は権限がなければサーバーで拒否される
+function showMembers(members) {
+    const membersElement = document.getElementById("members");
+    membersElement.textContent = "";
+    const isOwner = members.some(member => member.name == Name && member.role == "owner");
+    members.forEach(member => {
+        let listItem = document.createElement("li");
+        listItem.appendChild(document.createTextNode(member.name + " "));
+        if (member.name == Name || member.role == "owner") {
+            listItem.appendChild(document.createTextNode(roleLabels[member.role]));
+            membersElement.appendChild(listItem);
+            return;
+        }
+
+        let roleSelect = document.createElement("select");
+        ["admin", "moderator", "member", "readonly"].forEach(role => {
+            let option = document.createElement("option");
+            option.value = role;
+            option.textContent = roleLabels[role];
+            option.selected = role == member.role;
+            roleSelect.appendChild(option);
+        });
+        roleSelect.onchange = () => changeRole(member.userid, roleSelect.value);
+        listItem.appendChild(roleSelect);
+
+        let kickButton = document.createElement("button");
+        kickButton.textContent = "退出させる";
+        kickButton.onclick = () => kickMember(member.userid, member.name);
+        listItem.appendChild(kickButton);
+
+        if (isOwner) {
+            let transferButton = document.createElement("button");
+            transferButton.textContent = "作成者を譲る";
+            transferButton.onclick = () => transferOwner(member.userid, member.name);
+            listItem.appendChild(transferButton);
+        }
+        membersElement.appendChild(listItem);
+    });
+}
+
+// 参加者の役割を変更する
+function changeRole(userid, role) {
+    const errorElement = document.getElementById("members_error");
+    errorElement.textContent = "";
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/members/"+encodeURIComponent(userid)+"/role", {
+        method: "POST",
+        body: new URLSearchParams({ role: role }),
+    })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+        })
+        .catch(error => {
+            errorElement.textContent = error.message;
+            getMembers();
+        });
+}
+
+// 参加者をRoomから退出させる
+function kickMember(userid, name) {
+    if (!confirm(name + "をRoomから退出させますか？")) {
+        return;
+    }
+    const errorElement = document.getElementById("members_error");
+    errorElement.textContent = "";
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/members/"+encodeURIComponent(userid), { method: "DELETE" })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            getMembers();
+        })
+        .catch(error => {
+            errorElement.textContent = error.message;
+        });
+}
+
+// Roomの作成者を他の参加者に譲る。自分は管理者になる
+function transferOwner(userid, name) {
+    if (!confirm(name + "にRoomの作成者を譲りますか？")) {
+        return;
+    }
+    const errorElement = document.getElementById("members_error");
+    errorElement.textContent = "";
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(room_id)+"/owner", {
+        method: "POST",
+        body: new URLSearchParams({ userid: userid }),
+    })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            getMembers();
+        })
+        .catch(error => {
+            errorElement.textContent = error.message;
+        });
+}
+
+// ピン留めされたメッセージの一覧を表示する
+function showPins(pins) {
+    const pinsElement = document.getElementById("pins");
+    pinsElement.textContent = "";
+    pins.forEach(m => {
+        let listItem = document.createElement("li");
+        listItem.appendChild(document.createTextNode(m.name + " : "));
+        let messageText = document.createElement("span");
+        messageText.innerHTML = m.message;
+        listItem.appendChild(messageText);
+
+        let unpinButton = document.createElement("button");
+        unpinButton.textContent = "ピン留めを外す";
+        unpinButton.onclick = () => sendEvent("pin.remove", { id: m.id });
+        listItem.appendChild(unpinButton);
+        pinsElement.appendChild(listItem);
+    });
+}
+
+// メッセージを編集する
+function editMessage(id, current) {
+    let msg = window.prompt("メッセージを編集", current);
+    if (msg == null || msg == "") {
+        return;
+    }
+    sendEvent("chat.edit", { id: id, message: msg });
+}
+
+// メッセージを削除する
+function deleteMessage(id) {
+    if (!window.confirm("本当にメッセージを削除しますか？")) {
+        return;
+    }
+    sendEvent("chat.delete", { id: id });
+}
+
+// 履歴のメッセージをメッセージ欄の先頭に追加する
+function prependHistory(history, hasmore) {
+    let messageList = document.getElementById("messages");
+    let fragment = document.createDocumentFragment();
+
+    history.forEach(h => {
+        let listName = document.createElement("li");
+        listName.appendChild(document.createTextNode(h.createdat + " : " + h.name + "→" + (h.toname || "")));
+        fragment.appendChild(listName);
+        fragment.appendChild(newChatMessage(h));
+    });
+    messageList.insertBefore(fragment, messageList.firstChild);
+
+    if (history.length > 0) {
+        oldestMessageID = history[0].id;
+    }
+    document.getElementById("loadOlder").style.display = hasmore ? "block" : "none";
+}
+
+// スレッドを開く
+function openThread(id) {
+    sendEvent("thread.request", { id: id });
+}
+
+// スレッドの返信先のメッセージを表示し、返信の表示をリセットする
+function showThread(parent) {
+    threadParentID = parent.id;
+    oldestThreadMessageID = "";
+    let threadParent = document.getElementById("threadParent");
+    threadParent.textContent = "";
+    let listName = document.createElement("p");
+    listName.textContent = parent.createdat + " : " + parent.name;
+    threadParent.appendChild(listName);
+    threadParent.appendChild(newChatMessage(parent));
+    document.getElementById("threadMessages").textContent = "";
+    document.getElementById("thread").style.display = "block";
+}
+
+// スレッドを閉じる
+function closeThread() {
+    threadParentID = "";
+    document.getElementById("thread").style.display = "none";
+}
+
+// スレッドの返信をスレッドの先頭に追加する
+function prependThread(messages, hasmore) {
+    let threadList = document.getElementById("threadMessages");
+    let fragment = document.createDocumentFragment();
+
+    messages.forEach(m => {
+        let listName = document.createElement("li");
+        listName.appendChild(document.createTextNode(m.createdat + " : " + m.name));
+        fragment.appendChild(listName);
+        fragment.appendChild(newChatMessage(m));
+    });
+    threadList.insertBefore(fragment, threadList.firstChild);
+
+    if (messages.length > 0) {
+        oldestThreadMessageID = messages[0].id;
+    }
+    document.getElementById("loadOlderThread").style.display = hasmore ? "block" : "none";
+}
+
+// 表示中より過去のスレッドの返信を要求する
+function loadOlderThread() {
+    if (threadParentID == "" || oldestThreadMessageID == "") {
+        return;
+    }
+    sendEvent("thread.request", { id: threadParentID, before: oldestThreadMessageID });
+}
+
+// 表示中より過去のメッセージを要求する
+function loadOlder() {
+    if (oldestMessageID == "") {
+        return;
+    }
+    sendEvent("history.request", { before: oldestMessageID });
+}
+
 // サーバーにメッセージを送信する
 function send() {
     let sendMessage = document.getElementById("message");
@@ -112,32 +815,71 @@ function send() {
         return;
     }
     let sendToName = document.getElementById("toname");
-    let stn = sendToName.value;
-    if (stn != "") { // プライベートメッセージだったら
-        const message = { roomid: room_id, message: msg, name : Name, toname : stn};
-        socket.send(JSON.stringify(message));
-        sendMessage.value = "";
-        return;
+    let stn = sendToName.value; // 空でなければプライベートメッセージ
+    const id = newClientID();
+    pending[id] = { message: msg, toname: stn };
+    if (socket.readyState == WebSocket.OPEN) { // 切断中であれば再接続時に送信する
+        sendEvent("chat.send", pending[id], id);
     }
-    const message = { roomid: room_id, message: msg, name : Name, toname : ""};
-    socket.send(JSON.stringify(message));
     sendMessage.value = "";
+
+    // 送信するとサーバー側で入力中の表示が終わる
+    clearTimeout(typingTimer);
+    typingSentAt = 0;
+}
+
+// 開いているスレッドに返信する
+function sendReply() {
+    let replyMessage = document.getElementById("threadMessage");
+    let msg = replyMessage.value;
+    if (msg == "" || threadParentID == "") {
+        return;
+    }
+    const id = newClientID();
+    pending[id] = { message: msg, parentid: threadParentID, alsotoroom: document.getElementById("alsoToRoom").checked };
+    if (socket.readyState == WebSocket.OPEN) { // 切断中であれば再接続時に送信する
+        sendEvent("chat.send", pending[id], id);
+    }
+    replyMessage.value = "";
 }
 
 let typingTimer;
-const typingTimeout = 1000; // 1秒間のタイピングを入力中とみなす時間
+let typingSentAt = 0; // 最後にtyping.startを送信した時刻。入力中でなければ0
+let typingUsers = {}; // 入力中の他のユーザー (名前 → true)
+const typingTimeout = 3000; // 最後のタイピングからこの時間は入力中とみなす
+const typingResend = 3000; // 入力中の間にtyping.startを送り直す間隔。サーバー側の期限より短くする
 
 function showTypingStatus() {
-    // タイピング中のステータス表示を制御
-    clearTimeout(typingTimer);
-    document.getElementById("inputStatus").style.display = "block";
+    // 入力中であることをRoomの他のユーザーに知らせる
+    if (socket.readyState == WebSocket.OPEN && Date.now() - typingSentAt >= typingResend) {
+        sendEvent("typing.start", {});
+        typingSentAt = Date.now();
+    }
 
-    // 一定時間経過後に「入力中」のステータスを非表示にする
+    // 一定時間入力がなければ入力中の表示を終える
+    clearTimeout(typingTimer);
     typingTimer = setTimeout(() => {
-        document.getElementById("inputStatus").style.display = "none";
+        typingSentAt = 0;
+        if (socket.readyState == WebSocket.OPEN) {
+            sendEvent("typing.stop", {});
+        }
     }, typingTimeout);
 }
 
+// 入力中の他のユーザーを表示する
+function updateTypingUsers(name, typing) {
+    if (typing) {
+        typingUsers[name] = true;
+    } else {
+        delete typingUsers[name];
+    }
+
+    const names = Object.keys(typingUsers);
+    const inputStatus = document.getElementById("inputStatus");
+    inputStatus.textContent = names.join("、") + "が入力中...";
+    inputStatus.style.display = names.length > 0 ? "block" : "none";
+}
+
 // Roomの削除または離脱
 function deleteorleaveRoom(){
     let rid = room_id;
@@ -9,17 +9,41 @@ function getRooms() {
         .then(response => response.json())
         .then(data => {
             const rooms = data.roomslist;
+            const roomInfo = toRoomInfo(data.rooms || []);
 
             const roomListElement = document.getElementById("rooms");
             rooms.forEach(room => {
                 const listItem = document.createElement('li');
-                listItem.textContent = room;
+                listItem.textContent = roomLabel(room, roomInfo);
                 roomListElement.appendChild(listItem);
             });
         })
         .catch(error => console.error('Error fetching rooms data:', error));
 }
 
+// RoomのIDから名前、トピック、説明を引けるようにする
+function toRoomInfo(rooms) {
+    const roomInfo = {};
+    rooms.forEach(room => {
+        roomInfo[room.id] = room;
+    });
+    return roomInfo;
+}
+
+// 一覧に表示するRoomの名前とトピック。名前がないRoomはIDを表示する
+// スラッグがあればIDの代わりに表示する
+function roomLabel(id, roomInfo) {
+    const info = roomInfo[id];
+    if (!info || !info.name) {
+        return id;
+    }
+    let label = info.name + " (" + (info.slug || id) + ")";
+    if (info.topic) {
+        label += " - " + info.topic;
+    }
+    return label;
+}
+
 // 参加中のRoomの一覧を取得
 function getJoinRooms() {
     document.getElementById('joinrooms').textContent = '';
@@ -27,37 +51,193 @@ function getJoinRooms() {
         .then(response => response.json())
         .then(data => {
             const rooms = data.roomslist;
+            const roomInfo = toRoomInfo(data.rooms || []);
+            const unreadMentions = data.unreadmentions || {};
+            const unread = data.unread || {};
+            const lastActivity = data.lastactivity || {};
+            showDirectRooms(data.directrooms || [], unread, unreadMentions);
 
             const roomListElement = document.getElementById("joinrooms");
             rooms.forEach(room => {
                 const listItem = document.createElement('li');
-                listItem.textContent = room;
+                listItem.textContent = roomLabel(room, roomInfo);
+                // 未読のメッセージ数と最後の発言日時を表示
+                if (unread[room]) {
+                    const unreadCount = document.createElement('strong');
+                    unreadCount.className = "unread-count";
+                    unreadCount.textContent = "未読 " + unread[room];
+                    listItem.appendChild(unreadCount);
+                }
+                if (lastActivity[room]) {
+                    const activity = document.createElement('small');
+                    activity.className = "last-activity";
+                    activity.textContent = lastActivity[room];
+                    listItem.appendChild(activity);
+                }
+                // 未読のメンションがあればバッジを表示
+                if (unreadMentions[room]) {
+                    const badge = document.createElement('span');
+                    badge.className = "mention-badge";
+                    badge.textContent = "@" + unreadMentions[room];
+                    listItem.appendChild(badge);
+                }
                 roomListElement.appendChild(listItem);
             });
         })
         .catch(error => console.error('Error fetching joinrooms data:', error));
 }
 
+// ダイレクトメッセージの一覧を表示
+function showDirectRooms(directRooms, unread, unreadMentions) {
+    const directListElement = document.getElementById("directrooms");
+    directListElement.textContent = '';
+    directRooms.forEach(direct => {
+        const listItem = document.createElement('li');
+        const link = document.createElement('a');
+        link.href = protocol + "//" + domain + ":" + port + '/room?roomid=' + encodeURIComponent(direct.roomid);
+        link.textContent = direct.name;
+        listItem.appendChild(link);
+        if (unread[direct.roomid]) {
+            const unreadCount = document.createElement('strong');
+            unreadCount.className = "unread-count";
+            unreadCount.textContent = "未読 " + unread[direct.roomid];
+            listItem.appendChild(unreadCount);
+        }
+        if (unreadMentions[direct.roomid]) {
+            const badge = document.createElement('span');
+            badge.className = "mention-badge";
+            badge.textContent = "@" + unreadMentions[direct.roomid];
+            listItem.appendChild(badge);
+        }
+        directListElement.appendChild(listItem);
+    });
+}
+
+// ユーザー名を指定してダイレクトメッセージを開始
+function startDirect() {
+    const name = document.getElementById("direct_name").value.trim();
+    const errorElement = document.getElementById("direct_error");
+    errorElement.textContent = "";
+    if (name == "") {
+        return;
+    }
+    fetch(protocol+"//"+domain+":"+port+"/direct", {
+        method: "POST",
+        body: new URLSearchParams({name: name}),
+    })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            return response.json();
+        })
+        .then(data => {
+            window.location.href = protocol + "//" + domain + ":" + port + '/room?roomid=' + encodeURIComponent(data.roomid);
+        })
+        .catch(error => {
+            errorElement.textContent = error.message;
+        });
+}
+
+// 検索中の検索語と続きを取得するためのカーソル
+let searchQuery = "";
+let searchNext = "";
+
+// 参加中のRoomのメッセージを検索
+function searchMessages() {
+    searchQuery = document.getElementById("search_query").value.trim();
+    searchNext = "";
+    document.getElementById('search_results').textContent = '';
+    if (searchQuery == "") {
+        document.getElementById('search_more').style.display = "none";
+        return;
+    }
+    fetchSearchResults();
+}
+
+// 検索結果の続きを取得
+function searchMore() {
+    if (searchNext == "") {
+        return;
+    }
+    fetchSearchResults();
+}
+
+function fetchSearchResults() {
+    let url = protocol+"//"+domain+":"+port+"/search?q="+encodeURIComponent(searchQuery);
+    if (searchNext != "") {
+        url += "&before="+encodeURIComponent(searchNext);
+    }
+    fetch(url)
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            return response.json();
+        })
+        .then(data => {
+            const resultListElement = document.getElementById("search_results");
+            data.results.forEach(result => {
+                const listItem = document.createElement('li');
+                const link = document.createElement('a');
+                link.href = protocol + "//" + domain + ":" + port + '/room?roomid=' + encodeURIComponent(result.roomid);
+                link.textContent = "Room " + result.roomid;
+                listItem.appendChild(link);
+                const meta = document.createElement('small');
+                meta.textContent = " " + result.name + (result.toname ? " → " + result.toname : "") + " " + result.createdat + " ";
+                listItem.appendChild(meta);
+                // スニペットはサーバーでエスケープ済みで、一致した語が<mark>で囲まれている
+                const snippet = document.createElement('span');
+                snippet.innerHTML = result.snippet;
+                listItem.appendChild(snippet);
+                resultListElement.appendChild(listItem);
+            });
+            searchNext = data.next || "";
+            document.getElementById('search_more').style.display = searchNext ? "" : "none";
+        })
+        .catch(error => console.error('Error fetching search results:', error));
+}
+
 // ルームページに遷移
 function enterRoom() {
     let sendroomid = document.getElementById("enter_roomid");
-    let rid = sendroomid.value;
+    let rid = sendroomid.value.trim();
     if (rid == "") {
         return;
     }
-    window.location.href = protocol + "//" + domain + ":" + port + '/room?roomid=' + rid;
+    window.location.href = protocol + "//" + domain + ":" + port + '/room?roomid=' + encodeURIComponent(rid);
 
     sendroomid.value = "";
 }
 
+// 参加承認制のRoomに参加リクエストを送信
+function requestJoin() {
+    const resultElement = document.getElementById("request_result");
+    resultElement.textContent = "";
+    let rid = document.getElementById("request_roomid").value.trim();
+    if (rid == "") {
+        return;
+    }
+    fetch(protocol+"//"+domain+":"+port+"/rooms/"+encodeURIComponent(rid)+"/requests", { method: "POST" })
+        .then(response => {
+            if (!response.ok) {
+                return response.text().then(text => { throw new Error(text); });
+            }
+            resultElement.textContent = "参加リクエストを送信しました。承認されると参加中のRoom一覧に表示されます。";
+        })
+        .catch(error => {
+            resultElement.textContent = error.message;
+        });
+}
+
 // Room削除
 function deleteRoom() {
     let deleteRoomid = document.getElementById("delete_roomid");
-    let rid = deleteRoomid.value;
+    let rid = deleteRoomid.value.trim();
     if (rid == "") {
         return;
     }
-    window.location.href = protocol + "//" + domain + ":" + port + '/deleteroom?roomid=' + rid;
+    window.location.href = protocol + "//" + domain + ":" + port + '/deleteroom?roomid=' + encodeURIComponent(rid);
 
     deleteRoomid.value = "";
 }